@@ -0,0 +1,85 @@
+// Package auth attaches credentials to requests made to optimus and manages
+// the tokens cached by mirage login.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// ErrNotLoggedIn is returned when a profile needs a token which is not cached
+var ErrNotLoggedIn = errors.New("not logged in, run mirage login")
+
+// New creates the authenticator configured for the profile, nil is
+// returned when the profile does not use auth
+func New(cfg *config.AuthConfig, cache *Cache, profile string) (optimus.Authenticator, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Type {
+	case config.AuthBearer:
+		token := secret(cfg.Token, cfg.TokenEnv)
+		return &Bearer{Token: token, cache: cache, profile: profile}, nil
+	case config.AuthBasic:
+		password := secret(cfg.Password, cfg.PasswordEnv)
+		if password == "" {
+			return nil, fmt.Errorf("basic auth requires a password or password_env")
+		}
+		return &Basic{Username: cfg.Username, Password: password}, nil
+	default:
+		return NewOIDC(cfg, cache, profile), nil
+	}
+}
+
+// secret returns the value, or the variable env when value is empty
+func secret(value, env string) string {
+	if value == "" && env != "" {
+		return os.Getenv(env)
+	}
+	return value
+}
+
+// Bearer sends a static token, falling back to the token saved by mirage login
+type Bearer struct {
+	Token string
+
+	cache   *Cache
+	profile string
+}
+
+func (b *Bearer) Authenticate(_ context.Context, req *http.Request) error {
+	token := b.Token
+	if token == "" {
+		cached, err := b.cache.Load(b.profile)
+		if err != nil {
+			return err
+		}
+		if cached == nil {
+			return ErrNotLoggedIn
+		}
+		token = cached.AccessToken
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Basic sends a username and password with every request
+type Basic struct {
+	Username string
+	Password string
+}
+
+func (b *Basic) Authenticate(_ context.Context, req *http.Request) error {
+	req.SetBasicAuth(b.Username, b.Password)
+	return nil
+}
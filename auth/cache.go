@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	tokensDir = "tokens"
+
+	// expiryDelta renews tokens shortly before they expire, so they do not
+	// expire while a request is in flight
+	expiryDelta = 30 * time.Second
+)
+
+// Token is a token saved by mirage login
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports if the token can be used without refreshing it
+func (t *Token) Valid(now time.Time) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || now.Add(expiryDelta).Before(t.Expiry)
+}
+
+// Cache keeps a token for every profile in its own file below dir
+type Cache struct {
+	dir string
+}
+
+// NewCache creates a cache in the tokens directory of the mirage config dir
+func NewCache(configDir string) *Cache {
+	return &Cache{dir: filepath.Join(configDir, tokensDir)}
+}
+
+func (c *Cache) path(profile string) string {
+	return filepath.Join(c.dir, profile+".json")
+}
+
+// Load returns the token of the profile, nil when there is none
+func (c *Cache) Load(profile string) (*Token, error) {
+	content, err := os.ReadFile(c.path(profile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var t Token
+	if err := json.Unmarshal(content, &t); err != nil {
+		return nil, fmt.Errorf("invalid token cache %s: %w", c.path(profile), err)
+	}
+	return &t, nil
+}
+
+// Save stores the token of the profile, readable only by the user
+func (c *Cache) Save(profile string, t *Token) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path(profile), content, 0o600)
+}
+
+// Delete removes the token of the profile and reports if there was one
+func (c *Cache) Delete(profile string) (bool, error) {
+	err := os.Remove(c.path(profile))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sbchaos/mirage/config"
+)
+
+const (
+	deviceGrantType  = "urn:ietf:params:oauth:grant-type:device_code"
+	refreshGrantType = "refresh_token"
+
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// slowDownStep is added to the polling interval when the provider asks to slow down
+	slowDownStep = 5 * time.Second
+)
+
+// DeviceCode is shown to the user to approve the login in a browser
+type DeviceCode struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// tokenResponse is the response of the token endpoint, errors included
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (r *tokenResponse) err() error {
+	if r.ErrorDescription != "" {
+		return fmt.Errorf("%s: %s", r.Error, r.ErrorDescription)
+	}
+	return fmt.Errorf("%s", r.Error)
+}
+
+func (r *tokenResponse) token(now time.Time) *Token {
+	t := &Token{AccessToken: r.AccessToken, RefreshToken: r.RefreshToken, TokenType: r.TokenType}
+	if r.ExpiresIn > 0 {
+		t.Expiry = now.Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	return t
+}
+
+// OIDC authenticates with tokens from the OAuth2 device authorization flow,
+// refreshing them when they expire
+type OIDC struct {
+	cfg     *config.AuthConfig
+	cache   *Cache
+	profile string
+
+	httpClient *http.Client
+
+	mu             sync.Mutex
+	token          *Token
+	loaded         bool
+	deviceEndpoint string
+	tokenEndpoint  string
+}
+
+func NewOIDC(cfg *config.AuthConfig, cache *Cache, profile string) *OIDC {
+	return &OIDC{
+		cfg:            cfg,
+		cache:          cache,
+		profile:        profile,
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+		deviceEndpoint: cfg.DeviceEndpoint,
+		tokenEndpoint:  cfg.TokenEndpoint,
+	}
+}
+
+func (o *OIDC) Authenticate(ctx context.Context, req *http.Request) error {
+	t, err := o.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.AccessToken)
+	return nil
+}
+
+// Token returns the cached token, refreshing it when it expired
+func (o *OIDC) Token(ctx context.Context) (*Token, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.loaded {
+		t, err := o.cache.Load(o.profile)
+		if err != nil {
+			return nil, err
+		}
+		o.token = t
+		o.loaded = true
+	}
+	if o.token == nil {
+		return nil, ErrNotLoggedIn
+	}
+	if o.token.Valid(time.Now()) {
+		return o.token, nil
+	}
+	if o.token.RefreshToken == "" {
+		return nil, fmt.Errorf("token expired, run mirage login")
+	}
+
+	t, err := o.refresh(ctx, o.token.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("refreshing token: %w, run mirage login", err)
+	}
+	if t.RefreshToken == "" {
+		t.RefreshToken = o.token.RefreshToken
+	}
+	if err := o.cache.Save(o.profile, t); err != nil {
+		return nil, err
+	}
+	o.token = t
+	return t, nil
+}
+
+// Login runs the device authorization flow, prompt is called with the code
+// the user has to approve, the token is saved once approved
+func (o *OIDC) Login(ctx context.Context, prompt func(*DeviceCode)) error {
+	if err := o.discover(ctx); err != nil {
+		return err
+	}
+
+	form := url.Values{"client_id": {o.cfg.ClientID}}
+	scopes := o.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "offline_access"}
+	}
+	form.Set("scope", strings.Join(scopes, " "))
+	if o.cfg.Audience != "" {
+		form.Set("audience", o.cfg.Audience)
+	}
+
+	var code DeviceCode
+	if err := o.postForm(ctx, o.deviceEndpoint, form, &code); err != nil {
+		return fmt.Errorf("requesting device code: %w", err)
+	}
+	if code.DeviceCode == "" {
+		return fmt.Errorf("requesting device code: no device code in response")
+	}
+	prompt(&code)
+
+	t, err := o.poll(ctx, &code)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.token, o.loaded = t, true
+	return o.cache.Save(o.profile, t)
+}
+
+// poll asks for the token until the user approved or denied the login
+func (o *OIDC) poll(ctx context.Context, code *DeviceCode) (*Token, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = slowDownStep
+	}
+	expiresIn := time.Duration(code.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 10 * time.Minute
+	}
+	deadline := time.Now().Add(expiresIn)
+
+	form := url.Values{
+		"grant_type":  {deviceGrantType},
+		"device_code": {code.DeviceCode},
+		"client_id":   {o.cfg.ClientID},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("login expired before it was approved")
+		}
+
+		var resp tokenResponse
+		if err := o.postForm(ctx, o.tokenEndpoint, form, &resp); err != nil {
+			return nil, err
+		}
+		switch resp.Error {
+		case "":
+			return resp.token(time.Now()), nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownStep
+		case "access_denied":
+			return nil, fmt.Errorf("login was denied")
+		case "expired_token":
+			return nil, fmt.Errorf("login expired before it was approved")
+		default:
+			return nil, resp.err()
+		}
+	}
+}
+
+func (o *OIDC) refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	if err := o.discover(ctx); err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {refreshGrantType},
+		"refresh_token": {refreshToken},
+		"client_id":     {o.cfg.ClientID},
+	}
+	var resp tokenResponse
+	if err := o.postForm(ctx, o.tokenEndpoint, form, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, resp.err()
+	}
+	return resp.token(time.Now()), nil
+}
+
+// discover looks up the endpoints of the provider when they are not configured
+func (o *OIDC) discover(ctx context.Context) error {
+	if o.deviceEndpoint != "" && o.tokenEndpoint != "" {
+		return nil
+	}
+
+	endpoint := strings.TrimSuffix(o.cfg.Issuer, "/") + discoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("discovering oidc endpoints: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discovering oidc endpoints: %s returned %s", endpoint, resp.Status)
+	}
+
+	var doc struct {
+		DeviceEndpoint string `json:"device_authorization_endpoint"`
+		TokenEndpoint  string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("discovering oidc endpoints: %w", err)
+	}
+	if o.deviceEndpoint == "" {
+		o.deviceEndpoint = doc.DeviceEndpoint
+	}
+	if o.tokenEndpoint == "" {
+		o.tokenEndpoint = doc.TokenEndpoint
+	}
+	if o.deviceEndpoint == "" || o.tokenEndpoint == "" {
+		return fmt.Errorf("%s does not support the device authorization flow", o.cfg.Issuer)
+	}
+	return nil
+}
+
+// postForm posts the form and decodes the json response. OAuth2 errors are
+// answered with 400, they are decoded into a tokenResponse and returned as
+// error for other responses.
+func (o *OIDC) postForm(ctx context.Context, endpoint string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr tokenResponse
+		if json.Unmarshal(payload, &oauthErr) != nil || oauthErr.Error == "" {
+			return fmt.Errorf("%s returned %s", endpoint, resp.Status)
+		}
+		if tr, ok := out.(*tokenResponse); ok {
+			*tr = oauthErr
+			return nil
+		}
+		return oauthErr.err()
+	}
+	if err := json.Unmarshal(payload, out); err != nil {
+		return fmt.Errorf("decoding response of %s: %w", endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// Package bulk applies the same change to the specs of many jobs, either to
+// the job.yaml files of a local repository or to the specs on the server.
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+)
+
+// Edit holds the fields set on every selected job
+type Edit struct {
+	Owner string
+
+	// Labels are added or replaced, RemoveLabels are deleted
+	Labels       map[string]string
+	RemoveLabels []string
+
+	// Channels replace the channels of the notifier of an event, the
+	// notifier is added when the job has none for the event
+	Channels map[string][]string
+}
+
+// Empty reports whether the edit changes nothing
+func (e Edit) Empty() bool {
+	return e.Owner == "" && len(e.Labels) == 0 && len(e.RemoveLabels) == 0 && len(e.Channels) == 0
+}
+
+// Apply changes the job in place
+func (e Edit) Apply(j *spec.Job) {
+	if e.Owner != "" {
+		j.Owner = e.Owner
+	}
+	if len(e.Labels) > 0 || len(e.RemoveLabels) > 0 {
+		// labels may be shared with the spec the job was converted from
+		labels := map[string]string{}
+		for k, v := range j.Labels {
+			labels[k] = v
+		}
+		for k, v := range e.Labels {
+			labels[k] = v
+		}
+		for _, k := range e.RemoveLabels {
+			delete(labels, k)
+		}
+		j.Labels = labels
+		if len(labels) == 0 {
+			j.Labels = nil
+		}
+	}
+
+	events := make([]string, 0, len(e.Channels))
+	for on := range e.Channels {
+		events = append(events, on)
+	}
+	sort.Strings(events)
+	for _, on := range events {
+		channels := append([]string{}, e.Channels[on]...)
+		found := false
+		for i := range j.Behavior.Notify {
+			if j.Behavior.Notify[i].On == on {
+				j.Behavior.Notify[i].Channels = channels
+				found = true
+			}
+		}
+		if !found {
+			j.Behavior.Notify = append(j.Behavior.Notify, spec.Notifier{On: on, Channels: channels})
+		}
+	}
+}
+
+// ApplyFile makes the edit to the job.yaml of a local spec. Only the keys
+// the edit changes are rewritten, keys the spec does not know about, the
+// comments and the formatting of the rest of the file are kept.
+func (e Edit) ApplyFile(f *spec.File) error {
+	if e.Owner != "" && f.Job.Owner != e.Owner {
+		if err := f.Set(e.Owner, "owner"); err != nil {
+			return err
+		}
+	}
+	for _, k := range sortedKeys(e.Labels) {
+		if v, ok := f.Job.Labels[k]; ok && v == e.Labels[k] {
+			continue
+		}
+		if err := f.Set(e.Labels[k], "labels", k); err != nil {
+			return err
+		}
+	}
+	for _, k := range e.RemoveLabels {
+		if err := f.Delete("labels", k); err != nil {
+			return err
+		}
+	}
+
+	events := make([]string, 0, len(e.Channels))
+	for on := range e.Channels {
+		events = append(events, on)
+	}
+	sort.Strings(events)
+	for _, on := range events {
+		channels := e.Channels[on]
+		found := false
+		for i, n := range f.Job.Behavior.Notify {
+			if n.On != on {
+				continue
+			}
+			found = true
+			if equal(n.Channels, channels) {
+				continue
+			}
+			if err := f.Set(channels, "behavior", "notify", strconv.Itoa(i), "channels"); err != nil {
+				return err
+			}
+		}
+		if !found {
+			if err := f.Append(spec.Notifier{On: on, Channels: channels}, "behavior", "notify"); err != nil {
+				return err
+			}
+		}
+	}
+	return f.Save()
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary describes the edit in a line per changed field
+func (e Edit) Summary() []string {
+	var lines []string
+	if e.Owner != "" {
+		lines = append(lines, "owner = "+e.Owner)
+	}
+	for _, k := range sortedKeys(e.Labels) {
+		lines = append(lines, fmt.Sprintf("labels.%s = %s", k, e.Labels[k]))
+	}
+	for _, k := range e.RemoveLabels {
+		lines = append(lines, fmt.Sprintf("labels.%s removed", k))
+	}
+	events := make([]string, 0, len(e.Channels))
+	for on := range e.Channels {
+		events = append(events, on)
+	}
+	sort.Strings(events)
+	for _, on := range events {
+		lines = append(lines, fmt.Sprintf("behavior.notify.%s.channels = %s", on, strings.Join(e.Channels[on], ", ")))
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Target is a job the edit changes, with its spec before and after the edit
+type Target struct {
+	Namespace string
+	Before    optimus.JobSpec
+	After     optimus.JobSpec
+	Changes   []spec.Change
+
+	// File is the local spec of the job, nil when the job is edited on the server
+	File *spec.File
+
+	edit Edit
+}
+
+// Name returns the name of the job
+func (t *Target) Name() string {
+	return t.Before.Name
+}
+
+// Diff returns the changes of the target in the form used by the diff views
+func (t *Target) Diff() spec.JobDiff {
+	return spec.JobDiff{Name: t.Name(), Namespace: t.Namespace, Status: spec.ChangeModified, Changes: t.Changes}
+}
+
+// Apply writes the edited spec to its file, or deploys it when the target
+// has no local file
+func (t *Target) Apply(ctx context.Context, client *optimus.Client, project string) error {
+	if t.File != nil {
+		return t.edit.ApplyFile(t.File)
+	}
+	return client.DeployJobSpec(ctx, project, t.Namespace, t.After)
+}
+
+// Plan is the result of matching jobs and applying an edit to them
+type Plan struct {
+	Targets []*Target
+
+	// Unchanged is the number of matching jobs which already have the edit
+	Unchanged int
+}
+
+// PlanLocal applies the edit to the local specs matching the filter
+func PlanLocal(edit Edit, filter config.JobFilter, files []*spec.File) *Plan {
+	plan := &Plan{}
+	for _, f := range files {
+		before := f.Optimus()
+		plan.add(edit, filter, f.Namespace, before, f)
+	}
+	return plan
+}
+
+// PlanRemote applies the edit to the server specs matching the filter, specs
+// are given by namespace
+func PlanRemote(edit Edit, filter config.JobFilter, specs map[string][]optimus.JobSpec) *Plan {
+	namespaces := make([]string, 0, len(specs))
+	for ns := range specs {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	plan := &Plan{}
+	for _, ns := range namespaces {
+		for _, s := range specs[ns] {
+			plan.add(edit, filter, ns, s, nil)
+		}
+	}
+	return plan
+}
+
+func (p *Plan) add(edit Edit, filter config.JobFilter, namespace string, before optimus.JobSpec, f *spec.File) {
+	if !filter.Match(before.Name, before.Owner, before.Labels) {
+		return
+	}
+
+	// the edit works on a converted copy, Apply copies the maps it changes
+	job := spec.FromOptimus(before)
+	edit.Apply(job)
+	after := job.ToOptimus(before.Assets)
+
+	changes := spec.Diff(after, before)
+	if len(changes) == 0 {
+		p.Unchanged++
+		return
+	}
+	p.Targets = append(p.Targets, &Target{
+		Namespace: namespace,
+		Before:    before,
+		After:     after,
+		Changes:   changes,
+		File:      f,
+		edit:      edit,
+	})
+}
@@ -0,0 +1,153 @@
+package bulk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/spec"
+)
+
+const jobYAML = `# ingestion of orders
+version: 1
+name: orders
+owner: "team-a" # on call
+schedule:
+  start_date: "2022-01-01"
+  interval: 0 2 * * *
+
+behavior:
+  depends_on_past: false
+  notify:
+  - on: failure
+    channels:
+      - slack://#orders
+task:
+  name: bq2bq
+  config:
+    PROJECT: sample
+  window:
+    size: 24h
+    offset: 0
+    truncate_to: d
+resources:
+  request:
+    memory: 128Mi
+dependencies:
+  - http:
+      name: upstream
+      url: https://example.com/ready
+labels: {team: orders, tier: gold}
+`
+
+func writeSpec(t *testing.T, content string) *spec.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), spec.FileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := spec.LoadFile(path, "sample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func applyLocal(t *testing.T, edit Edit, f *spec.File) string {
+	t.Helper()
+	plan := PlanLocal(edit, config.JobFilter{}, []*spec.File{f})
+	if len(plan.Targets) != 1 {
+		t.Fatalf("got %d targets, want 1", len(plan.Targets))
+	}
+	if err := plan.Targets[0].Apply(context.Background(), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestApplyOwnerKeepsTheRestOfTheFile(t *testing.T) {
+	f := writeSpec(t, jobYAML)
+
+	got := applyLocal(t, Edit{Owner: "team-b"}, f)
+
+	want := strings.Replace(jobYAML, `owner: "team-a" # on call`, `owner: "team-b" # on call`, 1)
+	if got != want {
+		t.Errorf("file after edit:\n%s\nwant:\n%s", got, want)
+	}
+	if f.Job.Owner != "team-b" {
+		t.Errorf("owner of the loaded spec is %s, want team-b", f.Job.Owner)
+	}
+}
+
+func TestApplyFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edit    Edit
+		want    string
+	}{
+		{
+			name:    "label replaced in flow mapping",
+			content: "name: a\nowner: x\nlabels: {team: a, tier: gold}\nresources: {cpu: 1}\n",
+			edit:    Edit{Labels: map[string]string{"team": "b"}},
+			want:    "name: a\nowner: x\nlabels: {team: b, tier: gold}\nresources: {cpu: 1}\n",
+		},
+		{
+			name:    "label added to block mapping",
+			content: "name: a\nlabels:\n  team: a # owning team\nresources:\n  cpu: 1\n",
+			edit:    Edit{Labels: map[string]string{"tier": "gold"}},
+			want:    "name: a\nlabels:\n  team: a # owning team\n  tier: gold\nresources:\n  cpu: 1\n",
+		},
+		{
+			name:    "labels added to a spec without labels",
+			content: "name: a\nowner: x\n\n# kept\nresources:\n  cpu: 1\n",
+			edit:    Edit{Labels: map[string]string{"team": "a"}},
+			want:    "name: a\nowner: x\n\n# kept\nresources:\n  cpu: 1\nlabels:\n  team: a\n",
+		},
+		{
+			name:    "label removed",
+			content: "name: a\nlabels:\n  team: a\n  tier: gold\nhttp: {}\n",
+			edit:    Edit{RemoveLabels: []string{"team"}},
+			want:    "name: a\nlabels:\n  tier: gold\nhttp: {}\n",
+		},
+		{
+			name:    "last label removed along with labels",
+			content: "name: a\nlabels:\n  team: a\nhttp: {}\n",
+			edit:    Edit{RemoveLabels: []string{"team"}},
+			want:    "name: a\nhttp: {}\n",
+		},
+		{
+			name:    "channels of a notifier replaced",
+			content: "name: a\nbehavior:\n  notify:\n  - on: failure\n    channels: [slack://#a]\n    config: {x: y}\n",
+			edit:    Edit{Channels: map[string][]string{"failure": {"slack://#b"}}},
+			want:    "name: a\nbehavior:\n  notify:\n  - on: failure\n    channels: ['slack://#b']\n    config: {x: y}\n",
+		},
+		{
+			name:    "notifier appended",
+			content: "name: a\nbehavior:\n  notify:\n  - on: failure\n    channels:\n    - slack://#a\nresources: {}\n",
+			edit:    Edit{Channels: map[string][]string{"sla_miss": {"slack://#b"}}},
+			want:    "name: a\nbehavior:\n  notify:\n  - on: failure\n    channels:\n    - slack://#a\n  - \"on\": sla_miss\n    channels:\n      - slack://#b\nresources: {}\n",
+		},
+		{
+			name:    "notify added to behavior",
+			content: "name: a\nbehavior:\n  depends_on_past: true\n",
+			edit:    Edit{Channels: map[string][]string{"failure": {"slack://#a"}}},
+			want:    "name: a\nbehavior:\n  depends_on_past: true\n  notify:\n    - \"on\": failure\n      channels:\n        - slack://#a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := writeSpec(t, tt.content)
+			if got := applyLocal(t, tt.edit, f); got != tt.want {
+				t.Errorf("file after edit:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/bulk"
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/tui"
+)
+
+func NewCmdBulk() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bulk",
+		Short: "Change many jobs at once",
+	}
+	cmd.AddCommand(NewCmdBulkSet())
+	return cmd
+}
+
+type bulkSetOptions struct {
+	matchName   string
+	matchOwner  string
+	matchLabels map[string]string
+	all         bool
+
+	owner        string
+	labels       map[string]string
+	removeLabels []string
+	notify       []string
+
+	server      bool
+	interactive bool
+	dryRun      bool
+	yes         bool
+}
+
+func NewCmdBulkSet() *cobra.Command {
+	opts := &bulkSetOptions{}
+	cmd := &cobra.Command{
+		Use:   "set [paths]",
+		Short: "Set the owner, labels or notification channels of the matching jobs",
+		Long: "Set fields on every job matching the --match flags, or on every job with --all, and show the\n" +
+			"changes of each job before applying them. The job.yaml files under paths, or of every namespace\n" +
+			"in optimus.yaml, are edited in place keeping their comments. Without a local repository, or with\n" +
+			"--server, the specs of the namespace are edited and deployed on the server.",
+		Example: "mirage bulk set --match-owner old@example.com --owner new@example.com\n" +
+			"mirage bulk set --match-label team=growth --label team=acquisition --notify failure=slack://#acquisition -i\n" +
+			"mirage bulk set --match-name 'sample.daily_*' --remove-label legacy --server --yes",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runBulkSet(opts, args); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error editing jobs: %s", err)))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.matchName, "match-name", "", "Only edit jobs whose name matches the glob, eg. sample.daily_*")
+	cmd.Flags().StringVar(&opts.matchOwner, "match-owner", "", "Only edit jobs of the owner")
+	cmd.Flags().StringToStringVar(&opts.matchLabels, "match-label", nil, "Only edit jobs with the labels, eg. team=growth")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Edit every job, needed when no --match flag is given")
+	cmd.Flags().StringVar(&opts.owner, "owner", "", "Owner to set")
+	cmd.Flags().StringToStringVar(&opts.labels, "label", nil, "Labels to add or replace, eg. team=acquisition")
+	cmd.Flags().StringSliceVar(&opts.removeLabels, "remove-label", nil, "Labels to remove")
+	cmd.Flags().StringArrayVar(&opts.notify, "notify", nil, "Channels of the notifier of an event, eg. failure=slack://#team,pagerduty://team")
+	cmd.Flags().BoolVar(&opts.server, "server", false, "Edit the specs on the server even when there is a local repository")
+	cmd.Flags().BoolVarP(&opts.interactive, "interactive", "i", false, "Pick the jobs to edit in a terminal UI")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only show the changes")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Apply the changes without asking for confirmation")
+	return cmd
+}
+
+func (o *bulkSetOptions) edit() (bulk.Edit, error) {
+	edit := bulk.Edit{Owner: o.owner, Labels: o.labels, RemoveLabels: o.removeLabels}
+	for _, n := range o.notify {
+		on, value, ok := strings.Cut(n, "=")
+		if !ok || on == "" || value == "" {
+			return edit, fmt.Errorf("invalid --notify %s, use event=channel[,channel]", n)
+		}
+		if edit.Channels == nil {
+			edit.Channels = map[string][]string{}
+		}
+		for _, c := range strings.Split(value, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				edit.Channels[on] = append(edit.Channels[on], c)
+			}
+		}
+	}
+	for _, k := range o.removeLabels {
+		if _, ok := o.labels[k]; ok {
+			return edit, fmt.Errorf("label %s is both set and removed", k)
+		}
+	}
+	if edit.Empty() {
+		return edit, errors.New("nothing to set, use --owner, --label, --remove-label or --notify")
+	}
+	return edit, nil
+}
+
+func runBulkSet(opts *bulkSetOptions, paths []string) error {
+	edit, err := opts.edit()
+	if err != nil {
+		return err
+	}
+	filter := config.JobFilter{Name: opts.matchName, Owner: opts.matchOwner, Labels: opts.matchLabels}
+	if filter.Empty() && !opts.all {
+		return errors.New("no job selected, use --match-name, --match-owner, --match-label or --all")
+	}
+	if !filter.Empty() && opts.all {
+		return errors.New("--all cannot be combined with the --match flags")
+	}
+
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return err
+	}
+
+	remote := opts.server || (ctx.Optimus == nil && len(paths) == 0)
+	var client *optimus.Client
+	var plan *bulk.Plan
+	var title string
+	if remote {
+		if err := ctx.Validate(); err != nil {
+			return err
+		}
+		if client, err = newClient(ctx); err != nil {
+			return err
+		}
+		fetchCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		specs, err := client.ListJobSpecs(fetchCtx, ctx.Project, ctx.Namespace)
+		if err != nil {
+			return err
+		}
+		plan = bulk.PlanRemote(edit, filter, map[string][]optimus.JobSpec{ctx.Namespace: specs})
+		title = fmt.Sprintf("Edit jobs of %s/%s on %s", ctx.Project, ctx.Namespace, ctx.Host)
+	} else {
+		files, errs := loadSpecPaths(ctx, paths)
+		if len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Println(tui.RenderError(err.Error()))
+			}
+			return fmt.Errorf("%d job specs could not be read", len(errs))
+		}
+		if overrides.Namespace != "" {
+			kept := files[:0]
+			for _, f := range files {
+				if f.Namespace == overrides.Namespace {
+					kept = append(kept, f)
+				}
+			}
+			files = kept
+		}
+		plan = bulk.PlanLocal(edit, filter, files)
+		title = "Edit local job specs"
+	}
+
+	if len(plan.Targets) == 0 {
+		fmt.Println(tui.FeintStyle.Render(fmt.Sprintf("No job to change, %d matching jobs are already up to date", plan.Unchanged)))
+		return nil
+	}
+
+	targets := plan.Targets
+	if opts.interactive {
+		model, err := tui.NewBulkModel(title, edit, plan)
+		if err != nil {
+			return err
+		}
+		view, err := tui.NewSwitchableModel(model, client, ctx.Project, ctx.Namespace, ctx.Switch, openJobs(ctx, client))
+		if err != nil {
+			return err
+		}
+		if err := tea.NewProgram(view, tea.WithAltScreen()).Start(); err != nil {
+			return err
+		}
+		if view.Switched() {
+			// the edit was left for the jobs of another namespace
+			return nil
+		}
+		if targets = model.Selected(); len(targets) == 0 {
+			fmt.Println(tui.FeintStyle.Render("No job was changed"))
+			return nil
+		}
+	} else {
+		for _, t := range targets {
+			fmt.Print(tui.RenderJobDiff(t.Diff()))
+		}
+		fmt.Println()
+		if plan.Unchanged > 0 {
+			fmt.Println(tui.FeintStyle.Render(fmt.Sprintf("%d matching jobs are already up to date", plan.Unchanged)))
+		}
+		if opts.dryRun {
+			return nil
+		}
+		if !opts.yes {
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return errors.New("confirmation needs a terminal, use --yes to apply the changes")
+			}
+			if !confirm(fmt.Sprintf("Apply the changes to %d jobs? [y/N] ", len(targets))) {
+				fmt.Println(tui.FeintStyle.Render("No job was changed"))
+				return nil
+			}
+		}
+	}
+
+	if remote {
+		return deployTargets(ctx, client, targets)
+	}
+	return writeTargets(targets)
+}
+
+func writeTargets(targets []*bulk.Target) error {
+	failed := 0
+	for _, t := range targets {
+		if err := t.Apply(context.Background(), nil, ""); err != nil {
+			fmt.Println(tui.RenderError(fmt.Sprintf("%s: %s", t.Name(), err)))
+			failed++
+			continue
+		}
+		fmt.Println(tui.TextStyle.Copy().Foreground(tui.Green).Render("  ✓ ") + t.Name() + tui.FeintStyle.Render("  "+t.File.Path))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d job specs were not written", failed, len(targets))
+	}
+	fmt.Println(tui.FeintStyle.Render(fmt.Sprintf("%d job specs written, deploy them with mirage deploy", len(targets))))
+	return nil
+}
+
+func deployTargets(ctx *config.Context, client *optimus.Client, targets []*bulk.Target) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return deployTargetsPlain(client, ctx.Project, targets)
+	}
+
+	jobs := make([]tui.DeployJob, len(targets))
+	for i, t := range targets {
+		jobs[i] = tui.DeployJob{Namespace: t.Namespace, Spec: t.After}
+	}
+	model, err := tui.NewDeployModel(client, ctx.Project, jobs)
+	if err != nil {
+		return err
+	}
+	view, err := tui.NewSwitchableModel(model, client, ctx.Project, ctx.Namespace, ctx.Switch, nil)
+	if err != nil {
+		return err
+	}
+	if err := tea.NewProgram(view).Start(); err != nil {
+		return err
+	}
+	if failed := model.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d jobs were not deployed", failed, len(jobs))
+	}
+	return nil
+}
+
+// deployTargetsPlain deploys one job after the other with a line per job,
+// used when --yes applies the changes without a terminal like in CI
+func deployTargetsPlain(client *optimus.Client, project string, targets []*bulk.Target) error {
+	failed := 0
+	for _, t := range targets {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := t.Apply(ctx, client, project)
+		cancel()
+		if err != nil {
+			fmt.Println(tui.RenderError(fmt.Sprintf("%s: %s", t.Name(), err)))
+			failed++
+			continue
+		}
+		fmt.Println(tui.TextStyle.Copy().Foreground(tui.Green).Render("  ✓ ") + t.Name() + tui.FeintStyle.Render("  "+t.Namespace))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d jobs were not deployed", failed, len(targets))
+	}
+	return nil
+}
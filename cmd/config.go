@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/tui"
+)
+
+func NewCmdConfig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "config",
+		Short:   "Show the optimus server, project and namespace in use",
+		Example: "mirage config --profile staging",
+		Run:     runShowConfig,
+	}
+	return cmd
+}
+
+func runShowConfig(cmd *cobra.Command, args []string) {
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error loading config: %s", err)))
+		return
+	}
+
+	write := func(name, value string) {
+		if value == "" {
+			value = tui.FeintStyle.Render("not set")
+		}
+		fmt.Printf("%-10s %s\n", name+":", tui.BoldStyle.Render(value))
+	}
+	write("Profile", ctx.Profile)
+	write("Host", ctx.Host)
+	write("Project", ctx.Project)
+	write("Namespace", ctx.Namespace)
+	if ctx.Auth != nil {
+		write("Auth", ctx.Auth.Type)
+	}
+
+	if ctx.Optimus != nil {
+		fmt.Println("\n" + tui.FeintStyle.Render("Using "+config.OptimusFileName+" from the working directory"))
+	}
+	if names := ctx.Mirage.ProfileNames(); len(names) > 0 {
+		fmt.Println("\n" + tui.FeintStyle.Render(fmt.Sprintf("Available profiles: %v", names)))
+	}
+}
@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/congestion"
+	"github.com/sbchaos/mirage/spec"
 	"github.com/sbchaos/mirage/tui"
 )
 
@@ -21,7 +27,7 @@ func NewCmdCreate() *cobra.Command {
 }
 
 func runCreate(cmd *cobra.Command, args []string) {
-	model, err := tui.NewCreateModel()
+	model, err := tui.NewCreateModel(localScheduledJobs())
 	if err != nil {
 		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting create command: %s", err)) + "\n")
 		return
@@ -29,6 +35,40 @@ func runCreate(cmd *cobra.Command, args []string) {
 	if err := tea.NewProgram(model).Start(); err != nil {
 		log.Fatal(err)
 	}
+	if !model.Done() {
+		return
+	}
+
+	job := model.Job()
+	dir := filepath.Join(".", job.Name)
+	if err := writeJobSpec(dir, job); err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error writing job spec: %s", err)))
+		os.Exit(1)
+	}
+	fmt.Println(tui.BoldStyle.Copy().Foreground(tui.Green).Render(fmt.Sprintf("🎉 Done!  Your job has been created in ./%s", dir)))
+}
 
-	fmt.Println(tui.BoldStyle.Copy().Foreground(tui.Green).Render(fmt.Sprintf("🎉 Done!  Your job has been created in ./%s", "dir")))
+// localScheduledJobs returns the scheduled jobs of the optimus repository in
+// the working directory, nil outside of one
+func localScheduledJobs() []congestion.Job {
+	ctx, err := config.Resolve(overrides)
+	if err != nil || ctx.Optimus == nil {
+		return nil
+	}
+	files, _ := loadLocalSpecs(ctx)
+	return congestion.FromFiles(files)
+}
+
+// writeJobSpec writes the spec of a new job to dir, an existing spec is not replaced
+func writeJobSpec(dir string, job *spec.Job) error {
+	path := filepath.Join(dir, spec.FileName)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return (&spec.File{Path: path}).Update(job)
 }
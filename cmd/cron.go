@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	humancron "github.com/lnquy/cron"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/tui"
+)
+
+type cronOptions struct {
+	timezone string
+	locale   string
+	count    int
+	print    bool
+}
+
+func NewCmdCron() *cobra.Command {
+	opts := &cronOptions{}
+	cmd := &cobra.Command{
+		Use:   "cron [expression]",
+		Short: "Explore a cron expression",
+		Long: "Describe a cron expression and list its next and previous runs along with the gaps between\n" +
+			"them, warning about schedules which never fire or fire suspiciously often. The expression can\n" +
+			"be edited in a terminal UI, with --print or without a terminal the report is printed once.",
+		Example: "mirage cron\n" +
+			"mirage cron '*/7 * * * *' --print\n" +
+			"mirage cron 'CRON_TZ=Asia/Jakarta 30 6 * * 1-5' --locale de --count 10",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runCron(opts, strings.Join(args, "")); err != nil {
+				fmt.Println(tui.RenderError(err.Error()))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.timezone, "timezone", "Local", "IANA timezone to show the runs in, CRON_TZ= in the expression takes precedence")
+	cmd.Flags().StringVar(&opts.locale, "locale", "en", "Language of the description, eg. de, fr, ja")
+	cmd.Flags().IntVar(&opts.count, "count", 5, "Number of next and previous runs to show")
+	cmd.Flags().BoolVar(&opts.print, "print", false, "Print the report instead of starting the terminal UI")
+	return cmd
+}
+
+func runCron(opts *cronOptions, expr string) error {
+	loc, err := time.LoadLocation(opts.timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %s: %w", opts.timezone, err)
+	}
+	locale, err := humancron.ParseLocale(opts.locale)
+	if err != nil {
+		return fmt.Errorf("unknown locale %s", opts.locale)
+	}
+	if opts.count < 1 {
+		return errors.New("--count must be at least 1")
+	}
+	cronOpts := tui.CronOptions{Expression: expr, Timezone: loc, Locale: locale, Count: opts.count}
+
+	if opts.print || !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		if expr == "" {
+			return errors.New("an expression is required without a terminal")
+		}
+		report, err := tui.RenderCron(cronOpts, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid cron %s: %w", expr, err)
+		}
+		fmt.Print(report)
+		return nil
+	}
+
+	model, err := tui.NewCronModel(cronOpts)
+	if err != nil {
+		return err
+	}
+	return tea.NewProgram(model).Start()
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/reflow/truncate"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+	"github.com/sbchaos/mirage/tui"
+)
+
+type deployOptions struct {
+	dryRun bool
+}
+
+func NewCmdDeploy() *cobra.Command {
+	opts := &deployOptions{}
+	cmd := &cobra.Command{
+		Use:   "deploy [paths]",
+		Short: "Validate local job specs and deploy them to optimus",
+		Long: "Deploy the job specs found under paths, or the jobs of every namespace in optimus.yaml\n" +
+			"when no path is given. Nothing is deployed when any spec is invalid.",
+		Example: "mirage deploy\n" +
+			"mirage deploy jobs/sample.daily_report --dry-run",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDeploy(opts, args); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error deploying: %s", err)))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only validate the specs and show how they differ from the server")
+	return cmd
+}
+
+func runDeploy(opts *deployOptions, paths []string) error {
+	ctx, err := loadProjectContext()
+	if err != nil {
+		return err
+	}
+
+	files, errs := loadSpecPaths(ctx, paths)
+	for _, err := range errs {
+		fmt.Println(tui.RenderError(err.Error()))
+	}
+	diags := spec.ValidateAll(files)
+	for _, d := range diags {
+		fmt.Println(tui.RenderWarning(d.String()))
+	}
+	if len(errs) > 0 || len(diags) > 0 {
+		return fmt.Errorf("job specs have %d problems", len(errs)+len(diags))
+	}
+	if len(files) == 0 {
+		return errors.New("no job specs found")
+	}
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.dryRun {
+		return printDeployDiff(client, ctx.Project, files)
+	}
+
+	jobs := make([]tui.DeployJob, len(files))
+	for i, f := range files {
+		jobs[i] = tui.DeployJob{Namespace: f.Namespace, Spec: f.Optimus()}
+	}
+	model, err := tui.NewDeployModel(client, ctx.Project, jobs)
+	if err != nil {
+		return err
+	}
+	// the deployment keeps going to its project, picking a namespace only makes it the default
+	view, err := tui.NewSwitchableModel(model, client, ctx.Project, ctx.Namespace, ctx.Switch, nil)
+	if err != nil {
+		return err
+	}
+	if err := tea.NewProgram(view).Start(); err != nil {
+		return err
+	}
+	if failed := model.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d jobs were not deployed", failed, len(jobs))
+	}
+	return nil
+}
+
+// printDeployDiff lists the jobs which would be created or updated
+func printDeployDiff(client *optimus.Client, project string, files []*spec.File) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	fmt.Println(tui.BoldStyle.Render(fmt.Sprintf("%d valid job specs", len(files))))
+	for _, f := range files {
+		local := f.Optimus()
+		remote, err := client.GetJobSpec(ctx, project, f.Namespace, local.Name)
+		if errors.Is(err, optimus.ErrNotFound) {
+			fmt.Println(tui.TextStyle.Copy().Foreground(tui.Green).Render("  + " + local.Name + " (new)"))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		changes := spec.Diff(local, *remote)
+		if len(changes) == 0 {
+			fmt.Println(tui.FeintStyle.Render("  = " + local.Name + " (unchanged)"))
+			continue
+		}
+		fmt.Println(tui.TextStyle.Copy().Foreground(tui.Orange).Render(fmt.Sprintf("  ~ %s (%d changed fields)", local.Name, len(changes))))
+		for _, c := range changes {
+			fmt.Println(tui.FeintStyle.Render(fmt.Sprintf("      %s: %s → %s", c.Field, diffValue(c.Remote), diffValue(c.Local))))
+		}
+	}
+	return nil
+}
+
+// diffValue shortens a value to a single line, assets span many lines
+func diffValue(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	if i := strings.IndexByte(value, '\n'); i >= 0 {
+		value = value[:i] + " …"
+	}
+	return truncate.StringWithTail(value, 60, "…")
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/optimus/fake"
+	"github.com/sbchaos/mirage/tui"
+)
+
+type devServerOptions struct {
+	addr        string
+	fixtures    string
+	historyDays int
+	advance     time.Duration
+	replayStep  time.Duration
+	latency     time.Duration
+	errorRate   float64
+	faultPath   string
+	failDeploy  map[string]string
+	failReplay  map[string]string
+}
+
+func NewCmdDevServer() *cobra.Command {
+	opts := &devServerOptions{}
+	cmd := &cobra.Command{
+		Use:   "dev-server",
+		Short: "Run a local optimus server backed by fixtures for development and demos",
+		Long: "Run a local optimus server implementing the api used by mirage.\n" +
+			"Jobs are read from --fixtures, laid out as <project>/namespaces/<namespace>/<job>/job.yaml\n" +
+			"with run histories in <project>/runs/<job>.yaml. Without fixtures a sample project is served.\n" +
+			"Jobs without runs get a generated history, failures can be injected with flags or the /_dev endpoints.",
+		Example: "mirage dev-server\n" +
+			"mirage dev-server --fixtures ./fixtures --latency 300ms --error-rate 0.1\n" +
+			"mirage dev-server --fail-deploy sample.daily_report=\"quota exceeded\"",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDevServer(opts); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error running dev server: %s", err)))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.addr, "addr", "127.0.0.1:9100", "Address to listen on")
+	cmd.Flags().StringVar(&opts.fixtures, "fixtures", "", "Directory of fixture projects, defaults to a built-in sample")
+	cmd.Flags().IntVar(&opts.historyDays, "history-days", 14, "Days of runs generated for jobs without a run history, 0 to disable")
+	cmd.Flags().DurationVar(&opts.advance, "advance", 3*time.Second, "Interval at which running runs make progress, 0 to keep them running")
+	cmd.Flags().DurationVar(&opts.replayStep, "replay-step", 2*time.Second, "Time every replayed run takes")
+	cmd.Flags().DurationVar(&opts.latency, "latency", 0, "Delay added to every api response")
+	cmd.Flags().Float64Var(&opts.errorRate, "error-rate", 0, "Share of api requests failing with service unavailable, between 0 and 1")
+	cmd.Flags().StringVar(&opts.faultPath, "fault-path", "", "Only inject latency and errors into requests whose path contains this")
+	cmd.Flags().StringToStringVar(&opts.failDeploy, "fail-deploy", nil, "Fail deployments of a job with a message, eg. job=message")
+	cmd.Flags().StringToStringVar(&opts.failReplay, "fail-replay", nil, "Fail replayed runs of a job with a message, eg. job=message")
+
+	cmd.AddCommand(NewCmdDevServerInit())
+	return cmd
+}
+
+func NewCmdDevServerInit() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "init [dir]",
+		Short:   "Write the sample project as fixtures to start from",
+		Example: "mirage dev-server init ./fixtures",
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := "fixtures"
+			if len(args) > 0 {
+				dir = args[0]
+			}
+			if err := fake.WriteSample(dir); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error writing fixtures: %s", err)))
+				os.Exit(1)
+			}
+			fmt.Println("Fixtures written to " + tui.BoldStyle.Render(dir))
+			fmt.Println(tui.FeintStyle.Render("Serve them with: mirage dev-server --fixtures " + dir))
+		},
+	}
+	return cmd
+}
+
+func runDevServer(opts *devServerOptions) error {
+	if opts.errorRate < 0 || opts.errorRate > 1 {
+		return fmt.Errorf("error rate should be between 0 and 1")
+	}
+
+	server := fake.NewServer()
+	if opts.fixtures == "" {
+		server.LoadSample()
+	} else if err := server.LoadFixtures(opts.fixtures); err != nil {
+		return fmt.Errorf("loading fixtures: %w", err)
+	}
+	if opts.historyDays > 0 {
+		server.GenerateHistory(opts.historyDays, time.Now())
+	}
+	server.SetReplayStep(opts.replayStep)
+	server.SetFaults(fake.Faults{Latency: opts.latency, ErrorRate: opts.errorRate, PathFilter: opts.faultPath})
+	for job, msg := range opts.failDeploy {
+		server.FailDeploy(job, msg)
+	}
+	for job, msg := range opts.failReplay {
+		server.FailReplayRuns(job, msg)
+	}
+
+	listener, err := net.Listen("tcp", opts.addr)
+	if err != nil {
+		return err
+	}
+	httpServer := &http.Server{Handler: server}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if opts.advance > 0 {
+		go func() {
+			ticker := time.NewTicker(opts.advance)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case now := <-ticker.C:
+					server.Advance(now)
+				}
+			}
+		}()
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdown)
+	}()
+
+	printDevServerUsage("http://"+listener.Addr().String(), server.Namespaces())
+
+	if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func printDevServerUsage(host string, namespaces map[string][]string) {
+	fmt.Println("Serving optimus api on " + tui.BoldStyle.Render(host))
+	fmt.Println()
+
+	projects := make([]string, 0, len(namespaces))
+	for p := range namespaces {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	for _, p := range projects {
+		fmt.Printf("  %-20s  %s\n", p, tui.FeintStyle.Render(strings.Join(namespaces[p], ", ")))
+	}
+
+	if len(projects) > 0 && len(namespaces[projects[0]]) > 0 {
+		fmt.Println()
+		fmt.Println("Point mirage at it with:")
+		fmt.Printf("  mirage jobs --host %s --project %s --namespace %s\n", host, projects[0], namespaces[projects[0]][0])
+	}
+
+	fmt.Println()
+	fmt.Println("Inject failures while it runs:")
+	fmt.Printf("  curl -X POST %s/_dev/faults -d '{\"latency\":\"500ms\",\"error_rate\":0.2}'\n", host)
+	fmt.Printf("  curl -X POST %s/_dev/fail/deploy/<job> -d 'message'\n", host)
+	fmt.Printf("  curl -X POST %s/_dev/fail/replay/<job> -d 'message'\n", host)
+	fmt.Printf("  curl -X POST %s/_dev/reset\n", host)
+	fmt.Println()
+	fmt.Println(tui.FeintStyle.Render("Press ctrl+c to stop"))
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+	"github.com/sbchaos/mirage/tui"
+)
+
+type diffOptions struct {
+	output string
+}
+
+func NewCmdDiff() *cobra.Command {
+	opts := &diffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff [paths]",
+		Short: "Compare local job specs with the specs on the server",
+		Long: "Compare the job specs under paths, or of every namespace in optimus.yaml, field by field with\n" +
+			"the server. Jobs missing locally are only reported as removed when no path is given.",
+		Example: "mirage diff\n" +
+			"mirage diff jobs/sample.daily_report -o text\n" +
+			"mirage diff -o json | jq '.[] | select(.status != \"unchanged\")'",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runDiff(opts, args); err != nil {
+				fmt.Fprintln(os.Stderr, tui.RenderError(fmt.Sprintf("Error comparing job specs: %s", err)))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "tui", "Output format, one of tui, text, json")
+	return cmd
+}
+
+func runDiff(opts *diffOptions, paths []string) error {
+	switch opts.output {
+	case "tui", "text", "json":
+	default:
+		return fmt.Errorf("unknown output %s, use one of tui, text, json", opts.output)
+	}
+
+	ctx, err := loadProjectContext()
+	if err != nil {
+		return err
+	}
+	files, errs := loadSpecPaths(ctx, paths)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, tui.RenderError(err.Error()))
+		}
+		return fmt.Errorf("%d job specs could not be read", len(errs))
+	}
+
+	local := map[string][]optimus.JobSpec{}
+	for _, f := range files {
+		local[f.Namespace] = append(local[f.Namespace], f.Optimus())
+	}
+	if len(paths) == 0 && ctx.Optimus != nil {
+		// namespaces without local specs still report their jobs as removed
+		for _, ns := range ctx.Optimus.Namespaces {
+			if _, ok := local[ns.Name]; !ok {
+				local[ns.Name] = nil
+			}
+		}
+	}
+	if overrides.Namespace != "" {
+		for ns := range local {
+			if ns != overrides.Namespace {
+				delete(local, ns)
+			}
+		}
+	}
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	diffs, err := compareSpecs(client, ctx.Project, local, len(paths) == 0)
+	if err != nil {
+		return err
+	}
+
+	switch opts.output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+	case "text":
+		for _, d := range diffs {
+			if d.Status != spec.ChangeUnchanged {
+				fmt.Print(tui.RenderJobDiff(d))
+			}
+		}
+		return nil
+	}
+
+	model, err := tui.NewDiffModel("Changes in "+ctx.Project, diffs)
+	if err != nil {
+		return err
+	}
+	view, err := tui.NewSwitchableModel(model, client, ctx.Project, ctx.Namespace, ctx.Switch, openJobs(ctx, client))
+	if err != nil {
+		return err
+	}
+	if err := tea.NewProgram(view, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+	return nil
+}
+
+// compareSpecs fetches the specs of every namespace and compares them with
+// the local ones, removed jobs are left out unless includeRemoved is set
+func compareSpecs(client *optimus.Client, project string, local map[string][]optimus.JobSpec, includeRemoved bool) ([]spec.JobDiff, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	namespaces := make([]string, 0, len(local))
+	for ns := range local {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	diffs := []spec.JobDiff{}
+	for _, ns := range namespaces {
+		remote, err := client.ListJobSpecs(ctx, project, ns)
+		if err != nil {
+			return nil, fmt.Errorf("fetching specs of %s: %w", ns, err)
+		}
+		for _, d := range spec.CompareNamespace(ns, local[ns], remote) {
+			if d.Status == spec.ChangeRemoved && !includeRemoved {
+				continue
+			}
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs, nil
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/graph"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/tui"
+)
+
+const (
+	graphSourceServer = "server"
+	graphSourceLocal  = "local"
+)
+
+type graphOptions struct {
+	depth  int
+	source string
+}
+
+func NewCmdGraph() *cobra.Command {
+	opts := &graphOptions{}
+	cmd := &cobra.Command{
+		Use:   "graph <job>",
+		Short: "Explore the upstream and downstream dependencies of a job",
+		Example: "mirage graph sample.daily_report --depth 2\n" +
+			"mirage graph sample.daily_report --source local",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runGraph(opts, args[0])
+		},
+	}
+	cmd.Flags().IntVar(&opts.depth, "depth", 2, "Levels of dependencies expanded when the explorer opens")
+	cmd.Flags().StringVar(&opts.source, "source", graphSourceServer, "Where to resolve dependencies from, one of server, local")
+	cmd.AddCommand(NewCmdGraphExport())
+	return cmd
+}
+
+func runGraph(opts *graphOptions, name string) {
+	if opts.depth < 1 {
+		fmt.Println(tui.RenderError("Depth must be at least 1") + "\n")
+		return
+	}
+
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting graph command: %s", err)) + "\n")
+		return
+	}
+
+	g, err := loadGraph(ctx, opts.source)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting graph command: %s", err)) + "\n")
+		return
+	}
+
+	model, err := tui.NewGraphModel(g, tui.GraphOptions{
+		Project: ctx.Project,
+		Job:     name,
+		Depth:   opts.depth,
+		Source:  opts.source,
+	})
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting graph command: %s", err)) + "\n")
+		return
+	}
+
+	// the switcher needs the server, which local graphs may go without
+	var client *optimus.Client
+	if ctx.Host != "" {
+		if client, err = newClient(ctx); err != nil {
+			fmt.Println(tui.RenderError(fmt.Sprintf("Error starting graph command: %s", err)) + "\n")
+			return
+		}
+	}
+	view, err := tui.NewSwitchableModel(model, client, ctx.Project, ctx.Namespace, ctx.Switch, openJobs(ctx, client))
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting graph command: %s", err)) + "\n")
+		return
+	}
+	if err := tea.NewProgram(view, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadGraph builds the dependency graph of the project from the server or
+// from the specs in the optimus repository of the working directory
+func loadGraph(ctx *config.Context, source string) (*graph.Graph, error) {
+	switch source {
+	case graphSourceLocal:
+		if ctx.Project == "" {
+			return nil, fmt.Errorf("optimus project is not configured, use --project or a profile")
+		}
+		files, errs := loadLocalSpecs(ctx)
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, tui.RenderWarning(err.Error()))
+		}
+		if len(files) == 0 && len(errs) > 0 {
+			return nil, errs[0]
+		}
+		return graph.LoadLocal(ctx.Project, files), nil
+	case graphSourceServer:
+		// every namespace is read, the namespace of the context is not required
+		if err := ctx.ValidateProject(); err != nil {
+			return nil, err
+		}
+		client, err := newClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		reqCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		g, err := graph.LoadProject(reqCtx, client, ctx.Project)
+		if err != nil {
+			return nil, err
+		}
+		if err := graph.LoadExternal(reqCtx, client, g); err != nil {
+			fmt.Fprintln(os.Stderr, tui.RenderWarning(fmt.Sprintf("Dependencies in other projects are not resolved: %s", err)))
+		}
+		return g, nil
+	}
+	return nil, fmt.Errorf("unknown source %s, use one of server, local", source)
+}
+
+type graphExportOptions struct {
+	format        string
+	source        string
+	allNamespaces bool
+}
+
+func NewCmdGraphExport() *cobra.Command {
+	opts := &graphExportOptions{}
+	cmd := &cobra.Command{
+		Use:   "export [job]",
+		Short: "Export the dependency graph of a job, a namespace or a project",
+		Long: "Export the dependency graph as a diagram. With a job, the job and everything it is connected to\n" +
+			"is exported, otherwise the jobs of the namespace along with their upstream jobs, or the jobs of\n" +
+			"the whole project with --all-namespaces or when no namespace is configured.",
+		Example: "mirage graph export sample.daily_report --format mermaid\n" +
+			"mirage graph export --all-namespaces --format dot | dot -Tsvg > graph.svg",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runGraphExport(opts, args)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.format, "format", "f", graph.FormatDOT, "Format of the export, one of dot, mermaid, json")
+	cmd.Flags().StringVar(&opts.source, "source", graphSourceServer, "Where to resolve dependencies from, one of server, local")
+	cmd.Flags().BoolVar(&opts.allNamespaces, "all-namespaces", false, "Export every namespace of the project")
+	return cmd
+}
+
+func runGraphExport(opts *graphExportOptions, args []string) {
+	switch opts.format {
+	case graph.FormatDOT, graph.FormatMermaid, graph.FormatJSON:
+	default:
+		fmt.Fprintln(os.Stderr, tui.RenderError(fmt.Sprintf("Unknown format %s, use one of dot, mermaid, json", opts.format))+"\n")
+		os.Exit(1)
+	}
+
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tui.RenderError(fmt.Sprintf("Error exporting graph: %s", err))+"\n")
+		os.Exit(1)
+	}
+
+	g, err := loadGraph(ctx, opts.source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, tui.RenderError(fmt.Sprintf("Error exporting graph: %s", err))+"\n")
+		os.Exit(1)
+	}
+
+	var name string
+	var ids []string
+	switch {
+	case len(args) == 1:
+		root := args[0]
+		if g.Node(root) == nil {
+			root = graph.ID(ctx.Project, args[0])
+		}
+		if g.Node(root) == nil {
+			fmt.Fprintln(os.Stderr, tui.RenderError(fmt.Sprintf("Job %s not found", args[0]))+"\n")
+			os.Exit(1)
+		}
+		name = root
+		ids = append(ids, root)
+		ids = append(ids, g.AllUpstream(root)...)
+		ids = append(ids, g.AllDownstream(root)...)
+	case opts.allNamespaces || ctx.Namespace == "":
+		name = ctx.Project
+		for _, n := range g.Nodes() {
+			ids = append(ids, n.ID())
+			ids = append(ids, g.Upstream(n.ID())...)
+		}
+	default:
+		name = ctx.Project + "/" + ctx.Namespace
+		for _, n := range g.Nodes() {
+			if n.Project == ctx.Project && n.Namespace == ctx.Namespace {
+				ids = append(ids, n.ID())
+				ids = append(ids, g.Upstream(n.ID())...)
+			}
+		}
+	}
+
+	if err := g.NewExport(name, ids).Write(os.Stdout, opts.format); err != nil {
+		fmt.Fprintln(os.Stderr, tui.RenderError(fmt.Sprintf("Error exporting graph: %s", err))+"\n")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/congestion"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/tui"
+)
+
+type heatmapOptions struct {
+	period   string
+	slot     string
+	timezone string
+	source   string
+	suggest  string
+	print    bool
+}
+
+func NewCmdHeatmap() *cobra.Command {
+	opts := &heatmapOptions{}
+	cmd := &cobra.Command{
+		Use:   "heatmap",
+		Short: "Show how many job runs start in each minute or hour",
+		Long: "Expand the schedule of every job of the project over a day or a week and show a heatmap of\n" +
+			"the runs starting in each slot, to find the times the scheduler is swamped. With --suggest,\n" +
+			"less congested variants of a cron expression are listed instead.",
+		Example: "mirage heatmap\n" +
+			"mirage heatmap --period week --slot hour --source local\n" +
+			"mirage heatmap --suggest '0 2 * * *'",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runHeatmap(opts); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error building heatmap: %s", err)))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.period, "period", congestion.PeriodDay, "Period to expand schedules over, one of day, week")
+	cmd.Flags().StringVar(&opts.slot, "slot", "", "Slots to count runs in, one of minute, hour (default minute for a day, hour for a week)")
+	cmd.Flags().StringVar(&opts.timezone, "timezone", "UTC", "IANA timezone to show the slots in")
+	cmd.Flags().StringVar(&opts.source, "source", graphSourceServer, "Where to read the jobs from, one of server, local")
+	cmd.Flags().StringVar(&opts.suggest, "suggest", "", "Cron expression of a new job to propose less congested slots for")
+	cmd.Flags().BoolVar(&opts.print, "print", false, "Print the heatmap instead of starting the terminal UI")
+	return cmd
+}
+
+func runHeatmap(opts *heatmapOptions) error {
+	loc, err := time.LoadLocation(opts.timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone %s: %w", opts.timezone, err)
+	}
+	if opts.period != congestion.PeriodDay && opts.period != congestion.PeriodWeek {
+		return fmt.Errorf("unknown period %s, use one of day, week", opts.period)
+	}
+	if opts.slot == "" {
+		opts.slot = congestion.SlotMinute
+		if opts.period == congestion.PeriodWeek {
+			opts.slot = congestion.SlotHour
+		}
+	}
+	slot, err := congestion.SlotDuration(opts.slot)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return err
+	}
+	jobs, client, err := loadScheduledJobs(ctx, opts.source)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return errors.New("no scheduled job found")
+	}
+
+	if opts.suggest != "" {
+		return printSuggestions(jobs, opts.suggest, loc)
+	}
+
+	if opts.print || !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		start := congestion.PeriodStart(time.Now().In(loc), opts.period)
+		h, err := congestion.New(jobs, start, opts.period, slot)
+		if err != nil {
+			return err
+		}
+		fmt.Print(tui.RenderHeatmap(h, 10))
+		return nil
+	}
+
+	model, err := tui.NewHeatmapModel(jobs, tui.HeatmapOptions{
+		Project:   ctx.Project,
+		Namespace: ctx.Namespace,
+		Period:    opts.period,
+		Slot:      opts.slot,
+		Timezone:  loc,
+		Client:    client,
+		OnSwitch:  ctx.Switch,
+	})
+	if err != nil {
+		return err
+	}
+	return tea.NewProgram(model, tea.WithAltScreen()).Start()
+}
+
+// printSuggestions lists variants of an interval starting in less congested
+// minutes over the coming week
+func printSuggestions(jobs []congestion.Job, interval string, loc *time.Location) error {
+	start := congestion.PeriodStart(time.Now().In(loc), congestion.PeriodWeek)
+	h, err := congestion.New(jobs, start, congestion.PeriodWeek, time.Minute)
+	if err != nil {
+		return err
+	}
+	current, err := h.Score(interval)
+	if err != nil {
+		return fmt.Errorf("invalid cron %s: %w", interval, err)
+	}
+	suggestions, err := h.Suggest(interval, 5)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s starts with up to %s of other jobs in the same minute\n",
+		tui.BoldStyle.Render(interval), tui.BoldStyle.Render(fmt.Sprintf("%d runs", current)))
+	if len(suggestions) == 0 {
+		fmt.Println(tui.FeintStyle.Render("No less congested minute found nearby"))
+		return nil
+	}
+	fmt.Println()
+	for _, s := range suggestions {
+		fmt.Printf("  %-36s %s\n", s.Interval, tui.FeintStyle.Render(fmt.Sprintf("%d runs, %+dm", s.Runs, int(s.Shift/time.Minute))))
+	}
+	return nil
+}
+
+// loadScheduledJobs reads the jobs of every namespace from the server or the
+// optimus repository of the working directory, along with the client of the
+// server when they are read from it
+func loadScheduledJobs(ctx *config.Context, source string) ([]congestion.Job, *optimus.Client, error) {
+	switch source {
+	case graphSourceLocal:
+		files, errs := loadLocalSpecs(ctx)
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, tui.RenderWarning(err.Error()))
+		}
+		if len(files) == 0 && len(errs) > 0 {
+			return nil, nil, errs[0]
+		}
+		return congestion.FromFiles(files), nil, nil
+	case graphSourceServer:
+		// every namespace is read, the namespace of the context is not required
+		if err := ctx.ValidateProject(); err != nil {
+			return nil, nil, err
+		}
+		client, err := newClient(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		reqCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		jobs, err := congestion.Load(reqCtx, client, ctx.Project)
+		return jobs, client, err
+	}
+	return nil, nil, fmt.Errorf("unknown source %s, use one of server, local", source)
+}
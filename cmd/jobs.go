@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/notify"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/tui"
+)
+
+func NewCmdJobs() *cobra.Command {
+	var interval time.Duration
+	var offline bool
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Browse and watch the jobs of a namespace",
+		Example: "mirage jobs --namespace finance --interval 1m\n" +
+			"mirage jobs --offline",
+		Run: func(cmd *cobra.Command, args []string) {
+			runJobs(cmd, interval, offline)
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", 0, "Polling interval for changes, 0 disables watching (default from config or 30s)")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Only show the jobs and runs cached by the last fetch")
+	return cmd
+}
+
+func runJobs(cmd *cobra.Command, interval time.Duration, offline bool) {
+	ctx, err := loadContext()
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting jobs command: %s", err)) + "\n")
+		return
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting jobs command: %s", err)) + "\n")
+		return
+	}
+
+	if !cmd.Flags().Changed("interval") {
+		interval = ctx.Mirage.WatchInterval()
+	}
+	if offline {
+		interval = 0
+	}
+
+	model, err := newJobsModel(ctx, client, interval, offline)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting jobs command: %s", err)) + "\n")
+		return
+	}
+	if err := tea.NewProgram(model, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newJobsModel builds the jobs view of the namespace of the context
+func newJobsModel(ctx *config.Context, client *optimus.Client, interval time.Duration, offline bool) (tea.Model, error) {
+	snapshots, err := newSnapshotStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return tui.NewJobsModel(client, tui.JobsOptions{
+		Project:   ctx.Project,
+		Namespace: ctx.Namespace,
+		Refresh:   interval,
+		OnSwitch:  ctx.Switch,
+		Snapshots: snapshots,
+		Offline:   offline,
+		Notifier:  notify.New(ctx.Mirage.Notify, ctx.Host),
+	})
+}
+
+// openJobs replaces the views started for a single job, run or replay with
+// the jobs of the namespace picked in their switcher
+func openJobs(ctx *config.Context, client *optimus.Client) tui.ReloadFunc {
+	return func(project, namespace string) (tea.Model, error) {
+		picked := *ctx
+		picked.Project = project
+		picked.Namespace = namespace
+		return newJobsModel(&picked, client, ctx.Mirage.WatchInterval(), false)
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/lint"
+	"github.com/sbchaos/mirage/tui"
+)
+
+type lintOptions struct {
+	configPath string
+	failOn     string
+	listRules  bool
+}
+
+func NewCmdLint() *cobra.Command {
+	opts := &lintOptions{}
+	cmd := &cobra.Command{
+		Use:   "lint [paths]",
+		Short: "Check local job specs against the conventions of the team",
+		Long: "Lint the job specs found under paths, or the jobs of every namespace in optimus.yaml when\n" +
+			"no path is given. Rules are tuned and custom rules are declared in " + lint.ConfigFileName + ".\n" +
+			"A finding is suppressed by a comment on its line or the line above, like\n" +
+			"  # mirage:ignore owner-email\n" +
+			"or for the whole file with\n" +
+			"  # mirage:ignore-file cron-minute-zero",
+		Example: "mirage lint\n" +
+			"mirage lint jobs/sample.daily_report --fail-on warning\n" +
+			"mirage lint --rules",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runLint(opts, args); err != nil {
+				fmt.Println(tui.RenderError(err.Error()))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.configPath, "config", lint.ConfigFileName, "Lint config with rule settings and custom rules")
+	cmd.Flags().StringVar(&opts.failOn, "fail-on", lint.SeverityError, "Lowest severity of findings which fail the command: error, warning or info")
+	cmd.Flags().BoolVar(&opts.listRules, "rules", false, "List the enabled rules and exit")
+	return cmd
+}
+
+func runLint(opts *lintOptions, paths []string) error {
+	if !lint.ValidSeverity(opts.failOn) {
+		return fmt.Errorf("--fail-on should be one of error, warning, info")
+	}
+
+	cfg, err := lint.LoadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+	if cfg == nil && opts.configPath != lint.ConfigFileName {
+		return fmt.Errorf("lint config %s not found", opts.configPath)
+	}
+	linter, err := lint.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	if opts.listRules {
+		for _, r := range linter.Rules() {
+			fmt.Printf("%-24s %-8s %s\n", r.Rule, r.Severity, r.Message)
+		}
+		return nil
+	}
+
+	// linting works offline, the host of the context is not required
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return err
+	}
+	files, errs := loadSpecPaths(ctx, paths)
+	for _, err := range errs {
+		fmt.Println(tui.RenderError(err.Error()))
+	}
+
+	findings, err := linter.Lint(files)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, f := range findings {
+		fmt.Println(f.String())
+		if lint.AtLeast(f.Severity, opts.failOn) {
+			failed++
+		}
+	}
+
+	if failed > 0 || len(errs) > 0 {
+		return fmt.Errorf("found %d problems of severity %s or higher in job specs", failed+len(errs), opts.failOn)
+	}
+	if len(files) == 0 {
+		return errors.New("no job specs found")
+	}
+	fmt.Println(tui.FeintStyle.Render(fmt.Sprintf("%d job specs linted, %d findings", len(files), len(findings))))
+	return nil
+}
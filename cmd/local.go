@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/spec"
+)
+
+// loadLocalSpecs reads the job specs of every namespace configured in
+// optimus.yaml, specs which fail to parse are returned as errors
+func loadLocalSpecs(ctx *config.Context) ([]*spec.File, []error) {
+	if ctx.Optimus == nil {
+		return nil, []error{fmt.Errorf("%s not found in the working directory", config.OptimusFileName)}
+	}
+
+	var files []*spec.File
+	var errs []error
+	for _, ns := range ctx.Optimus.Namespaces {
+		if ns.Job.Path == "" {
+			continue
+		}
+		nsFiles, nsErrs := spec.Load(ns.Job.Path, ns.Name)
+		files = append(files, nsFiles...)
+		errs = append(errs, nsErrs...)
+	}
+	return files, errs
+}
+
+// loadSpecPaths reads the job specs under paths, or every configured namespace
+// when no path is given. The namespace of a path is the namespace of optimus.yaml
+// whose job path contains it, falling back to the namespace of the context.
+func loadSpecPaths(ctx *config.Context, paths []string) ([]*spec.File, []error) {
+	if len(paths) == 0 {
+		return loadLocalSpecs(ctx)
+	}
+
+	var files []*spec.File
+	var errs []error
+	for _, path := range paths {
+		namespace := namespaceOf(ctx, path)
+		if namespace == "" {
+			errs = append(errs, fmt.Errorf("namespace of %s is not known, use --namespace", path))
+			continue
+		}
+		pathFiles, pathErrs := spec.Load(path, namespace)
+		if len(pathFiles) == 0 && len(pathErrs) == 0 {
+			pathErrs = append(pathErrs, fmt.Errorf("no %s found in %s", spec.FileName, path))
+		}
+		files = append(files, pathFiles...)
+		errs = append(errs, pathErrs...)
+	}
+	return files, errs
+}
+
+func namespaceOf(ctx *config.Context, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ctx.Namespace
+	}
+	if ctx.Optimus != nil {
+		for _, ns := range ctx.Optimus.Namespaces {
+			if ns.Job.Path == "" {
+				continue
+			}
+			dir, err := filepath.Abs(ns.Job.Path)
+			if err != nil {
+				continue
+			}
+			if abs == dir || strings.HasPrefix(abs, dir+string(filepath.Separator)) {
+				return ns.Name
+			}
+		}
+	}
+	return ctx.Namespace
+}
+
+// findLocalSpec loads the spec of a job by the path of its spec or of its
+// directory, or by its name among the specs of the optimus repository
+func findLocalSpec(ctx *config.Context, nameOrPath string) (*spec.File, error) {
+	if info, err := os.Stat(nameOrPath); err == nil {
+		path := nameOrPath
+		if info.IsDir() {
+			path = filepath.Join(path, spec.FileName)
+		}
+		return spec.LoadFile(path, namespaceOf(ctx, path))
+	}
+
+	files, errs := loadLocalSpecs(ctx)
+	for _, f := range files {
+		if f.Job.Name == nameOrPath {
+			return f, nil
+		}
+	}
+	if len(files) == 0 && len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("no job named %s in the optimus repository", nameOrPath)
+}
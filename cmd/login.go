@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/auth"
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/tui"
+)
+
+func NewCmdLogin() *cobra.Command {
+	var token string
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in to optimus with the auth of the profile and cache the token",
+		Long: "Log in to optimus with the auth configured in the profile.\n" +
+			"For oidc the device flow is started and the token is cached and refreshed under the config directory,\n" +
+			"for bearer auth without a configured token the given token is cached.",
+		Example: "mirage login --profile production\n" +
+			"mirage login --token $OPTIMUS_TOKEN",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runLogin(token); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error logging in: %s", err)))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&token, "token", "", "Token to cache for bearer auth, asked for when not given")
+	return cmd
+}
+
+func NewCmdLogout() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "logout",
+		Short:   "Remove the cached token of the profile",
+		Example: "mirage logout --profile production",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runLogout(); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error logging out: %s", err)))
+				os.Exit(1)
+			}
+		},
+	}
+	return cmd
+}
+
+func runLogin(token string) error {
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return err
+	}
+	if ctx.Auth == nil {
+		return fmt.Errorf("profile %s has no auth configured", ctx.ProfileName())
+	}
+	if err := ctx.Auth.Validate(); err != nil {
+		return err
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	cache := auth.NewCache(dir)
+
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch ctx.Auth.Type {
+	case config.AuthOIDC:
+		provider := auth.NewOIDC(ctx.Auth, cache, ctx.ProfileName())
+		err = provider.Login(signalCtx, func(code *auth.DeviceCode) {
+			uri := code.VerificationURIComplete
+			if uri == "" {
+				uri = code.VerificationURI
+			}
+			fmt.Println("Open " + tui.BoldStyle.Render(uri) + " in a browser and confirm the code " + tui.BoldStyle.Render(code.UserCode))
+			fmt.Println(tui.FeintStyle.Render("Waiting for the login to be approved..."))
+		})
+		if err != nil {
+			return err
+		}
+	case config.AuthBearer:
+		if ctx.Auth.Token != "" || ctx.Auth.TokenEnv != "" {
+			fmt.Println(tui.RenderWarning("The profile configures a token, the cached token is only used when it is empty"))
+		}
+		if token == "" {
+			if token, err = readToken(); err != nil {
+				return err
+			}
+		}
+		if token == "" {
+			return fmt.Errorf("token is empty")
+		}
+		if err := cache.Save(ctx.ProfileName(), &auth.Token{AccessToken: token, TokenType: "Bearer"}); err != nil {
+			return err
+		}
+	case config.AuthBasic:
+		fmt.Println(tui.FeintStyle.Render("Basic auth uses the credentials of the profile, no token is cached"))
+	}
+
+	if ctx.Host == "" {
+		fmt.Println("Logged in with profile " + tui.BoldStyle.Render(ctx.ProfileName()))
+		return nil
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := client.ListProjects(signalCtx); err != nil {
+		return fmt.Errorf("optimus rejected the credentials: %w", err)
+	}
+	fmt.Println("Logged in to " + tui.BoldStyle.Render(ctx.Host) + " with profile " + tui.BoldStyle.Render(ctx.ProfileName()))
+	return nil
+}
+
+// readToken asks for the token without echoing it when stdin is a terminal
+func readToken() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("reading token: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+	}
+
+	fmt.Print("Token: ")
+	value, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+func runLogout() error {
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return err
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	removed, err := auth.NewCache(dir).Delete(ctx.ProfileName())
+	if err != nil {
+		return err
+	}
+	if !removed {
+		fmt.Println(tui.FeintStyle.Render("No cached token for profile " + ctx.ProfileName()))
+		return nil
+	}
+	fmt.Println("Logged out of profile " + tui.BoldStyle.Render(ctx.ProfileName()))
+	return nil
+}
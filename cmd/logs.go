@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/tui"
+)
+
+// runTimeLayouts are the accepted forms of --run, without a zone the time is local
+var runTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+func NewCmdLogs() *cobra.Command {
+	var run string
+	cmd := &cobra.Command{
+		Use:   "logs <job>",
+		Short: "Show the task and hook logs of a run, following runs in progress",
+		Example: "mirage logs sample.daily_report\n" +
+			"mirage logs sample.daily_report --run 2022-08-01T02:00:00Z",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runLogs(args[0], run)
+		},
+	}
+	cmd.Flags().StringVar(&run, "run", "", "Scheduled time of the run, defaults to the latest run")
+	return cmd
+}
+
+func runLogs(job, run string) {
+	var scheduledAt time.Time
+	if run != "" {
+		var err error
+		if scheduledAt, err = parseRunTime(run); err != nil {
+			fmt.Println(tui.RenderError(err.Error()) + "\n")
+			return
+		}
+	}
+
+	ctx, err := loadContext()
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting logs command: %s", err)) + "\n")
+		return
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting logs command: %s", err)) + "\n")
+		return
+	}
+
+	model, err := tui.NewLogsModel(client, tui.LogsOptions{
+		Project: ctx.Project,
+		Job:     job,
+		Run:     scheduledAt,
+	})
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting logs command: %s", err)) + "\n")
+		return
+	}
+	view, err := tui.NewSwitchableModel(model, client, ctx.Project, ctx.Namespace, ctx.Switch, openJobs(ctx, client))
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting logs command: %s", err)) + "\n")
+		return
+	}
+	if err := tea.NewProgram(view, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseRunTime(value string) (time.Time, error) {
+	for _, layout := range runTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid run time %s, use a time like 2022-08-01T02:00:00Z", value)
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/graph"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/replay"
+	"github.com/sbchaos/mirage/tui"
+)
+
+type replayOptions struct {
+	from   string
+	to     string
+	dryRun bool
+	output string
+	yes    bool
+	force  bool
+	watch  bool
+}
+
+func NewCmdReplay() *cobra.Command {
+	opts := &replayOptions{}
+	cmd := &cobra.Command{
+		Use:     "replay <job>",
+		Short:   "Replay a job and its downstream for a range of dates",
+		Example: "mirage replay sample.daily_report --from 2022-08-01 --to 2022-08-07 --dry-run",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runReplay(opts, args[0]); err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error replaying %s: %s", args[0], err)))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.from, "from", "", "First date of the replay, eg. 2022-08-01")
+	cmd.Flags().StringVar(&opts.to, "to", "", "Last date of the replay, defaults to --from")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "Only preview the runs which would be replayed")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "text", "Output format of the preview, text or json")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "Submit the replay without asking for confirmation")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Replay even if runs of the job are in progress")
+	cmd.Flags().BoolVarP(&opts.watch, "watch", "w", false, "Track the progress of the replay once submitted")
+	_ = cmd.MarkFlagRequired("from")
+
+	cmd.AddCommand(NewCmdReplayStatus())
+	return cmd
+}
+
+func NewCmdReplayStatus() *cobra.Command {
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:     "status <id>",
+		Short:   "Track the progress of a submitted replay",
+		Example: "mirage replay status 2d7a9a9c0e4f72bc9d369e03bcf65932",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cfg, err := loadContext()
+			if err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error starting replay status command: %s", err)) + "\n")
+				return
+			}
+			client, err := newClient(cfg)
+			if err != nil {
+				fmt.Println(tui.RenderError(fmt.Sprintf("Error starting replay status command: %s", err)) + "\n")
+				return
+			}
+			watchReplay(cfg, client, args[0], interval)
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "Polling interval for the replay status")
+	return cmd
+}
+
+func watchReplay(ctx *config.Context, client *optimus.Client, id string, interval time.Duration) {
+	model, err := tui.NewReplayStatusModel(client, ctx.Project, id, interval)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting replay status: %s", err)) + "\n")
+		return
+	}
+	view, err := tui.NewSwitchableModel(model, client, ctx.Project, ctx.Namespace, ctx.Switch, openJobs(ctx, client))
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting replay status: %s", err)) + "\n")
+		return
+	}
+	if err := tea.NewProgram(view, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runReplay(opts *replayOptions, jobName string) error {
+	if opts.output != "text" && opts.output != "json" {
+		return fmt.Errorf("unknown output format %s", opts.output)
+	}
+	start, err := time.Parse(optimus.ReplayDateFormat, opts.from)
+	if err != nil {
+		return fmt.Errorf("invalid --from: %w", err)
+	}
+	end := start
+	if opts.to != "" {
+		if end, err = time.Parse(optimus.ReplayDateFormat, opts.to); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+	}
+
+	cfg, err := loadContext()
+	if err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	loadCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	g, err := graph.LoadProject(loadCtx, client, cfg.Project)
+	if err != nil {
+		return err
+	}
+	// the range covers the complete last day
+	plan, err := replay.NewPlan(g, graph.ID(cfg.Project, jobName), start, end.AddDate(0, 0, 1).Add(-time.Second))
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(plan); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println(tui.RenderReplayPlan(plan))
+	}
+	if opts.dryRun {
+		return nil
+	}
+
+	if !opts.yes {
+		question := fmt.Sprintf("Replay %d runs of %s and its %d downstream jobs? [y/N] ",
+			plan.TotalRuns(), jobName, len(plan.Downstream))
+		if !confirm(question) {
+			fmt.Println(tui.FeintStyle.Render("Replay cancelled"))
+			return nil
+		}
+	}
+
+	// the confirmation waits on the user, the submission gets its own deadline
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	id, err := client.Replay(ctx, cfg.Project, cfg.Namespace, optimus.ReplayRequest{
+		JobName:   jobName,
+		StartDate: start.Format(optimus.ReplayDateFormat),
+		EndDate:   end.Format(optimus.ReplayDateFormat),
+		Force:     opts.force,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(tui.BoldStyle.Copy().Foreground(tui.Green).Render("Replay submitted with id " + id))
+	if opts.watch {
+		watchReplay(cfg, client, id, 5*time.Second)
+		return nil
+	}
+	fmt.Println(tui.FeintStyle.Render("Track it with: mirage replay status " + id))
+	return nil
+}
+
+// confirm asks a yes or no question on the terminal
+func confirm(question string) bool {
+	fmt.Fprint(os.Stderr, question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
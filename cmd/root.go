@@ -3,8 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/auth"
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/snapshot"
 )
 
 const longDescription = `
@@ -16,6 +22,12 @@ const longDescription = `
                             /____/         
 `
 
+// snapshotsDir is the directory below the cache dir keeping snapshots of server state
+const snapshotsDir = "snapshots"
+
+// overrides holds the values of the global flags shared by all commands
+var overrides = config.Overrides{}
+
 func Execute() {
 	rootCmd := &cobra.Command{
 		Use:   "mirage",
@@ -23,12 +35,90 @@ func Execute() {
 		Long:  longDescription,
 	}
 
+	rootCmd.PersistentFlags().StringVar(&overrides.Profile, "profile", "", "Profile from ~/.config/mirage/config.yaml to use")
+	rootCmd.PersistentFlags().StringVar(&overrides.Host, "host", "", "Address of the optimus server, https is used unless another scheme is given")
+	rootCmd.PersistentFlags().StringVar(&overrides.Project, "project", "", "Name of the optimus project")
+	rootCmd.PersistentFlags().StringVar(&overrides.Namespace, "namespace", "", "Name of the optimus namespace")
+
 	// Register Top Level Commands
 	rootCmd.AddCommand(NewCmdCreate())
 	rootCmd.AddCommand(NewCmdWindow())
+	rootCmd.AddCommand(NewCmdCron())
+	rootCmd.AddCommand(NewCmdConfig())
+	rootCmd.AddCommand(NewCmdSwitch())
+	rootCmd.AddCommand(NewCmdLogin())
+	rootCmd.AddCommand(NewCmdLogout())
+	rootCmd.AddCommand(NewCmdJobs())
+	rootCmd.AddCommand(NewCmdRuns())
+	rootCmd.AddCommand(NewCmdLogs())
+	rootCmd.AddCommand(NewCmdReplay())
+	rootCmd.AddCommand(NewCmdSLA())
+	rootCmd.AddCommand(NewCmdHeatmap())
+	rootCmd.AddCommand(NewCmdGraph())
+	rootCmd.AddCommand(NewCmdDeploy())
+	rootCmd.AddCommand(NewCmdDiff())
+	rootCmd.AddCommand(NewCmdValidate())
+	rootCmd.AddCommand(NewCmdLint())
+	rootCmd.AddCommand(NewCmdBulk())
+	rootCmd.AddCommand(NewCmdDevServer())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
+
+// loadContext resolves the server, project and namespace for the command
+func loadContext() (*config.Context, error) {
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Validate(); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// loadProjectContext resolves the context of commands working on every
+// namespace of the project, which do not need a namespace to be set
+func loadProjectContext() (*config.Context, error) {
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.ValidateProject(); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// newClient creates an optimus client for the resolved context, with the
+// credentials of its profile attached
+func newClient(ctx *config.Context) (*optimus.Client, error) {
+	authenticator, err := newAuthenticator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if authenticator == nil {
+		return optimus.NewClient(ctx.Host)
+	}
+	return optimus.NewClient(ctx.Host, optimus.WithAuth(authenticator))
+}
+
+// newSnapshotStore returns the snapshots of the profile of the context
+func newSnapshotStore(ctx *config.Context) (*snapshot.Store, error) {
+	dir, err := config.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.NewStore(filepath.Join(dir, snapshotsDir), ctx.ProfileName()), nil
+}
+
+func newAuthenticator(ctx *config.Context) (optimus.Authenticator, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return auth.New(ctx.Auth, auth.NewCache(dir), ctx.ProfileName())
+}
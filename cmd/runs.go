@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/tui"
+)
+
+func NewCmdRuns() *cobra.Command {
+	var offline bool
+	cmd := &cobra.Command{
+		Use:   "runs <job>",
+		Short: "Show the run history of a job",
+		Example: "mirage runs sample.daily_report\n" +
+			"mirage runs sample.daily_report --offline",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runRuns(args[0], offline)
+		},
+	}
+	cmd.Flags().BoolVar(&offline, "offline", false, "Only show the runs cached by the last fetch")
+	return cmd
+}
+
+func runRuns(job string, offline bool) {
+	ctx, err := loadContext()
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting runs command: %s", err)) + "\n")
+		return
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting runs command: %s", err)) + "\n")
+		return
+	}
+
+	snapshots, err := newSnapshotStore(ctx)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting runs command: %s", err)) + "\n")
+		return
+	}
+
+	model, err := tui.NewRunsModel(client, tui.RunsOptions{
+		Project:   ctx.Project,
+		Namespace: ctx.Namespace,
+		Job:       job,
+		Snapshots: snapshots,
+		Offline:   offline,
+	})
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting runs command: %s", err)) + "\n")
+		return
+	}
+	// the switcher needs the server, offline there is nothing to switch to
+	switchClient := client
+	if offline {
+		switchClient = nil
+	}
+	view, err := tui.NewSwitchableModel(model, switchClient, ctx.Project, ctx.Namespace, ctx.Switch, openJobs(ctx, client))
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting runs command: %s", err)) + "\n")
+		return
+	}
+	if err := tea.NewProgram(view, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/tui"
+)
+
+func NewCmdSLA() *cobra.Command {
+	var days int
+	var defaultSLA time.Duration
+	var interval time.Duration
+	cmd := &cobra.Command{
+		Use:   "sla",
+		Short: "Monitor the SLA of the jobs of a namespace",
+		Long: "Compare the expected completion of every job, its scheduled time plus the SLA, with the end of its runs.\n" +
+			"The SLA is read from the sla_miss notifier of a job spec, --sla applies to jobs without one.",
+		Example: "mirage sla --days 14\n" +
+			"mirage sla --sla 2h --interval 1m",
+		Run: func(cmd *cobra.Command, args []string) {
+			runSLA(cmd, days, defaultSLA, interval)
+		},
+	}
+	cmd.Flags().IntVar(&days, "days", 7, "Number of days of runs to evaluate")
+	cmd.Flags().DurationVar(&defaultSLA, "sla", 0, "SLA of jobs which do not declare one (default from the notify config)")
+	cmd.Flags().DurationVar(&interval, "interval", 0, "Polling interval for changes, 0 disables watching (default from config or 30s)")
+	return cmd
+}
+
+func runSLA(cmd *cobra.Command, days int, defaultSLA, interval time.Duration) {
+	ctx, err := loadContext()
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting sla command: %s", err)) + "\n")
+		return
+	}
+	if days < 1 {
+		fmt.Println(tui.RenderError("Error starting sla command: --days must be at least 1") + "\n")
+		return
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting sla command: %s", err)) + "\n")
+		return
+	}
+
+	if !cmd.Flags().Changed("interval") {
+		interval = ctx.Mirage.WatchInterval()
+	}
+	if !cmd.Flags().Changed("sla") {
+		defaultSLA = ctx.Mirage.Notify.SLA
+	}
+
+	model, err := tui.NewSLAModel(client, tui.SLAOptions{
+		Project:    ctx.Project,
+		Namespace:  ctx.Namespace,
+		Days:       days,
+		DefaultSLA: defaultSLA,
+		Refresh:    interval,
+		OnSwitch:   ctx.Switch,
+	})
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting sla command: %s", err)) + "\n")
+		return
+	}
+	if err := tea.NewProgram(model, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/tui"
+)
+
+func NewCmdSwitch() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switch",
+		Short: "Pick the default project and namespace of the profile",
+		Long: "Pick the default project and namespace of the profile from the ones on the server.\n" +
+			"The switcher is also available with ctrl+p in every view working on a project or namespace.\n" +
+			"The jobs, sla and heatmap views reload for the namespace picked, the runs, logs, graph,\n" +
+			"replay status, diff and bulk views are replaced by its jobs. A deployment in progress keeps\n" +
+			"going to its project, the namespace picked becomes the default.",
+		Example: "mirage switch --profile staging",
+		Run:     runSwitch,
+	}
+	return cmd
+}
+
+func runSwitch(cmd *cobra.Command, args []string) {
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting switch command: %s", err)) + "\n")
+		return
+	}
+	if ctx.Host == "" {
+		fmt.Println(tui.RenderError("Error starting switch command: optimus host is not configured, use --host or a profile") + "\n")
+		return
+	}
+	client, err := newClient(ctx)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting switch command: %s", err)) + "\n")
+		return
+	}
+
+	model, err := tui.NewSwitchModel(client, ctx.Project, ctx.Namespace, ctx.Switch)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error starting switch command: %s", err)) + "\n")
+		return
+	}
+	if err := tea.NewProgram(model, tea.WithAltScreen()).Start(); err != nil {
+		log.Fatal(err)
+	}
+
+	project, namespace, err := model.Selected()
+	switch {
+	case err != nil:
+		fmt.Println(tui.RenderError(fmt.Sprintf("Error saving the profile: %s", err)))
+	case project != "":
+		fmt.Println("Switched to " + tui.BoldStyle.Render(project+"/"+namespace))
+	}
+}
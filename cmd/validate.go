@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/graph"
+	"github.com/sbchaos/mirage/spec"
+	"github.com/sbchaos/mirage/tui"
+)
+
+type validateOptions struct {
+	pluginsDir string
+}
+
+func NewCmdValidate() *cobra.Command {
+	opts := &validateOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate [paths]",
+		Short: "Validate local job specs",
+		Long: "Validate the job specs found under paths, or the jobs of every namespace in optimus.yaml\n" +
+			"when no path is given. Problems are listed as file:line and the command exits with 1 when\n" +
+			"any is found, which makes it usable as a pre-commit hook. No server is contacted.",
+		Example: "mirage validate\n" +
+			"mirage validate jobs/sample.daily_report --plugins ./plugins",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := runValidate(opts, args); err != nil {
+				fmt.Println(tui.RenderError(err.Error()))
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&opts.pluginsDir, "plugins", "", "Directory of plugin descriptors (yaml) to validate task config against, in addition to the built-in ones")
+	return cmd
+}
+
+func runValidate(opts *validateOptions, paths []string) error {
+	// validation works offline, the host of the context is not required
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return err
+	}
+
+	plugins := spec.DefaultPlugins()
+	if opts.pluginsDir != "" {
+		if plugins, err = spec.LoadPlugins(opts.pluginsDir); err != nil {
+			return err
+		}
+	}
+
+	files, errs := loadSpecPaths(ctx, paths)
+	var diags []spec.Diagnostic
+	failed := 0
+	for _, err := range errs {
+		var parseErr *spec.ParseError
+		if errors.As(err, &parseErr) {
+			diags = append(diags, parseErr.Diagnostic())
+			continue
+		}
+		fmt.Println(tui.RenderError(err.Error()))
+		failed++
+	}
+
+	diags = append(diags, spec.ValidateAll(files)...)
+	for _, f := range files {
+		diags = append(diags, f.ValidateTask(plugins)...)
+	}
+
+	// dependencies may be jobs of namespaces other than the validated paths
+	all := repositorySpecs(ctx, files)
+	jobs := map[string]bool{}
+	for _, f := range all {
+		jobs[f.Job.Name] = true
+	}
+	diags = append(diags, spec.ValidateDependencies(ctx.Project, files, jobs)...)
+
+	validated := map[string]bool{}
+	for _, f := range files {
+		validated[f.Path] = true
+	}
+	for _, d := range graph.CycleDiagnostics(ctx.Project, all) {
+		if validated[d.Path] {
+			diags = append(diags, d)
+		}
+	}
+
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Path != diags[j].Path {
+			return diags[i].Path < diags[j].Path
+		}
+		return diags[i].Line < diags[j].Line
+	})
+	for _, d := range diags {
+		fmt.Println(d.String())
+	}
+
+	if problems := len(diags) + failed; problems > 0 {
+		return fmt.Errorf("found %d problems in job specs", problems)
+	}
+	if len(files) == 0 {
+		return errors.New("no job specs found")
+	}
+	fmt.Println(tui.FeintStyle.Render(fmt.Sprintf("%d job specs are valid", len(files))))
+	return nil
+}
+
+// repositorySpecs returns the given files along with the specs of every
+// namespace of optimus.yaml which are not among them
+func repositorySpecs(ctx *config.Context, files []*spec.File) []*spec.File {
+	all := append([]*spec.File{}, files...)
+	seen := map[string]bool{}
+	for _, f := range files {
+		if abs, err := filepath.Abs(f.Path); err == nil {
+			seen[abs] = true
+		}
+	}
+	local, _ := loadLocalSpecs(ctx)
+	for _, f := range local {
+		if abs, err := filepath.Abs(f.Path); err != nil || !seen[abs] {
+			all = append(all, f)
+		}
+	}
+	return all
+}
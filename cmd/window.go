@@ -3,10 +3,14 @@ package cmd
 import (
 	"fmt"
 	"log"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
 
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/job"
 	"github.com/sbchaos/mirage/tui"
 )
 
@@ -17,6 +21,7 @@ func NewCmdWindow() *cobra.Command {
 		Example: "mirage window",
 		Run:     runShowWindow,
 	}
+	cmd.AddCommand(NewCmdWindowReport())
 	return cmd
 }
 
@@ -30,3 +35,139 @@ func runShowWindow(cmd *cobra.Command, args []string) {
 		log.Fatal(err)
 	}
 }
+
+type windowReportOptions struct {
+	cron       string
+	size       time.Duration
+	offset     time.Duration
+	truncateTo string
+	timezone   string
+	year       int
+}
+
+func NewCmdWindowReport() *cobra.Command {
+	opts := &windowReportOptions{}
+	cmd := &cobra.Command{
+		Use:   "report [job]",
+		Short: "List the runs of a job whose window is affected by DST transitions",
+		Long: "List the runs of a job whose window is affected by DST transitions. The job is given by its name\n" +
+			"in the optimus repository of the working directory or by the path of its spec, the schedule and\n" +
+			"window flags override the ones of the spec. Without a job, the flags describe the job.",
+		Example: "mirage window report sample.daily_report --year 2026\n" +
+			"mirage window report ./jobs/daily_report --timezone Europe/Berlin\n" +
+			"mirage window report --cron '0 2 * * *' --size 24h --truncate-to d --timezone Europe/Berlin --year 2026",
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 {
+				if err := opts.fromJob(args[0], cmd.Flags().Changed); err != nil {
+					fmt.Println(tui.RenderError(fmt.Sprintf("Error loading job %s: %s", args[0], err)))
+					return
+				}
+			}
+			runWindowReport(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.cron, "cron", "0 0 * * *", "Cron schedule of the job")
+	cmd.Flags().DurationVar(&opts.size, "size", job.HoursInDay, "Size of the window")
+	cmd.Flags().DurationVar(&opts.offset, "offset", 0, "Offset of the window")
+	cmd.Flags().StringVar(&opts.truncateTo, "truncate-to", "d", "Truncation of the window, one of h, d, w, M")
+	cmd.Flags().StringVar(&opts.timezone, "timezone", "Local", "IANA timezone of the job, defaults to the CRON_TZ of the schedule of a job")
+	cmd.Flags().IntVar(&opts.year, "year", time.Now().Year(), "Year to report on")
+	return cmd
+}
+
+// fromJob takes the schedule and the window of the spec of a job, given by
+// name or path, for the options whose flag is not changed
+func (opts *windowReportOptions) fromJob(nameOrPath string, changed func(flag string) bool) error {
+	ctx, err := config.Resolve(overrides)
+	if err != nil {
+		return err
+	}
+	f, err := findLocalSpec(ctx, nameOrPath)
+	if err != nil {
+		return err
+	}
+
+	w := f.Job.Task.Window
+	if !changed("cron") {
+		opts.cron = f.Job.Schedule.Interval
+	}
+	if !changed("size") {
+		if opts.size, err = time.ParseDuration(w.Size); err != nil {
+			return fmt.Errorf("invalid window size %q in %s", w.Size, f.Path)
+		}
+	}
+	if !changed("offset") && w.Offset != "" {
+		if opts.offset, err = time.ParseDuration(w.Offset); err != nil {
+			return fmt.Errorf("invalid window offset %q in %s", w.Offset, f.Path)
+		}
+	}
+	if !changed("truncate-to") {
+		opts.truncateTo = w.TruncateTo
+	}
+	if tz, _ := job.SplitTimezone(opts.cron); tz != "" && !changed("timezone") {
+		opts.timezone = tz
+	}
+	return nil
+}
+
+func runWindowReport(opts *windowReportOptions) {
+	loc, err := time.LoadLocation(opts.timezone)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Invalid timezone %s: %s", opts.timezone, err)))
+		return
+	}
+	schedule, err := cron.ParseStandard(opts.cron)
+	if err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Invalid cron %s: %s", opts.cron, err)))
+		return
+	}
+	window := job.DataWindow{
+		Size:       opts.size,
+		Offset:     opts.offset,
+		TruncateTo: opts.truncateTo,
+	}
+	if err := window.Validate(); err != nil {
+		fmt.Println(tui.RenderError(fmt.Sprintf("Invalid window: %s", err)))
+		return
+	}
+
+	transitions := job.Transitions(opts.year, loc)
+	if len(transitions) == 0 {
+		fmt.Println(tui.FeintStyle.Render(fmt.Sprintf("No DST transitions in %s during %d", loc, opts.year)))
+		return
+	}
+
+	fmt.Println(tui.BoldStyle.Render(fmt.Sprintf("DST transitions in %s during %d", loc, opts.year)))
+	for _, t := range transitions {
+		fmt.Printf("  %s  %s → %s  (day is %s long)\n",
+			t.At.Format(time.RFC3339), formatOffset(t.FromOffset), formatOffset(t.ToOffset), t.DayLength())
+	}
+	fmt.Println()
+
+	affected := job.DSTReport(window, schedule, opts.year, loc)
+	if len(affected) == 0 {
+		fmt.Println(tui.FeintStyle.Render("No runs of the job are affected"))
+		return
+	}
+
+	fmt.Println(tui.BoldStyle.Render(fmt.Sprintf("%d affected runs", len(affected))))
+	for _, r := range affected {
+		line := fmt.Sprintf("  %s  window %s → %s  (%s instead of %s)",
+			r.ScheduledAt.Format(time.RFC3339), r.WindowStart.Format(time.RFC3339),
+			r.WindowEnd.Format(time.RFC3339), r.Duration(), r.Expected)
+		if r.Duration() < r.Expected {
+			line = tui.TextStyle.Copy().Foreground(tui.Orange).Render(line)
+		}
+		fmt.Println(line)
+	}
+}
+
+func formatOffset(offset time.Duration) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	return fmt.Sprintf("UTC%s%02d:%02d", sign, int(offset.Hours()), int(offset.Minutes())%60)
+}
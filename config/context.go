@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// Overrides are the values passed with global flags on the command line
+type Overrides struct {
+	Profile   string
+	Host      string
+	Project   string
+	Namespace string
+}
+
+// Context is the resolved server, project and namespace mirage talks to
+type Context struct {
+	Profile   string
+	Host      string
+	Project   string
+	Namespace string
+
+	// Auth is the authentication of the profile, nil when requests are anonymous
+	Auth *AuthConfig
+
+	// Optimus is the optimus.yaml of the working directory, nil if absent
+	Optimus *OptimusConfig
+	Mirage  *Config
+}
+
+// Resolve builds the context from flags, the selected mirage profile and
+// optimus.yaml in the working directory, in that order of precedence
+func Resolve(overrides Overrides) (*Context, error) {
+	mirageCfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	optimusCfg, err := LoadOptimusConfig(wd)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolve(overrides, mirageCfg, optimusCfg)
+}
+
+func resolve(overrides Overrides, mirageCfg *Config, optimusCfg *OptimusConfig) (*Context, error) {
+	ctx := &Context{
+		Profile: overrides.Profile,
+		Optimus: optimusCfg,
+		Mirage:  mirageCfg,
+	}
+	if ctx.Profile == "" {
+		ctx.Profile = mirageCfg.CurrentProfile
+	}
+
+	profile, err := mirageCfg.Profile(overrides.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if optimusCfg != nil {
+		ctx.Host = optimusCfg.Host
+		ctx.Project = optimusCfg.Project.Name
+		if len(optimusCfg.Namespaces) == 1 {
+			ctx.Namespace = optimusCfg.Namespaces[0].Name
+		}
+	}
+
+	ctx.Auth = profile.Auth
+	ctx.Host = firstNonEmpty(overrides.Host, profile.Host, ctx.Host)
+	ctx.Project = firstNonEmpty(overrides.Project, profile.Project, ctx.Project)
+	ctx.Namespace = firstNonEmpty(overrides.Namespace, profile.Namespace, ctx.Namespace)
+	return ctx, nil
+}
+
+// Validate checks that enough is configured to talk to a namespace
+func (c *Context) Validate() error {
+	if err := c.ValidateProject(); err != nil {
+		return err
+	}
+	if c.Namespace == "" {
+		return fmt.Errorf("optimus namespace is not configured, use --namespace or a profile")
+	}
+	return nil
+}
+
+// ValidateProject checks that the host and the project are set, for commands
+// working on every namespace of the project
+func (c *Context) ValidateProject() error {
+	if c.Host == "" {
+		return fmt.Errorf("optimus host is not configured, use --host or a profile")
+	}
+	if c.Project == "" {
+		return fmt.Errorf("optimus project is not configured, use --project or a profile")
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ProfileName returns the name of the profile in use
+func (c *Context) ProfileName() string {
+	if c.Profile == "" {
+		return DefaultProfile
+	}
+	return c.Profile
+}
+
+// Switch changes the project and namespace of the context and saves them as
+// the default of its profile, the profile is created when it does not exist
+func (c *Context) Switch(project, namespace string) error {
+	c.Project = project
+	c.Namespace = namespace
+
+	name := c.ProfileName()
+	profile, ok := c.Mirage.Profiles[name]
+	if !ok {
+		profile = &Profile{Host: c.Host}
+		c.Mirage.Profiles[name] = profile
+	}
+	profile.Project = project
+	profile.Namespace = namespace
+	if c.Mirage.CurrentProfile == "" {
+		c.Mirage.CurrentProfile = name
+	}
+	return c.Mirage.Save()
+}
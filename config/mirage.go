@@ -0,0 +1,256 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	appName        = "mirage"
+	configFileName = "config.yaml"
+	DefaultProfile = "default"
+
+	DefaultWatchInterval = 30 * time.Second
+)
+
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+	AuthOIDC   = "oidc"
+)
+
+// Profile points mirage at an optimus server, project and namespace
+type Profile struct {
+	Host      string      `yaml:"host"`
+	Project   string      `yaml:"project"`
+	Namespace string      `yaml:"namespace"`
+	Auth      *AuthConfig `yaml:"auth,omitempty"`
+}
+
+// AuthConfig selects how requests to optimus are authenticated, secrets can
+// be read from environment variables instead of being kept in the file
+type AuthConfig struct {
+	// Type is one of bearer, basic or oidc
+	Type string `yaml:"type"`
+
+	// Token is the static token of bearer auth, without one the token saved
+	// by mirage login is used
+	Token    string `yaml:"token,omitempty"`
+	TokenEnv string `yaml:"token_env,omitempty"`
+
+	Username    string `yaml:"username,omitempty"`
+	Password    string `yaml:"password,omitempty"`
+	PasswordEnv string `yaml:"password_env,omitempty"`
+
+	// Issuer is the url of the OIDC provider, its endpoints are discovered
+	// unless DeviceEndpoint and TokenEndpoint are set
+	Issuer         string   `yaml:"issuer,omitempty"`
+	ClientID       string   `yaml:"client_id,omitempty"`
+	Scopes         []string `yaml:"scopes,omitempty"`
+	Audience       string   `yaml:"audience,omitempty"`
+	DeviceEndpoint string   `yaml:"device_endpoint,omitempty"`
+	TokenEndpoint  string   `yaml:"token_endpoint,omitempty"`
+}
+
+// Validate checks the fields required by the auth type are set
+func (a *AuthConfig) Validate() error {
+	switch a.Type {
+	case AuthBearer:
+	case AuthBasic:
+		if a.Username == "" {
+			return fmt.Errorf("basic auth requires a username")
+		}
+	case AuthOIDC:
+		if a.ClientID == "" {
+			return fmt.Errorf("oidc auth requires a client_id")
+		}
+		if a.Issuer == "" && (a.DeviceEndpoint == "" || a.TokenEndpoint == "") {
+			return fmt.Errorf("oidc auth requires an issuer or both device_endpoint and token_endpoint")
+		}
+	default:
+		return fmt.Errorf("unknown auth type %q, should be one of bearer, basic, oidc", a.Type)
+	}
+	return nil
+}
+
+// WatchConfig controls how often the tui polls optimus for changes
+type WatchConfig struct {
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// NotifyConfig selects the jobs mirage notifies about while watching and how
+type NotifyConfig struct {
+	// Jobs are the names of watched jobs
+	Jobs []string `yaml:"jobs,omitempty"`
+
+	// Filters watch every job matching one of them
+	Filters []JobFilter `yaml:"filters,omitempty"`
+
+	// SLA is the time after the scheduled time a run of a watched job should
+	// succeed in, used for jobs without an sla_miss notifier in their spec
+	SLA time.Duration `yaml:"sla,omitempty"`
+
+	// Bell rings the terminal bell, enabled unless set to false
+	Bell *bool `yaml:"bell,omitempty"`
+
+	// Command is run by the shell for every notification with the details
+	// of the run in MIRAGE_* environment variables
+	Command string `yaml:"command,omitempty"`
+}
+
+// JobFilter matches jobs, every field which is set has to match
+type JobFilter struct {
+	// Name is a glob pattern like sample.daily_*
+	Name   string            `yaml:"name,omitempty"`
+	Owner  string            `yaml:"owner,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// Empty reports whether the filter has no field set
+func (f JobFilter) Empty() bool {
+	return f.Name == "" && f.Owner == "" && len(f.Labels) == 0
+}
+
+// Match reports whether a job with the name, owner and labels matches every
+// field of the filter which is set, an empty filter matches every job
+func (f JobFilter) Match(name, owner string, labels map[string]string) bool {
+	if f.Name != "" {
+		if ok, _ := path.Match(f.Name, name); !ok {
+			return false
+		}
+	}
+	if f.Owner != "" && f.Owner != owner {
+		return false
+	}
+	for k, v := range f.Labels {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// RingBell reports if the terminal bell is rung for notifications
+func (n NotifyConfig) RingBell() bool {
+	return n.Bell == nil || *n.Bell
+}
+
+// Config is the mirage configuration with multiple named profiles
+type Config struct {
+	CurrentProfile string              `yaml:"current_profile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+	Watch          WatchConfig         `yaml:"watch,omitempty"`
+	Notify         NotifyConfig        `yaml:"notify,omitempty"`
+
+	path string
+}
+
+// Dir returns the directory holding mirage configuration, usually ~/.config/mirage
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", appName), nil
+}
+
+// CacheDir returns the directory holding data cached by mirage, usually ~/.cache/mirage
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// Load reads the mirage config, an empty config is returned when the file
+// does not exist yet
+func Load() (*Config, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFrom(filepath.Join(dir, configFileName))
+}
+
+func LoadFrom(path string) (*Config, error) {
+	cfg := &Config{
+		Profiles: map[string]*Profile{},
+		path:     path,
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*Profile{}
+	}
+	return cfg, nil
+}
+
+// Save writes the config back to the file it was loaded from
+func (c *Config) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, content, 0o600)
+}
+
+// Profile returns the profile with name, falling back to the current
+// profile when name is empty
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		if len(c.Profiles) == 0 || name == DefaultProfile {
+			return &Profile{}, nil
+		}
+		return nil, fmt.Errorf("profile %s does not exist in %s", name, c.path)
+	}
+	return p, nil
+}
+
+// WatchInterval returns the configured polling interval or the default
+func (c *Config) WatchInterval() time.Duration {
+	if c.Watch.Interval != 0 {
+		return c.Watch.Interval
+	}
+	return DefaultWatchInterval
+}
+
+// ProfileNames returns the names of all profiles in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const OptimusFileName = "optimus.yaml"
+
+// OptimusConfig is the client configuration used by the optimus cli
+type OptimusConfig struct {
+	Version    int                 `yaml:"version"`
+	Host       string              `yaml:"host"`
+	Project    OptimusProject      `yaml:"project"`
+	Namespaces []*OptimusNamespace `yaml:"namespaces"`
+}
+
+type OptimusProject struct {
+	Name   string            `yaml:"name"`
+	Config map[string]string `yaml:"config"`
+}
+
+type OptimusNamespace struct {
+	Name      string             `yaml:"name"`
+	Config    map[string]string  `yaml:"config"`
+	Job       OptimusJobPath     `yaml:"job"`
+	Datastore []OptimusDatastore `yaml:"datastore"`
+}
+
+type OptimusJobPath struct {
+	Path string `yaml:"path"`
+}
+
+type OptimusDatastore struct {
+	Type string `yaml:"type"`
+	Path string `yaml:"path"`
+}
+
+// LoadOptimusConfig reads optimus.yaml from dir, it returns nil without
+// an error when the file does not exist
+func LoadOptimusConfig(dir string) (*OptimusConfig, error) {
+	path := filepath.Join(dir, OptimusFileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cfg := &OptimusConfig{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// GetNamespace returns the namespace with name, or nil when not configured
+func (c *OptimusConfig) GetNamespace(name string) *OptimusNamespace {
+	for _, ns := range c.Namespaces {
+		if ns.Name == name {
+			return ns
+		}
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+// Package congestion expands the schedules of many jobs over a day or a week
+// and counts the runs starting in each slot, to find the times the scheduler
+// is swamped and the times a new job could start in instead.
+package congestion
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/job"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+)
+
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+
+	SlotMinute = "minute"
+	SlotHour   = "hour"
+)
+
+// SchedulerTimezone is the timezone of jobs whose interval has no CRON_TZ= prefix
+var SchedulerTimezone = time.UTC
+
+// Job is a scheduled job counted in the heatmap
+type Job struct {
+	Name      string
+	Namespace string
+	Interval  string
+}
+
+// FromSpecs returns the scheduled jobs of a namespace on the server
+func FromSpecs(namespace string, specs []optimus.JobSpec) []Job {
+	var jobs []Job
+	for _, s := range specs {
+		if s.Interval != "" {
+			jobs = append(jobs, Job{Name: s.Name, Namespace: namespace, Interval: s.Interval})
+		}
+	}
+	return jobs
+}
+
+// Load returns the scheduled jobs of every namespace in the project from the server
+func Load(ctx context.Context, client *optimus.Client, project string) ([]Job, error) {
+	namespaces, err := client.ListNamespaces(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []Job
+	for _, ns := range namespaces {
+		specs, err := client.ListJobSpecs(ctx, project, ns.Name)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, FromSpecs(ns.Name, specs)...)
+	}
+	return jobs, nil
+}
+
+// FromFiles returns the scheduled jobs of local specs
+func FromFiles(files []*spec.File) []Job {
+	var jobs []Job
+	for _, f := range files {
+		if f.Job.Schedule.Interval != "" {
+			jobs = append(jobs, Job{Name: f.Job.Name, Namespace: f.Namespace, Interval: f.Job.Schedule.Interval})
+		}
+	}
+	return jobs
+}
+
+// PeriodStart returns the start of the day or the week, starting on Monday,
+// containing t
+func PeriodStart(t time.Time, period string) time.Time {
+	start := job.StartOfDay(t)
+	if period == PeriodWeek {
+		start = job.StartOfDay(start.AddDate(0, 0, -(int(start.Weekday())+6)%7))
+	}
+	return start
+}
+
+// SlotDuration returns the length of a slot
+func SlotDuration(slot string) (time.Duration, error) {
+	switch slot {
+	case SlotMinute:
+		return time.Minute, nil
+	case SlotHour:
+		return time.Hour, nil
+	}
+	return 0, fmt.Errorf("unknown slot %s, use one of minute, hour", slot)
+}
+
+// Slot is a period runs start in, along with the jobs starting them
+type Slot struct {
+	Start time.Time
+	Runs  int
+
+	// Jobs are the jobs with a run in the slot as namespace/name, sorted
+	Jobs []string
+}
+
+// Heatmap counts the runs of jobs starting in each slot of a period
+type Heatmap struct {
+	Start  time.Time
+	End    time.Time
+	Period string
+	Slot   time.Duration
+	Slots  []Slot
+
+	// Jobs is the number of jobs expanded, Invalid lists the jobs whose
+	// interval is not a valid cron expression
+	Jobs    int
+	Invalid []string
+}
+
+// New expands the schedules of jobs over the day or the week starting at
+// start, counting runs in slots of the given length
+func New(jobs []Job, start time.Time, period string, slot time.Duration) (*Heatmap, error) {
+	var end time.Time
+	switch period {
+	case PeriodDay:
+		end = job.StartOfDay(start.AddDate(0, 0, 1))
+	case PeriodWeek:
+		end = job.StartOfDay(start.AddDate(0, 0, 7))
+	default:
+		return nil, fmt.Errorf("unknown period %s, use one of day, week", period)
+	}
+
+	n := int((end.Sub(start) + slot - 1) / slot)
+	h := &Heatmap{Start: start, End: end, Period: period, Slot: slot, Slots: make([]Slot, n)}
+	for i := range h.Slots {
+		h.Slots[i].Start = start.Add(time.Duration(i) * slot)
+	}
+
+	for _, j := range jobs {
+		name := j.Namespace + "/" + j.Name
+		runs, err := h.runs(j.Interval)
+		if err != nil {
+			h.Invalid = append(h.Invalid, name)
+			continue
+		}
+		h.Jobs++
+		for _, t := range runs {
+			s := &h.Slots[h.Index(t)]
+			s.Runs++
+			if len(s.Jobs) == 0 || s.Jobs[len(s.Jobs)-1] != name {
+				s.Jobs = append(s.Jobs, name)
+			}
+		}
+	}
+	for i := range h.Slots {
+		sort.Strings(h.Slots[i].Jobs)
+	}
+	return h, nil
+}
+
+// runs returns the runs of an interval within the period of the heatmap
+func (h *Heatmap) runs(interval string) ([]time.Time, error) {
+	schedule, err := cron.ParseStandard(interval)
+	if err != nil {
+		return nil, err
+	}
+	// intervals without a CRON_TZ= prefix run in the timezone of the
+	// scheduler, not the one the heatmap is shown in
+	var runs []time.Time
+	for t := schedule.Next(h.Start.Add(-time.Nanosecond).In(SchedulerTimezone)); !t.IsZero() && t.Before(h.End); t = schedule.Next(t) {
+		runs = append(runs, t)
+	}
+	return runs, nil
+}
+
+// Index returns the slot a time falls in
+func (h *Heatmap) Index(t time.Time) int {
+	return int(t.Sub(h.Start) / h.Slot)
+}
+
+// Max returns the largest number of runs starting in a slot
+func (h *Heatmap) Max() int {
+	max := 0
+	for _, s := range h.Slots {
+		if s.Runs > max {
+			max = s.Runs
+		}
+	}
+	return max
+}
+
+// Busiest returns the n slots with the most runs, earliest first on ties
+func (h *Heatmap) Busiest(n int) []Slot {
+	slots := make([]Slot, 0, len(h.Slots))
+	for _, s := range h.Slots {
+		if s.Runs > 0 {
+			slots = append(slots, s)
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].Runs > slots[j].Runs })
+	if len(slots) > n {
+		slots = slots[:n]
+	}
+	return slots
+}
+
+// Suggestion is a schedule for a new job along with the congestion it meets
+type Suggestion struct {
+	Interval string
+
+	// Runs is the largest number of runs of other jobs starting in the
+	// same slot as a run of the interval
+	Runs int
+
+	// Shift is how far the first run moves compared to the original interval
+	Shift time.Duration
+}
+
+// Score returns the congestion an interval meets, the largest number of runs
+// already starting in a slot any of its runs starts in
+func (h *Heatmap) Score(interval string) (int, error) {
+	runs, err := h.runs(interval)
+	if err != nil {
+		return 0, err
+	}
+	score := 0
+	for _, t := range runs {
+		if n := h.Slots[h.Index(t)].Runs; n > score {
+			score = n
+		}
+	}
+	return score, nil
+}
+
+// Suggest proposes up to n variants of an interval starting in less congested
+// slots, moving the minute and, when the hour is fixed, the hour by up to two
+// hours. Intervals whose minute is not a single number cannot be moved.
+func (h *Heatmap) Suggest(interval string, n int) ([]Suggestion, error) {
+	current, err := h.Score(interval)
+	if err != nil {
+		return nil, err
+	}
+
+	tz, expr := job.SplitTimezone(interval)
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, nil
+	}
+	minute, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, nil
+	}
+	hour, hourErr := strconv.Atoi(fields[1])
+	hourShifts := []int{0}
+	if hourErr == nil {
+		hourShifts = []int{0, -1, 1, -2, 2}
+	}
+
+	var suggestions []Suggestion
+	for _, dh := range hourShifts {
+		for m := 0; m < 60; m++ {
+			candidate := append([]string{strconv.Itoa(m)}, fields[1:]...)
+			if hourErr == nil {
+				candidate[1] = strconv.Itoa((hour + dh + 24) % 24)
+			}
+			shift := time.Duration(dh)*time.Hour + time.Duration(m-minute)*time.Minute
+			if shift == 0 {
+				continue
+			}
+			ci := strings.Join(candidate, " ")
+			if tz != "" {
+				ci = "CRON_TZ=" + tz + " " + ci
+			}
+			score, err := h.Score(ci)
+			if err != nil || score >= current {
+				continue
+			}
+			suggestions = append(suggestions, Suggestion{Interval: ci, Runs: score, Shift: shift})
+		}
+	}
+
+	// least congested first, then closest to the original time
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Runs != suggestions[j].Runs {
+			return suggestions[i].Runs < suggestions[j].Runs
+		}
+		return abs(suggestions[i].Shift) < abs(suggestions[j].Shift)
+	})
+	if len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions, nil
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
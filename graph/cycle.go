@@ -0,0 +1,121 @@
+package graph
+
+import "sort"
+
+// Cycles returns the dependency cycles of the graph, each cycle is a path of
+// ids following upstream edges which starts and ends with the same job
+func (g *Graph) Cycles() [][]string {
+	var cycles [][]string
+	for _, component := range g.components() {
+		if len(component) == 1 && !contains(g.upstream[component[0]], component[0]) {
+			continue
+		}
+		cycles = append(cycles, g.cyclePath(component))
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// components returns the strongly connected components of the graph using
+// Tarjan's algorithm, every component with more than one job holds a cycle
+func (g *Graph) components() [][]string {
+	ids := map[string]bool{}
+	for id, upstream := range g.upstream {
+		ids[id] = true
+		for _, u := range upstream {
+			ids[u] = true
+		}
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	index := map[string]int{}
+	lowLink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var components [][]string
+
+	var connect func(id string)
+	connect = func(id string) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range g.upstream[id] {
+			if _, visited := index[next]; !visited {
+				connect(next)
+				if lowLink[next] < lowLink[id] {
+					lowLink[id] = lowLink[next]
+				}
+			} else if onStack[next] && index[next] < lowLink[id] {
+				lowLink[id] = index[next]
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		sort.Strings(component)
+		components = append(components, component)
+	}
+
+	for _, id := range sorted {
+		if _, visited := index[id]; !visited {
+			connect(id)
+		}
+	}
+	return components
+}
+
+// cyclePath finds a path through the component from its first job back to itself
+func (g *Graph) cyclePath(component []string) []string {
+	start := component[0]
+	members := map[string]bool{}
+	for _, id := range component {
+		members[id] = true
+	}
+
+	seen := map[string]bool{}
+	var path []string
+	var find func(id string) bool
+	find = func(id string) bool {
+		path = append(path, id)
+		seen[id] = true
+		for _, next := range g.upstream[id] {
+			if next == start {
+				path = append(path, start)
+				return true
+			}
+			if members[next] && !seen[next] && find(next) {
+				return true
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	find(start)
+	return path
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Export formats supported by Export
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatJSON    = "json"
+)
+
+// ExportNode is a job in an exported graph, jobs outside the loaded
+// projects only have an id
+type ExportNode struct {
+	ID        string `json:"id"`
+	Project   string `json:"project"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Owner     string `json:"owner,omitempty"`
+	Schedule  string `json:"schedule,omitempty"`
+	Task      string `json:"task,omitempty"`
+}
+
+// ExportEdge points from an upstream job to the job depending on it
+type ExportEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Export is the part of the graph written by Write
+type Export struct {
+	Name  string       `json:"name"`
+	Nodes []ExportNode `json:"nodes"`
+	Edges []ExportEdge `json:"edges"`
+}
+
+// NewExport selects the jobs with ids and the edges between them
+func (g *Graph) NewExport(name string, ids []string) *Export {
+	selected := map[string]bool{}
+	for _, id := range ids {
+		selected[id] = true
+	}
+	sorted := make([]string, 0, len(selected))
+	for id := range selected {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	e := &Export{Name: name, Nodes: []ExportNode{}, Edges: []ExportEdge{}}
+	for _, id := range sorted {
+		node := ExportNode{ID: id, Project: Project(id), Name: Name(id)}
+		if n := g.nodes[id]; n != nil {
+			node.Namespace = n.Namespace
+			node.Owner = n.Spec.Owner
+			node.Schedule = n.Spec.Interval
+			node.Task = n.Spec.TaskName
+		}
+		e.Nodes = append(e.Nodes, node)
+
+		for _, upstream := range g.upstream[id] {
+			if selected[upstream] {
+				e.Edges = append(e.Edges, ExportEdge{From: upstream, To: id})
+			}
+		}
+	}
+	sort.Slice(e.Edges, func(i, j int) bool {
+		if e.Edges[i].From != e.Edges[j].From {
+			return e.Edges[i].From < e.Edges[j].From
+		}
+		return e.Edges[i].To < e.Edges[j].To
+	})
+	return e
+}
+
+// Write renders the export in format
+func (e *Export) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatDOT:
+		return e.writeDOT(w)
+	case FormatMermaid:
+		return e.writeMermaid(w)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(e)
+	}
+	return fmt.Errorf("unknown format %s, use one of dot, mermaid, json", format)
+}
+
+func (e *Export) writeDOT(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph %s {\n", strconv.Quote(e.Name))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\"];\n\n")
+	for _, n := range e.Nodes {
+		attrs := []string{"label=" + strconv.Quote(strings.Join(n.lines(), "\n"))}
+		if n.Owner != "" {
+			attrs = append(attrs, "owner="+strconv.Quote(n.Owner))
+		}
+		if n.Schedule != "" {
+			attrs = append(attrs, "schedule="+strconv.Quote(n.Schedule))
+		}
+		if n.Task != "" {
+			attrs = append(attrs, "task="+strconv.Quote(n.Task))
+		}
+		if n.Namespace == "" {
+			attrs = append(attrs, "style=dashed")
+		}
+		fmt.Fprintf(b, "  %s [%s];\n", strconv.Quote(n.ID), strings.Join(attrs, ", "))
+	}
+	if len(e.Edges) > 0 {
+		b.WriteString("\n")
+	}
+	for _, edge := range e.Edges {
+		fmt.Fprintf(b, "  %s -> %s;\n", strconv.Quote(edge.From), strconv.Quote(edge.To))
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (e *Export) writeMermaid(w io.Writer) error {
+	// mermaid ids can not contain the characters of job names, nodes are
+	// numbered and labelled instead
+	ids := map[string]string{}
+	b := &strings.Builder{}
+	b.WriteString("flowchart LR\n")
+	for i, n := range e.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		lines := n.lines()
+		for j := range lines {
+			lines[j] = mermaidEscape(lines[j])
+		}
+		label := strings.Join(lines, "<br/>")
+		if n.Namespace == "" {
+			fmt.Fprintf(b, "  %s([\"%s\"])\n", ids[n.ID], label)
+		} else {
+			fmt.Fprintf(b, "  %s[\"%s\"]\n", ids[n.ID], label)
+		}
+	}
+	for _, edge := range e.Edges {
+		fmt.Fprintf(b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// lines returns the label of the node, one attribute per line
+func (n ExportNode) lines() []string {
+	lines := []string{n.ID}
+	if n.Owner != "" {
+		lines = append(lines, "owner: "+n.Owner)
+	}
+	if n.Schedule != "" {
+		lines = append(lines, "schedule: "+n.Schedule)
+	}
+	if n.Task != "" {
+		lines = append(lines, "task: "+n.Task)
+	}
+	return lines
+}
+
+var mermaidReplacer = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+func mermaidEscape(s string) string {
+	return mermaidReplacer.Replace(s)
+}
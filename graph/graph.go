@@ -0,0 +1,162 @@
+// Package graph resolves dependencies between optimus jobs.
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// Node is a job in the dependency graph, identified by project and name
+type Node struct {
+	Project   string
+	Namespace string
+	Spec      *optimus.JobSpec
+}
+
+func (n *Node) ID() string {
+	return ID(n.Project, n.Spec.Name)
+}
+
+// ID returns the identifier of a job across projects
+func ID(project, name string) string {
+	return project + "/" + name
+}
+
+// Graph holds the jobs and the edges from every job to its upstreams
+type Graph struct {
+	nodes      map[string]*Node
+	upstream   map[string][]string
+	downstream map[string][]string
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes:      map[string]*Node{},
+		upstream:   map[string][]string{},
+		downstream: map[string][]string{},
+	}
+}
+
+// Add inserts the specs of a namespace into the graph, dependencies without
+// a project prefix are resolved in the same project
+func (g *Graph) Add(project, namespace string, specs []optimus.JobSpec) {
+	for i := range specs {
+		node := &Node{Project: project, Namespace: namespace, Spec: &specs[i]}
+		id := node.ID()
+		g.nodes[id] = node
+
+		for _, dep := range specs[i].Dependencies {
+			upstream := dependencyID(project, dep.Name)
+			g.upstream[id] = appendUnique(g.upstream[id], upstream)
+			g.downstream[upstream] = appendUnique(g.downstream[upstream], id)
+		}
+	}
+}
+
+// Node returns the job with id, nil when it is not part of the graph
+func (g *Graph) Node(id string) *Node {
+	return g.nodes[id]
+}
+
+// Upstream returns the ids of the jobs id depends on
+func (g *Graph) Upstream(id string) []string {
+	return g.upstream[id]
+}
+
+// Downstream returns the ids of the jobs depending on id
+func (g *Graph) Downstream(id string) []string {
+	return g.downstream[id]
+}
+
+// AllDownstream returns every job depending on id directly or transitively
+func (g *Graph) AllDownstream(id string) []string {
+	return walk(id, g.downstream)
+}
+
+// AllUpstream returns every job id depends on directly or transitively
+func (g *Graph) AllUpstream(id string) []string {
+	return walk(id, g.upstream)
+}
+
+// Nodes returns the jobs of the graph sorted by id
+func (g *Graph) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	return nodes
+}
+
+// Project returns the project part of a job id
+func Project(id string) string {
+	if i := strings.Index(id, "/"); i >= 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+// Name returns the job name part of a job id
+func Name(id string) string {
+	return id[strings.Index(id, "/")+1:]
+}
+
+// walk collects the ids reachable from id over edges, in breadth first order
+// and sorted
+func walk(id string, edges map[string][]string) []string {
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	var result []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[current] {
+			if seen[next] {
+				continue
+			}
+			seen[next] = true
+			result = append(result, next)
+			queue = append(queue, next)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// dependencyID resolves a dependency name of the form job or project/job
+func dependencyID(project, name string) string {
+	if strings.Contains(name, "/") {
+		return name
+	}
+	return ID(project, name)
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// missingProjects returns the projects referenced by dependencies which are
+// not in loaded
+func (g *Graph) missingProjects(loaded map[string]bool) []string {
+	missing := map[string]bool{}
+	for _, upstream := range g.upstream {
+		for _, id := range upstream {
+			if p := Project(id); !loaded[p] {
+				missing[p] = true
+			}
+		}
+	}
+	projects := make([]string, 0, len(missing))
+	for p := range missing {
+		projects = append(projects, p)
+	}
+	sort.Strings(projects)
+	return projects
+}
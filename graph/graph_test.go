@@ -0,0 +1,244 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+)
+
+// newGraph builds a graph of the sample project from job names and their
+// upstream dependencies
+func newGraph(deps map[string][]string) *Graph {
+	var specs []optimus.JobSpec
+	for name, upstream := range deps {
+		s := optimus.JobSpec{Name: name}
+		for _, u := range upstream {
+			s.Dependencies = append(s.Dependencies, optimus.JobDependency{Name: u})
+		}
+		specs = append(specs, s)
+	}
+	g := New()
+	g.Add("sample", "analytics", specs)
+	return g
+}
+
+func TestCycles(t *testing.T) {
+	tests := []struct {
+		name string
+		deps map[string][]string
+		want [][]string
+	}{
+		{
+			name: "no cycle",
+			deps: map[string][]string{"a": {"b"}, "b": {"c"}, "c": nil},
+		},
+		{
+			name: "self dependency",
+			deps: map[string][]string{"a": {"a"}},
+			want: [][]string{{"sample/a", "sample/a"}},
+		},
+		{
+			name: "two jobs",
+			deps: map[string][]string{"a": {"b"}, "b": {"a"}},
+			want: [][]string{{"sample/a", "sample/b", "sample/a"}},
+		},
+		{
+			name: "three jobs with a tail",
+			deps: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a", "d"}, "d": nil, "e": {"a"}},
+			want: [][]string{{"sample/a", "sample/b", "sample/c", "sample/a"}},
+		},
+		{
+			name: "two separate cycles",
+			deps: map[string][]string{"a": {"b"}, "b": {"a"}, "x": {"y"}, "y": {"x"}},
+			want: [][]string{{"sample/a", "sample/b", "sample/a"}, {"sample/x", "sample/y", "sample/x"}},
+		},
+		{
+			name: "across projects",
+			deps: map[string][]string{"a": {"other/b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newGraph(tt.deps).Cycles()
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Cycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func writeSpecs(t *testing.T, deps map[string][]string) []*spec.File {
+	t.Helper()
+	dir := t.TempDir()
+	var files []*spec.File
+	for _, name := range []string{"a", "b", "c", "d"} {
+		upstream, ok := deps[name]
+		if !ok {
+			continue
+		}
+		content := "version: 1\nname: " + name + "\nowner: data@example.com\nschedule:\n  interval: 0 2 * * *\n"
+		if len(upstream) > 0 {
+			content += "dependencies:\n"
+			for _, u := range upstream {
+				content += "- job: " + u + "\n"
+			}
+		}
+		path := filepath.Join(dir, name, spec.FileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		f, err := spec.LoadFile(path, "analytics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	return files
+}
+
+func TestCycleDiagnostics(t *testing.T) {
+	tests := []struct {
+		name string
+		deps map[string][]string
+		want []string
+	}{
+		{
+			name: "no cycle",
+			deps: map[string][]string{"a": {"b"}, "b": nil},
+		},
+		{
+			name: "self dependency is left to spec validation",
+			deps: map[string][]string{"a": {"a"}},
+		},
+		{
+			name: "every job of the cycle",
+			deps: map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}, "d": {"a"}},
+			want: []string{
+				"a:6 dependency cycle a → b → c → a",
+				"b:6 dependency cycle a → b → c → a",
+				"c:6 dependency cycle a → b → c → a",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range CycleDiagnostics("sample", writeSpecs(t, tt.deps)) {
+				if d.Field != "dependencies" {
+					t.Errorf("diagnostic on %s, want dependencies", d.Field)
+				}
+				got = append(got, fmt.Sprintf("%s:%d %s", filepath.Base(filepath.Dir(d.Path)), d.Line, d.Message))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("CycleDiagnostics() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func sampleExport() *Export {
+	g := New()
+	g.Add("sample", "analytics", []optimus.JobSpec{
+		{Name: "report", Owner: "bi@example.com", Interval: "0 4 * * *", TaskName: "bq2bq",
+			Dependencies: []optimus.JobDependency{{Name: "orders"}, {Name: "other/users"}, {Name: "hidden"}}},
+		{Name: "orders", Owner: `"ops" <ops@example.com>`, Interval: "0 2 * * *"},
+		{Name: "hidden"},
+	})
+	return g.NewExport("sample", []string{"sample/report", "sample/orders", "other/users", "sample/report"})
+}
+
+func TestNewExport(t *testing.T) {
+	e := sampleExport()
+
+	var ids []string
+	for _, n := range e.Nodes {
+		ids = append(ids, n.ID)
+	}
+	if want := "[other/users sample/orders sample/report]"; fmt.Sprint(ids) != want {
+		t.Errorf("nodes %v, want %s", ids, want)
+	}
+	if external := e.Nodes[0]; external.Namespace != "" || external.Project != "other" || external.Name != "users" {
+		t.Errorf("external node %+v, want only its id", external)
+	}
+	if want := "[{other/users sample/report} {sample/orders sample/report}]"; fmt.Sprint(e.Edges) != want {
+		t.Errorf("edges %v, want %s", e.Edges, want)
+	}
+}
+
+func TestExportWrite(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: FormatDOT,
+			want: `digraph "sample" {
+  rankdir=LR;
+  node [shape=box, style=rounded, fontname="Helvetica"];
+
+  "other/users" [label="other/users", style=dashed];
+  "sample/orders" [label="sample/orders\nowner: \"ops\" <ops@example.com>\nschedule: 0 2 * * *", owner="\"ops\" <ops@example.com>", schedule="0 2 * * *"];
+  "sample/report" [label="sample/report\nowner: bi@example.com\nschedule: 0 4 * * *\ntask: bq2bq", owner="bi@example.com", schedule="0 4 * * *", task="bq2bq"];
+
+  "other/users" -> "sample/report";
+  "sample/orders" -> "sample/report";
+}
+`,
+		},
+		{
+			format: FormatMermaid,
+			want: `flowchart LR
+  n0(["other/users"])
+  n1["sample/orders<br/>owner: #quot;ops#quot; #lt;ops@example.com#gt;<br/>schedule: 0 2 * * *"]
+  n2["sample/report<br/>owner: bi@example.com<br/>schedule: 0 4 * * *<br/>task: bq2bq"]
+  n0 --> n2
+  n1 --> n2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if err := sampleExport().Write(b, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Write(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportWriteJSON(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := sampleExport().Write(b, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var got Export
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(*sampleExport()) {
+		t.Errorf("round trip = %+v, want %+v", got, *sampleExport())
+	}
+	if strings.Contains(b.String(), `"namespace": ""`) {
+		t.Errorf("external node written with an empty namespace:\n%s", b.String())
+	}
+}
+
+func TestExportWriteUnknownFormat(t *testing.T) {
+	if err := sampleExport().Write(&bytes.Buffer{}, "svg"); err == nil {
+		t.Error("Write(svg) succeeded, want an error")
+	}
+}
@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"strings"
+
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+)
+
+// LoadLocal builds the graph of the project from job specs read from disk
+func LoadLocal(project string, files []*spec.File) *Graph {
+	namespaces := map[string][]optimus.JobSpec{}
+	var order []string
+	for _, f := range files {
+		if _, ok := namespaces[f.Namespace]; !ok {
+			order = append(order, f.Namespace)
+		}
+		namespaces[f.Namespace] = append(namespaces[f.Namespace], f.Optimus())
+	}
+
+	g := New()
+	for _, ns := range order {
+		g.Add(project, ns, namespaces[ns])
+	}
+	return g
+}
+
+// CycleDiagnostics reports the dependency cycles of the local specs on every
+// job which is part of a cycle
+func CycleDiagnostics(project string, files []*spec.File) []spec.Diagnostic {
+	byID := map[string]*spec.File{}
+	for _, f := range files {
+		byID[ID(project, f.Job.Name)] = f
+	}
+
+	var diags []spec.Diagnostic
+	for _, cycle := range LoadLocal(project, files).Cycles() {
+		// jobs depending on themselves are reported by spec validation
+		if len(cycle) <= 2 {
+			continue
+		}
+		names := make([]string, len(cycle))
+		for i, id := range cycle {
+			names[i] = Name(id)
+		}
+		for _, id := range cycle[:len(cycle)-1] {
+			f, ok := byID[id]
+			if !ok {
+				continue
+			}
+			diags = append(diags, spec.Diagnostic{
+				Path:    f.Path,
+				Line:    f.Line("dependencies"),
+				Field:   "dependencies",
+				Message: "dependency cycle " + strings.Join(names, " → "),
+			})
+		}
+	}
+	return diags
+}
@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// LoadProject builds the graph of every namespace in the project from the server
+func LoadProject(ctx context.Context, client *optimus.Client, project string) (*Graph, error) {
+	namespaces, err := client.ListNamespaces(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	g := New()
+	for _, ns := range namespaces {
+		specs, err := client.ListJobSpecs(ctx, project, ns.Name)
+		if err != nil {
+			return nil, err
+		}
+		g.Add(project, ns.Name, specs)
+	}
+	return g, nil
+}
+
+// LoadExternal adds the projects referenced by cross-project dependencies which
+// are not part of the graph yet, it does not follow their own dependencies
+func LoadExternal(ctx context.Context, client *optimus.Client, g *Graph) error {
+	loaded := map[string]bool{}
+	for _, n := range g.Nodes() {
+		loaded[n.Project] = true
+	}
+
+	for _, project := range g.missingProjects(loaded) {
+		external, err := LoadProject(ctx, client, project)
+		if err != nil {
+			return err
+		}
+		for id, n := range external.nodes {
+			if g.nodes[id] == nil {
+				g.Add(n.Project, n.Namespace, []optimus.JobSpec{*n.Spec})
+			}
+		}
+	}
+	return nil
+}
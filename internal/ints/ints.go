@@ -0,0 +1,18 @@
+// Package ints provides the int helpers missing from the Go version the module targets.
+package ints
+
+// Min returns the smaller of a and b
+func Min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of a and b
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
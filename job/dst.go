@@ -0,0 +1,119 @@
+package job
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Transition is a change of UTC offset in a location, usually a DST switch
+type Transition struct {
+	At         time.Time
+	FromOffset time.Duration
+	ToOffset   time.Duration
+}
+
+// DayLength returns the wall-clock length of the day the transition happens on
+func (t Transition) DayLength() time.Duration {
+	start := StartOfDay(t.At)
+	return StartOfDay(start.AddDate(0, 0, 1)).Sub(start)
+}
+
+// DSTAffectedRun is a scheduled run whose window spans an offset transition
+// and therefore covers more or less time than its wall clock suggests
+type DSTAffectedRun struct {
+	ScheduledAt time.Time
+	WindowStart time.Time
+	WindowEnd   time.Time
+	Transition  Transition
+
+	// Expected is the duration of the window for the same wall clock
+	// in a location without transitions
+	Expected time.Duration
+}
+
+// Duration returns the elapsed time covered by the window of the run
+func (r DSTAffectedRun) Duration() time.Duration {
+	return r.WindowEnd.Sub(r.WindowStart)
+}
+
+func wallTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// Transitions lists the offset changes of loc during the year
+func Transitions(year int, loc *time.Location) []Transition {
+	var transitions []Transition
+
+	current := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc)
+	_, prevOffset := current.Zone()
+	for current.Before(end) {
+		next := current.Add(time.Hour)
+		_, offset := next.Zone()
+		if offset != prevOffset {
+			transitions = append(transitions, Transition{
+				At:         findTransition(current, next),
+				FromOffset: time.Duration(prevOffset) * time.Second,
+				ToOffset:   time.Duration(offset) * time.Second,
+			})
+			prevOffset = offset
+		}
+		current = next
+	}
+	return transitions
+}
+
+// findTransition narrows down the instant the offset changes between from and to
+func findTransition(from, to time.Time) time.Time {
+	_, fromOffset := from.Zone()
+	for to.Sub(from) > time.Minute {
+		mid := from.Add(to.Sub(from) / 2)
+		if _, offset := mid.Zone(); offset == fromOffset {
+			from = mid
+		} else {
+			to = mid
+		}
+	}
+	return to.Truncate(time.Minute)
+}
+
+// DSTReport lists the runs of a schedule during the year whose window crosses
+// an offset transition in loc, these are the runs processing 23 or 25 hours
+// of data for a daily window.
+func DSTReport(window DataWindow, schedule cron.Schedule, year int, loc *time.Location) []DSTAffectedRun {
+	transitions := Transitions(year, loc)
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	window.Location = loc
+	nominal := window
+	nominal.Location = time.UTC
+	var affected []DSTAffectedRun
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc)
+	for run := schedule.Next(start.Add(-time.Second)); run.Before(end) && !run.IsZero(); run = schedule.Next(run) {
+		windowStart, windowEnd := window.GetNextInterval(run)
+		expectedStart, expectedEnd := nominal.GetNextInterval(wallTime(run))
+		expected := expectedEnd.Sub(expectedStart)
+		for _, t := range transitions {
+			if t.At.Before(windowStart) || t.At.After(windowEnd) {
+				continue
+			}
+			r := DSTAffectedRun{
+				ScheduledAt: run,
+				WindowStart: windowStart,
+				WindowEnd:   windowEnd,
+				Transition:  t,
+				Expected:    expected,
+			}
+			if r.Duration() != r.Expected {
+				affected = append(affected, r)
+			}
+			break
+		}
+	}
+	return affected
+}
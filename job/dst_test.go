@@ -0,0 +1,130 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		want     []Transition
+		days     []time.Duration
+	}{
+		{
+			name:     "two transitions",
+			location: "Europe/Berlin",
+			want: []Transition{
+				{At: time.Date(2023, 3, 26, 1, 0, 0, 0, time.UTC), FromOffset: time.Hour, ToOffset: 2 * time.Hour},
+				{At: time.Date(2023, 10, 29, 1, 0, 0, 0, time.UTC), FromOffset: 2 * time.Hour, ToOffset: time.Hour},
+			},
+			days: []time.Duration{23 * time.Hour, 25 * time.Hour},
+		},
+		{
+			name:     "southern hemisphere",
+			location: "Australia/Sydney",
+			want: []Transition{
+				{At: time.Date(2023, 4, 1, 16, 0, 0, 0, time.UTC), FromOffset: 11 * time.Hour, ToOffset: 10 * time.Hour},
+				{At: time.Date(2023, 9, 30, 16, 0, 0, 0, time.UTC), FromOffset: 10 * time.Hour, ToOffset: 11 * time.Hour},
+			},
+			days: []time.Duration{25 * time.Hour, 23 * time.Hour},
+		},
+		{
+			name:     "without DST",
+			location: "Asia/Kolkata",
+		},
+		{
+			name:     "UTC",
+			location: "UTC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Transitions(2023, mustLoad(t, tt.location))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Transitions() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].At.Equal(tt.want[i].At) || got[i].FromOffset != tt.want[i].FromOffset || got[i].ToOffset != tt.want[i].ToOffset {
+					t.Errorf("transition %d is %+v, want %+v", i, got[i], tt.want[i])
+				}
+				if day := got[i].DayLength(); day != tt.days[i] {
+					t.Errorf("day of transition %d is %s long, want %s", i, day, tt.days[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDSTReport(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2023, month, day, hour, 0, 0, 0, berlin)
+	}
+
+	tests := []struct {
+		name     string
+		window   DataWindow
+		cron     string
+		location *time.Location
+		want     []DSTAffectedRun
+	}{
+		{
+			name:     "daily window",
+			window:   DataWindow{Size: 24 * time.Hour, TruncateTo: "d"},
+			cron:     "0 4 * * *",
+			location: berlin,
+			want: []DSTAffectedRun{
+				{ScheduledAt: at(3, 27, 4), WindowStart: at(3, 26, 0), WindowEnd: at(3, 27, 0), Expected: 24 * time.Hour},
+				{ScheduledAt: at(10, 30, 4), WindowStart: at(10, 29, 0), WindowEnd: at(10, 30, 0), Expected: 24 * time.Hour},
+			},
+		},
+		{
+			name:     "monthly window",
+			window:   DataWindow{Size: HoursInMonth, TruncateTo: "M"},
+			cron:     "0 4 1 * *",
+			location: berlin,
+			want: []DSTAffectedRun{
+				{ScheduledAt: at(3, 1, 4), WindowStart: at(3, 1, 0), WindowEnd: at(3, 31, 0), Expected: 30 * 24 * time.Hour},
+				{ScheduledAt: at(10, 1, 4), WindowStart: at(10, 1, 0), WindowEnd: at(10, 31, 0), Expected: 30 * 24 * time.Hour},
+			},
+		},
+		{
+			name:     "hourly window keeps its length",
+			window:   DataWindow{Size: time.Hour, TruncateTo: "h"},
+			cron:     "0 * * * *",
+			location: berlin,
+		},
+		{
+			name:     "location without transitions",
+			window:   DataWindow{Size: 24 * time.Hour, TruncateTo: "d"},
+			cron:     "0 4 * * *",
+			location: time.UTC,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := cron.ParseStandard(tt.cron)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := DSTReport(tt.window, schedule, 2023, tt.location)
+			if len(got) != len(tt.want) {
+				t.Fatalf("DSTReport() returned %d runs, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				r := got[i]
+				if !r.ScheduledAt.Equal(want.ScheduledAt) || !r.WindowStart.Equal(want.WindowStart) || !r.WindowEnd.Equal(want.WindowEnd) {
+					t.Errorf("run %d is %s with window %s - %s, want %s with window %s - %s",
+						i, r.ScheduledAt, r.WindowStart, r.WindowEnd, want.ScheduledAt, want.WindowStart, want.WindowEnd)
+				}
+				if r.Expected != want.Expected {
+					t.Errorf("run %d expected %s, want %s", i, r.Expected, want.Expected)
+				}
+			}
+		})
+	}
+}
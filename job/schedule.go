@@ -0,0 +1,187 @@
+package job
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// minInterval is the shortest interval between runs expected of batch jobs
+const minInterval = 5 * time.Minute
+
+// NextRuns returns the next n times the schedule fires after from
+func NextRuns(schedule cron.Schedule, from time.Time, n int) []time.Time {
+	var runs []time.Time
+	for t := schedule.Next(from); !t.IsZero() && len(runs) < n; t = schedule.Next(t) {
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+// PreviousRuns returns the last n times the schedule fired before, oldest
+// first. cron schedules only look forward, so runs are searched in windows
+// growing back from before.
+func PreviousRuns(schedule cron.Schedule, before time.Time, n int) []time.Time {
+	if n <= 0 {
+		return nil
+	}
+	for span := time.Hour; span <= 5*366*24*time.Hour; span *= 4 {
+		var runs []time.Time
+		for t := schedule.Next(before.Add(-span)); !t.IsZero() && t.Before(before); t = schedule.Next(t) {
+			runs = append(runs, t)
+			if len(runs) > n {
+				runs = runs[1:]
+			}
+		}
+		if len(runs) == n {
+			return runs
+		}
+	}
+
+	// fewer than n runs within the last five years
+	var runs []time.Time
+	for t := schedule.Next(before.Add(-5 * 366 * 24 * time.Hour)); !t.IsZero() && t.Before(before); t = schedule.Next(t) {
+		runs = append(runs, t)
+	}
+	if len(runs) > n {
+		runs = runs[len(runs)-n:]
+	}
+	return runs
+}
+
+// Gaps returns the durations between consecutive runs
+func Gaps(runs []time.Time) []time.Duration {
+	var gaps []time.Duration
+	for i := 1; i < len(runs); i++ {
+		gaps = append(gaps, runs[i].Sub(runs[i-1]))
+	}
+	return gaps
+}
+
+// DistinctGaps returns the different gaps between runs, shortest first
+func DistinctGaps(runs []time.Time) []time.Duration {
+	seen := map[time.Duration]bool{}
+	var gaps []time.Duration
+	for _, g := range Gaps(runs) {
+		if !seen[g] {
+			seen[g] = true
+			gaps = append(gaps, g)
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps
+}
+
+// ScheduleWarnings lists the surprises of a cron expression: schedules which
+// never or rarely fire, fire suspiciously often or at uneven intervals
+func ScheduleWarnings(expr string, schedule cron.Schedule, now time.Time) []string {
+	var warnings []string
+
+	runs := NextRuns(schedule, now, 500)
+	if len(runs) == 0 {
+		return []string{"never fires, no date matches the expression within the next five years"}
+	}
+	if len(runs) == 1 {
+		warnings = append(warnings, fmt.Sprintf("fires only once within the next five years, on %s", runs[0].Format("2006-01-02")))
+	} else if gaps := DistinctGaps(runs); gaps[len(gaps)-1] > 366*24*time.Hour {
+		warnings = append(warnings, fmt.Sprintf("fires rarely, up to %s between runs", FormatGap(gaps[len(gaps)-1])))
+	}
+	if gaps := DistinctGaps(runs); len(gaps) > 0 && gaps[0] < minInterval {
+		warnings = append(warnings, fmt.Sprintf("fires every %s, suspiciously often for a batch job", FormatGap(gaps[0])))
+	}
+
+	fields := cronFields(expr)
+	if len(fields) != 5 {
+		return warnings
+	}
+	if step, ok := fieldStep(fields[0]); ok && 60%step != 0 {
+		warnings = append(warnings, fmt.Sprintf("minute step %d does not divide the hour, runs are unevenly spaced around the full hour", step))
+	}
+	if step, ok := fieldStep(fields[1]); ok && 24%step != 0 {
+		warnings = append(warnings, fmt.Sprintf("hour step %d does not divide the day, runs are unevenly spaced around midnight", step))
+	}
+	if day := maxDayOfMonth(fields[2]); day > 28 {
+		warnings = append(warnings, fmt.Sprintf("day of month %d does not exist in every month, those months are skipped", day))
+	}
+	if fields[2] != "*" && fields[2] != "?" && fields[4] != "*" && fields[4] != "?" {
+		warnings = append(warnings, "day of month and day of week are both set, the job fires when either matches")
+	}
+	return warnings
+}
+
+// FormatGap renders a gap between runs like 1h30m, 7d or 7d1h
+func FormatGap(d time.Duration) string {
+	days := d / (24 * time.Hour)
+	rest := d - days*24*time.Hour
+
+	s := ""
+	if days > 0 {
+		s = fmt.Sprintf("%dd", days)
+	}
+	if rest > 0 || days == 0 {
+		r := strings.TrimSuffix(rest.String(), "0s")
+		if strings.HasSuffix(r, "h0m") {
+			r = strings.TrimSuffix(r, "0m")
+		}
+		s += r
+	}
+	return s
+}
+
+// SplitTimezone splits the CRON_TZ= or TZ= prefix from an expression, tz is
+// empty when the expression has none
+func SplitTimezone(expr string) (tz, rest string) {
+	expr = strings.TrimSpace(expr)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(expr, prefix) {
+			tz, rest, _ = strings.Cut(expr[len(prefix):], " ")
+			return tz, strings.TrimSpace(rest)
+		}
+	}
+	return "", expr
+}
+
+// cronFields returns the fields of an expression without its timezone prefix,
+// descriptors like @daily have no fields
+func cronFields(expr string) []string {
+	_, expr = SplitTimezone(expr)
+	if strings.HasPrefix(expr, "@") {
+		return nil
+	}
+	return strings.Fields(expr)
+}
+
+// fieldStep returns the step of a field like */7 or 5-50/10
+func fieldStep(field string) (int, bool) {
+	i := strings.Index(field, "/")
+	if i < 0 || strings.Contains(field, ",") {
+		return 0, false
+	}
+	step, err := strconv.Atoi(field[i+1:])
+	if err != nil || step <= 0 {
+		return 0, false
+	}
+	return step, true
+}
+
+// maxDayOfMonth returns the largest day listed in a day of month field, the
+// upper bound for ranges like 28-31, 0 when the field has no plain days
+func maxDayOfMonth(field string) int {
+	max := 0
+	for _, part := range strings.Split(field, ",") {
+		if strings.ContainsAny(part, "*?/") {
+			continue
+		}
+		if i := strings.Index(part, "-"); i >= 0 {
+			part = part[i+1:]
+		}
+		if day, err := strconv.Atoi(part); err == nil && day > max {
+			max = day
+		}
+	}
+	return max
+}
@@ -0,0 +1,27 @@
+package job
+
+import "testing"
+
+func TestMaxDayOfMonth(t *testing.T) {
+	tests := []struct {
+		field string
+		want  int
+	}{
+		{field: "*", want: 0},
+		{field: "?", want: 0},
+		{field: "*/2", want: 0},
+		{field: "15", want: 15},
+		{field: "1,15,30", want: 30},
+		{field: "28-31", want: 31},
+		{field: "1-5,20", want: 20},
+		{field: "1,29-30", want: 30},
+		{field: "25-31/2", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			if got := maxDayOfMonth(tt.field); got != tt.want {
+				t.Errorf("maxDayOfMonth(%q) = %d, want %d", tt.field, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package job
+
+import (
+	"archive/zip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// zoneDirs are the places the IANA timezone database is installed on unix systems
+var zoneDirs = []string{"/usr/share/zoneinfo", "/usr/share/lib/zoneinfo", "/usr/lib/locale/TZ"}
+
+// zoneRegions are the top level directories of the database holding
+// canonical zone names, others hold aliases or legacy names
+var zoneRegions = map[string]bool{
+	"Africa": true, "America": true, "Antarctica": true, "Arctic": true, "Asia": true,
+	"Atlantic": true, "Australia": true, "Europe": true, "Indian": true, "Pacific": true,
+}
+
+// Timezones lists the IANA timezone names known to the system, sorted with
+// UTC first. The database of the system is preferred, the copy shipped with
+// Go is used when there is none.
+func Timezones() []string {
+	seen := map[string]bool{}
+	add := func(name string) {
+		name = filepath.ToSlash(name)
+		region, _, ok := strings.Cut(name, "/")
+		if ok && zoneRegions[region] {
+			seen[name] = true
+		}
+	}
+
+	dirs := zoneDirs
+	if dir := os.Getenv("ZONEINFO"); dir != "" {
+		dirs = append([]string{dir}, dirs...)
+	}
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if name, err := filepath.Rel(dir, path); err == nil {
+				add(name)
+			}
+			return nil
+		})
+		if len(seen) > 0 {
+			break
+		}
+	}
+	if len(seen) == 0 {
+		if r, err := zip.OpenReader(filepath.Join(runtime.GOROOT(), "lib", "time", "zoneinfo.zip")); err == nil {
+			for _, f := range r.File {
+				add(f.Name)
+			}
+			r.Close()
+		}
+	}
+
+	names := make([]string, 0, len(seen)+1)
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{"UTC"}, names...)
+}
+
+// LocalTimezone returns the IANA name of the local timezone, or UTC when the
+// name cannot be found
+func LocalTimezone() string {
+	if tz := os.Getenv("TZ"); tz != "" {
+		if _, err := time.LoadLocation(tz); err == nil {
+			return tz
+		}
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+			if name == "Etc/UTC" || name == "Etc/Universal" || name == "Etc/Zulu" {
+				return "UTC"
+			}
+			if _, err := time.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+	if name := time.Local.String(); name != "Local" {
+		return name
+	}
+	return "UTC"
+}
@@ -1,6 +1,9 @@
 package job
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 const (
 	HoursInMonth = time.Duration(30) * 24 * time.Hour
@@ -11,36 +14,79 @@ type DataWindow struct {
 	Size       time.Duration
 	Offset     time.Duration
 	TruncateTo string
+
+	// Location is the zone used for wall-clock truncation, defaults to the
+	// location of the reference time when nil
+	Location *time.Location
+}
+
+// NewDataWindow parses the window fields of a job spec, size and offset are
+// durations like 24h or -1h
+func NewDataWindow(size, offset, truncateTo string) (*DataWindow, error) {
+	w := &DataWindow{TruncateTo: truncateTo}
+
+	var err error
+	if size != "" {
+		if w.Size, err = time.ParseDuration(size); err != nil {
+			return nil, fmt.Errorf("invalid window size %s: %w", size, err)
+		}
+	}
+	if offset != "" {
+		if w.Offset, err = time.ParseDuration(offset); err != nil {
+			return nil, fmt.Errorf("invalid window offset %s: %w", offset, err)
+		}
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Validate checks the window can be used to compute an interval
+func (d *DataWindow) Validate() error {
+	switch d.TruncateTo {
+	case "", "h", "d", "w", "M":
+	default:
+		return fmt.Errorf("invalid window truncate_to %s, should be one of h, d, w, M", d.TruncateTo)
+	}
+	if d.Size <= 0 {
+		return fmt.Errorf("window size should be greater than 0")
+	}
+	return nil
 }
 
 // GetNextInterval returns the interval for current window configuration
 func (d *DataWindow) GetNextInterval(today time.Time) (time.Time, time.Time) {
+	if d.Location != nil {
+		today = today.In(d.Location)
+	}
 	floatingEnd := today
 
 	// apply truncation to end
 	if d.TruncateTo == "h" {
 		// remove time upto hours
-		floatingEnd = floatingEnd.Truncate(time.Hour)
+		floatingEnd = StartOfHour(floatingEnd)
 	} else if d.TruncateTo == "d" {
 		// remove time upto day
-		floatingEnd = floatingEnd.Truncate(HoursInDay)
+		floatingEnd = StartOfDay(floatingEnd)
 	} else if d.TruncateTo == "w" {
 		// shift current window to nearest Sunday
-		nearestSunday := time.Duration(time.Saturday-floatingEnd.Weekday()+1) * HoursInDay
-		floatingEnd = floatingEnd.Add(nearestSunday)
-		floatingEnd = floatingEnd.Truncate(HoursInDay)
+		nearestSunday := int(time.Saturday - floatingEnd.Weekday() + 1)
+		floatingEnd = floatingEnd.AddDate(0, 0, nearestSunday)
+		floatingEnd = StartOfDay(floatingEnd)
 	}
 
-	windowEnd := floatingEnd.Add(d.Offset)
-	windowStart := windowEnd.Add(-d.Size)
+	windowEnd := AddWallClock(floatingEnd, d.Offset)
+	windowStart := AddWallClock(windowEnd, -d.Size)
 
 	// handle monthly windows separately as every month is not of same size
 	if d.TruncateTo == "M" {
 		floatingEnd = today
+		loc := today.Location()
 		// shift current window to nearest month start and end
 
 		// truncate the date
-		floatingEnd = time.Date(floatingEnd.Year(), floatingEnd.Month(), 1, 0, 0, 0, 0, time.UTC)
+		floatingEnd = time.Date(floatingEnd.Year(), floatingEnd.Month(), 1, 0, 0, 0, 0, loc)
 
 		// then add the month offset
 		// for handling offset, treat 30 days as 1 month
@@ -51,15 +97,15 @@ func (d *DataWindow) GetNextInterval(today time.Time) (time.Time, time.Time) {
 		floatingEnd = floatingEnd.AddDate(0, 1, -1)
 
 		// final end is computed
-		windowEnd = floatingEnd.Truncate(HoursInDay)
+		windowEnd = StartOfDay(floatingEnd)
 
 		// truncate days/hours from window start as well
-		floatingStart := time.Date(floatingEnd.Year(), floatingEnd.Month(), 1, 0, 0, 0, 0, time.UTC)
+		floatingStart := StartOfDay(time.Date(floatingEnd.Year(), floatingEnd.Month(), 1, 0, 0, 0, 0, loc))
 		// for handling size, treat 30 days as 1 month, and as we have already truncated current month
 		// subtract 1 from this
 		sizeMonths := (d.Size / HoursInMonth) - 1
 		if sizeMonths > 0 {
-			floatingStart = floatingStart.AddDate(0, int(-sizeMonths), 0)
+			floatingStart = StartOfDay(floatingStart.AddDate(0, int(-sizeMonths), 0))
 		}
 
 		// final start is computed
@@ -68,3 +114,49 @@ func (d *DataWindow) GetNextInterval(today time.Time) (time.Time, time.Time) {
 
 	return windowStart, windowEnd
 }
+
+// StartOfHour removes minutes and smaller units from the wall clock of t,
+// this keeps zones with half-hour offsets aligned to their own hours
+func StartOfHour(t time.Time) time.Time {
+	sinceHour := time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second +
+		time.Duration(t.Nanosecond())
+	return t.Add(-sinceHour)
+}
+
+// StartOfDay returns the first instant of the calendar day of t in its location.
+// When midnight does not exist because of a DST jump, the first valid instant
+// of that day is returned.
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return wallClock(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// AddWallClock adds whole days of dur as calendar days and the remainder as
+// elapsed time, so a day on a DST transition is 23 or 25 hours long
+func AddWallClock(t time.Time, dur time.Duration) time.Time {
+	days := int(dur / HoursInDay)
+	rest := dur % HoursInDay
+	if days == 0 {
+		return t.Add(rest)
+	}
+
+	year, month, day := t.Date()
+	moved := wallClock(year, month, day+days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	return moved.Add(rest)
+}
+
+// wallClock works like time.Date, but when the wall clock falls into a DST gap
+// it is always moved forward by the length of the gap, so 02:30 on a day
+// jumping from 02:00 to 03:00 resolves to 03:30. time.Date leaves the
+// direction unspecified.
+func wallClock(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, nsec, loc)
+
+	requested := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+	resolved := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if resolved.Before(requested) {
+		t = t.Add(requested.Sub(resolved))
+	}
+	return t
+}
@@ -0,0 +1,283 @@
+package job
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestStartOfHour(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	kolkata := mustLoad(t, "Asia/Kolkata")
+	// 01:30 happens twice on 2022-11-06 in New York, first in EDT, then in EST
+	firstHalfPast := time.Date(2022, 11, 6, 5, 30, 0, 0, time.UTC).In(newYork)
+	secondHalfPast := time.Date(2022, 11, 6, 6, 30, 0, 0, time.UTC).In(newYork)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{
+			name: "regular hour",
+			t:    time.Date(2022, 3, 1, 10, 45, 12, 500, newYork),
+			want: time.Date(2022, 3, 1, 10, 0, 0, 0, newYork),
+		},
+		{
+			name: "hour after spring forward",
+			t:    time.Date(2022, 3, 13, 3, 20, 0, 0, newYork),
+			want: time.Date(2022, 3, 13, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "first 01:30 on fall back",
+			t:    firstHalfPast,
+			want: time.Date(2022, 11, 6, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "second 01:30 on fall back",
+			t:    secondHalfPast,
+			want: time.Date(2022, 11, 6, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "half-hour offset",
+			t:    time.Date(2022, 3, 1, 10, 45, 0, 0, kolkata),
+			want: time.Date(2022, 3, 1, 10, 0, 0, 0, kolkata),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StartOfHour(tt.t); !got.Equal(tt.want) {
+				t.Errorf("StartOfHour(%s) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartOfDay(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	// clocks in Sao Paulo jumped from 00:00 to 01:00 on 2018-11-04
+	saoPaulo := mustLoad(t, "America/Sao_Paulo")
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{
+			name: "spring forward",
+			t:    time.Date(2022, 3, 13, 18, 0, 0, 0, newYork),
+			want: time.Date(2022, 3, 13, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "fall back",
+			t:    time.Date(2022, 11, 6, 18, 0, 0, 0, newYork),
+			want: time.Date(2022, 11, 6, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "midnight skipped",
+			t:    time.Date(2018, 11, 4, 18, 0, 0, 0, saoPaulo),
+			want: time.Date(2018, 11, 4, 1, 0, 0, 0, saoPaulo),
+		},
+		{
+			name: "day after midnight skipped",
+			t:    time.Date(2018, 11, 5, 18, 0, 0, 0, saoPaulo),
+			want: time.Date(2018, 11, 5, 2, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StartOfDay(tt.t); !got.Equal(tt.want) {
+				t.Errorf("StartOfDay(%s) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddWallClock(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name    string
+		t       time.Time
+		dur     time.Duration
+		want    time.Time
+		elapsed time.Duration
+	}{
+		{
+			name:    "day over spring forward",
+			t:       time.Date(2022, 3, 12, 12, 0, 0, 0, newYork),
+			dur:     24 * time.Hour,
+			want:    time.Date(2022, 3, 13, 12, 0, 0, 0, newYork),
+			elapsed: 23 * time.Hour,
+		},
+		{
+			name:    "day over fall back",
+			t:       time.Date(2022, 11, 5, 12, 0, 0, 0, newYork),
+			dur:     24 * time.Hour,
+			want:    time.Date(2022, 11, 6, 12, 0, 0, 0, newYork),
+			elapsed: 25 * time.Hour,
+		},
+		{
+			name:    "day into the gap",
+			t:       time.Date(2022, 3, 12, 2, 30, 0, 0, newYork),
+			dur:     24 * time.Hour,
+			want:    time.Date(2022, 3, 13, 3, 30, 0, 0, newYork),
+			elapsed: 24 * time.Hour,
+		},
+		{
+			name:    "days and hours over spring forward",
+			t:       time.Date(2022, 3, 12, 12, 0, 0, 0, newYork),
+			dur:     36 * time.Hour,
+			want:    time.Date(2022, 3, 14, 0, 0, 0, 0, newYork),
+			elapsed: 35 * time.Hour,
+		},
+		{
+			name:    "hours over spring forward",
+			t:       time.Date(2022, 3, 13, 1, 30, 0, 0, newYork),
+			dur:     time.Hour,
+			want:    time.Date(2022, 3, 13, 3, 30, 0, 0, newYork),
+			elapsed: time.Hour,
+		},
+		{
+			name:    "hours over fall back",
+			t:       time.Date(2022, 11, 6, 0, 30, 0, 0, newYork),
+			dur:     2 * time.Hour,
+			want:    time.Date(2022, 11, 6, 1, 30, 0, 0, time.FixedZone("EST", -5*3600)),
+			elapsed: 2 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AddWallClock(tt.t, tt.dur)
+			if !got.Equal(tt.want) {
+				t.Errorf("AddWallClock(%s, %s) = %s, want %s", tt.t, tt.dur, got, tt.want)
+			}
+			if elapsed := got.Sub(tt.t); elapsed != tt.elapsed {
+				t.Errorf("AddWallClock(%s, %s) moved %s, want %s", tt.t, tt.dur, elapsed, tt.elapsed)
+			}
+		})
+	}
+}
+
+func TestGetNextIntervalAcrossDST(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, berlin)
+	}
+
+	// Berlin moves from UTC+1 to UTC+2 on 2023-03-26, a 23 hour day, and
+	// back on 2023-10-29, a 25 hour day
+	tests := []struct {
+		name      string
+		window    DataWindow
+		today     time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+		duration  time.Duration
+	}{
+		{
+			name:      "hourly window after spring forward",
+			window:    DataWindow{Size: time.Hour, TruncateTo: "h"},
+			today:     at(2023, 3, 26, 3, 30),
+			wantStart: at(2023, 3, 26, 1, 0),
+			wantEnd:   at(2023, 3, 26, 3, 0),
+			duration:  time.Hour,
+		},
+		{
+			name:      "hourly window in the repeated hour",
+			window:    DataWindow{Size: time.Hour, TruncateTo: "h"},
+			today:     time.Date(2023, 10, 29, 1, 30, 0, 0, time.UTC),
+			wantStart: time.Date(2023, 10, 29, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2023, 10, 29, 1, 0, 0, 0, time.UTC),
+			duration:  time.Hour,
+		},
+		{
+			name:      "day of hours over spring forward",
+			window:    DataWindow{Size: 24 * time.Hour, TruncateTo: "h"},
+			today:     at(2023, 3, 27, 1, 30),
+			wantStart: at(2023, 3, 26, 1, 0),
+			wantEnd:   at(2023, 3, 27, 1, 0),
+			duration:  23 * time.Hour,
+		},
+		{
+			name:      "daily window on the 23 hour day",
+			window:    DataWindow{Size: 24 * time.Hour, TruncateTo: "d"},
+			today:     at(2023, 3, 27, 2, 0),
+			wantStart: at(2023, 3, 26, 0, 0),
+			wantEnd:   at(2023, 3, 27, 0, 0),
+			duration:  23 * time.Hour,
+		},
+		{
+			name:      "daily window on the 25 hour day",
+			window:    DataWindow{Size: 24 * time.Hour, TruncateTo: "d"},
+			today:     at(2023, 10, 30, 2, 0),
+			wantStart: at(2023, 10, 29, 0, 0),
+			wantEnd:   at(2023, 10, 30, 0, 0),
+			duration:  25 * time.Hour,
+		},
+		{
+			// hours of the offset are elapsed time, twelve hours after
+			// midnight is 13:00 on the 23 hour day
+			name:      "daily window with an offset into the 23 hour day",
+			window:    DataWindow{Size: 24 * time.Hour, Offset: 12 * time.Hour, TruncateTo: "d"},
+			today:     at(2023, 3, 26, 18, 0),
+			wantStart: at(2023, 3, 25, 13, 0),
+			wantEnd:   at(2023, 3, 26, 13, 0),
+			duration:  23 * time.Hour,
+		},
+		{
+			name:      "weekly window over spring forward",
+			window:    DataWindow{Size: 7 * 24 * time.Hour, TruncateTo: "w"},
+			today:     at(2023, 3, 27, 2, 0),
+			wantStart: at(2023, 3, 26, 0, 0),
+			wantEnd:   at(2023, 4, 2, 0, 0),
+			duration:  7*24*time.Hour - time.Hour,
+		},
+		{
+			name:      "weekly window over fall back",
+			window:    DataWindow{Size: 7 * 24 * time.Hour, TruncateTo: "w"},
+			today:     at(2023, 10, 30, 2, 0),
+			wantStart: at(2023, 10, 29, 0, 0),
+			wantEnd:   at(2023, 11, 5, 0, 0),
+			duration:  7*24*time.Hour + time.Hour,
+		},
+		{
+			name:      "monthly window over spring forward",
+			window:    DataWindow{Size: HoursInMonth, TruncateTo: "M"},
+			today:     at(2023, 3, 27, 2, 0),
+			wantStart: at(2023, 3, 1, 0, 0),
+			wantEnd:   at(2023, 3, 31, 0, 0),
+			duration:  30*24*time.Hour - time.Hour,
+		},
+		{
+			name:      "monthly window over fall back",
+			window:    DataWindow{Size: HoursInMonth, TruncateTo: "M"},
+			today:     at(2023, 10, 30, 2, 0),
+			wantStart: at(2023, 10, 1, 0, 0),
+			wantEnd:   at(2023, 10, 31, 0, 0),
+			duration:  30*24*time.Hour + time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := tt.window
+			window.Location = berlin
+			start, end := window.GetNextInterval(tt.today)
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("GetNextInterval(%s) = %s - %s, want %s - %s", tt.today, start, end, tt.wantStart, tt.wantEnd)
+			}
+			if d := end.Sub(start); d != tt.duration {
+				t.Errorf("GetNextInterval(%s) covers %s, want %s", tt.today, d, tt.duration)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sbchaos/mirage/spec"
+)
+
+// ConfigFileName is the lint config looked up in the working directory
+const ConfigFileName = ".mirage-lint.yaml"
+
+// Config enables and tunes the built-in rules and declares custom rules
+type Config struct {
+	Rules  map[string]RuleConfig `yaml:"rules"`
+	Custom []CustomRule          `yaml:"custom"`
+}
+
+// RuleConfig overrides the defaults of a rule, Pattern and Allowed are only
+// used by the built-in rules documenting them
+type RuleConfig struct {
+	Severity string   `yaml:"severity"`
+	Disabled bool     `yaml:"disabled"`
+	Pattern  string   `yaml:"pattern"`
+	Allowed  []string `yaml:"allowed"`
+}
+
+// CustomRule checks a field of job.yaml, addressed by its keys joined with dots
+// like labels.team or task.config.DATASET
+type CustomRule struct {
+	ID          string `yaml:"id"`
+	Description string `yaml:"description"`
+	Severity    string `yaml:"severity"`
+
+	// Task limits the rule to jobs of a task
+	Task  string `yaml:"task"`
+	Field string `yaml:"field"`
+
+	Required bool `yaml:"required"`
+
+	// Pattern must match the value of the field, Forbid must not
+	Pattern string `yaml:"pattern"`
+	Forbid  string `yaml:"forbid"`
+
+	// Message replaces the generated message of findings
+	Message string `yaml:"message"`
+}
+
+// LoadConfig reads the lint config at path, it returns nil without an error
+// when the file does not exist
+func LoadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	for id, rc := range cfg.Rules {
+		if rc.Severity != "" && !ValidSeverity(rc.Severity) {
+			return nil, fmt.Errorf("invalid %s: severity %s of rule %s should be one of error, warning, info", path, rc.Severity, id)
+		}
+	}
+	return cfg, nil
+}
+
+type customRule struct {
+	CustomRule
+	keys    []string
+	pattern *regexp.Regexp
+	forbid  *regexp.Regexp
+}
+
+func (c CustomRule) compile() (*customRule, error) {
+	if c.ID == "" {
+		return nil, fmt.Errorf("custom rule of field %s has no id", c.Field)
+	}
+	if c.Field == "" {
+		return nil, fmt.Errorf("custom rule %s has no field", c.ID)
+	}
+	if c.Severity == "" {
+		c.Severity = SeverityWarning
+	}
+	if !ValidSeverity(c.Severity) {
+		return nil, fmt.Errorf("custom rule %s: severity %s should be one of error, warning, info", c.ID, c.Severity)
+	}
+	if !c.Required && c.Pattern == "" && c.Forbid == "" {
+		return nil, fmt.Errorf("custom rule %s checks nothing, set required, pattern or forbid", c.ID)
+	}
+
+	rule := &customRule{CustomRule: c, keys: strings.Split(c.Field, ".")}
+	var err error
+	if c.Pattern != "" {
+		if rule.pattern, err = regexp.Compile(c.Pattern); err != nil {
+			return nil, fmt.Errorf("custom rule %s: invalid pattern: %w", c.ID, err)
+		}
+	}
+	if c.Forbid != "" {
+		if rule.forbid, err = regexp.Compile(c.Forbid); err != nil {
+			return nil, fmt.Errorf("custom rule %s: invalid forbid: %w", c.ID, err)
+		}
+	}
+	return rule, nil
+}
+
+func (r *customRule) ID() string       { return r.CustomRule.ID }
+func (r *customRule) Severity() string { return r.CustomRule.Severity }
+
+func (r *customRule) Description() string {
+	if r.CustomRule.Description != "" {
+		return r.CustomRule.Description
+	}
+	return "custom rule on " + r.Field
+}
+
+func (r *customRule) Check(f *spec.File) []spec.Diagnostic {
+	if r.Task != "" && !strings.EqualFold(r.Task, f.Job.Task.Name) {
+		return nil
+	}
+
+	report := func(message string) []spec.Diagnostic {
+		if r.Message != "" {
+			message = r.Message
+		}
+		return []spec.Diagnostic{{Path: f.Path, Line: f.Line(r.keys...), Field: r.Field, Message: message}}
+	}
+
+	value, ok := f.Value(r.keys...)
+	switch {
+	case !ok || value == "":
+		if r.Required {
+			return report(r.Field + " is required")
+		}
+	case r.pattern != nil && !r.pattern.MatchString(value):
+		return report(fmt.Sprintf("%s %q does not match %s", r.Field, value, r.Pattern))
+	case r.forbid != nil && r.forbid.MatchString(value):
+		return report(fmt.Sprintf("%s %q matches %s, which is not allowed", r.Field, value, r.Forbid))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCustomRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    CustomRule
+		content string
+		want    []int
+		err     string
+	}{
+		{
+			name:    "required field missing",
+			rule:    CustomRule{ID: "team", Field: "labels.team", Required: true},
+			content: "name: a\nlabels:\n  tier: gold\n",
+			want:    []int{2},
+		},
+		{
+			name:    "required field set",
+			rule:    CustomRule{ID: "team", Field: "labels.team", Required: true},
+			content: "name: a\nlabels:\n  team: a\n",
+		},
+		{
+			name:    "pattern not matched",
+			rule:    CustomRule{ID: "dataset", Field: "task.config.DATASET", Pattern: "^mart_"},
+			content: "name: a\ntask:\n  name: bq2bq\n  config:\n    DATASET: scratch\n",
+			want:    []int{5},
+		},
+		{
+			name:    "forbidden value",
+			rule:    CustomRule{ID: "dataset", Field: "task.config.DATASET", Forbid: "^tmp"},
+			content: "name: a\ntask:\n  name: bq2bq\n  config:\n    DATASET: tmp_a\n",
+			want:    []int{5},
+		},
+		{
+			name:    "other task",
+			rule:    CustomRule{ID: "dataset", Task: "bq2bq", Field: "task.config.DATASET", Required: true},
+			content: "name: a\ntask:\n  name: python\n",
+		},
+		{
+			name: "without id",
+			rule: CustomRule{Field: "labels.team", Required: true},
+			err:  "has no id",
+		},
+		{
+			name: "without field",
+			rule: CustomRule{ID: "team", Required: true},
+			err:  "has no field",
+		},
+		{
+			name: "without check",
+			rule: CustomRule{ID: "team", Field: "labels.team"},
+			err:  "checks nothing",
+		},
+		{
+			name: "invalid severity",
+			rule: CustomRule{ID: "team", Field: "labels.team", Required: true, Severity: "fatal"},
+			err:  "severity fatal",
+		},
+		{
+			name: "invalid pattern",
+			rule: CustomRule{ID: "team", Field: "labels.team", Pattern: "("},
+			err:  "invalid pattern",
+		},
+		{
+			name: "same id as a built-in rule",
+			rule: CustomRule{ID: RuleOwnerEmail, Field: "owner", Required: true},
+			err:  "defined more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Custom: []CustomRule{tt.rule}}
+			if tt.err != "" {
+				_, err := New(cfg)
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if got := lintLines(t, cfg, tt.rule.ID, tt.content); !equalLines(got, tt.want) {
+				t.Errorf("%s reported lines %v, want %v", tt.rule.ID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Package lint checks job specs against the conventions of a team, beyond the
+// hard validation of the spec package.
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sbchaos/mirage/spec"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// severityRank orders severities from the least to the most severe
+var severityRank = map[string]int{SeverityInfo: 0, SeverityWarning: 1, SeverityError: 2}
+
+// ValidSeverity reports whether s is a known severity
+func ValidSeverity(s string) bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+// AtLeast reports whether severity s is as severe as min
+func AtLeast(s, min string) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+// Rule checks a convention on a single job spec
+type Rule interface {
+	// ID identifies the rule in the config and in suppression comments
+	ID() string
+	Description() string
+
+	// Severity is the severity of findings unless configured otherwise
+	Severity() string
+	Check(f *spec.File) []spec.Diagnostic
+}
+
+// Finding is a diagnostic reported by a rule
+type Finding struct {
+	spec.Diagnostic
+	Rule     string
+	Severity string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s [%s] %s", f.Path, f.Line, f.Severity, f.Rule, f.Message)
+}
+
+type enabledRule struct {
+	rule     Rule
+	severity string
+}
+
+// Linter runs the enabled rules over job specs
+type Linter struct {
+	rules []enabledRule
+}
+
+// New creates a linter from the built-in rules and the custom rules of cfg,
+// cfg may be nil to use the defaults of the built-in rules
+func New(cfg *Config) (*Linter, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	rules, err := builtinRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cfg.Custom {
+		rule, err := c.compile()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	l := &Linter{}
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		if seen[rule.ID()] {
+			return nil, fmt.Errorf("rule %s is defined more than once", rule.ID())
+		}
+		seen[rule.ID()] = true
+
+		rc := cfg.Rules[rule.ID()]
+		if rc.Disabled {
+			continue
+		}
+		severity := rule.Severity()
+		if rc.Severity != "" {
+			severity = rc.Severity
+		}
+		l.rules = append(l.rules, enabledRule{rule: rule, severity: severity})
+	}
+	for id := range cfg.Rules {
+		if !seen[id] {
+			return nil, fmt.Errorf("rule %s configured in the lint config does not exist", id)
+		}
+	}
+	return l, nil
+}
+
+// Rules returns the enabled rules with their configured severity
+func (l *Linter) Rules() []Finding {
+	rules := make([]Finding, len(l.rules))
+	for i, r := range l.rules {
+		rules[i] = Finding{Rule: r.rule.ID(), Severity: r.severity, Diagnostic: spec.Diagnostic{Message: r.rule.Description()}}
+	}
+	return rules
+}
+
+// Lint runs every rule over the files and drops findings suppressed by
+// comments, findings are sorted by file and line
+func (l *Linter) Lint(files []*spec.File) ([]Finding, error) {
+	var findings []Finding
+	for _, f := range files {
+		suppressed, err := loadSuppressions(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range l.rules {
+			for _, d := range r.rule.Check(f) {
+				if suppressed.covers(r.rule.ID(), d.Line) {
+					continue
+				}
+				findings = append(findings, Finding{Diagnostic: d, Rule: r.rule.ID(), Severity: r.severity})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
@@ -0,0 +1,177 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/job"
+	"github.com/sbchaos/mirage/spec"
+)
+
+const (
+	RuleOwnerEmail          = "owner-email"
+	RuleBQ2BQDataset        = "bq2bq-dataset"
+	RuleHourlyMonthlyWindow = "hourly-monthly-window"
+	RuleCronMinuteZero      = "cron-minute-zero"
+)
+
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+func builtinRules(cfg *Config) ([]Rule, error) {
+	owner := &ownerEmail{}
+	if p := cfg.Rules[RuleOwnerEmail].Pattern; p != "" {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: invalid pattern: %w", RuleOwnerEmail, err)
+		}
+		owner.pattern = re
+	}
+
+	return []Rule{
+		owner,
+		&bq2bqDataset{allowed: cfg.Rules[RuleBQ2BQDataset].Allowed},
+		hourlyMonthlyWindow{},
+		cronMinuteZero{},
+	}, nil
+}
+
+// ownerEmail requires the owner to be an email, matching pattern when configured
+type ownerEmail struct {
+	pattern *regexp.Regexp
+}
+
+func (r *ownerEmail) ID() string       { return RuleOwnerEmail }
+func (r *ownerEmail) Severity() string { return SeverityError }
+
+func (r *ownerEmail) Description() string {
+	if r.pattern != nil {
+		return "owner must be an email matching " + r.pattern.String()
+	}
+	return "owner must be an email, configure pattern to require a team domain"
+}
+
+func (r *ownerEmail) Check(f *spec.File) []spec.Diagnostic {
+	owner := f.Job.Owner
+	if owner == "" {
+		return nil
+	}
+	message := ""
+	switch {
+	case !emailPattern.MatchString(owner):
+		message = fmt.Sprintf("owner %s is not an email", owner)
+	case r.pattern != nil && !r.pattern.MatchString(owner):
+		message = fmt.Sprintf("owner %s does not match %s", owner, r.pattern)
+	default:
+		return nil
+	}
+	return []spec.Diagnostic{{Path: f.Path, Line: f.Line("owner"), Field: "owner", Message: message}}
+}
+
+// bq2bqDataset requires the destination of bq2bq jobs to be one of the allowed
+// datasets, given as dataset or project.dataset, and is a no-op without them
+type bq2bqDataset struct {
+	allowed []string
+}
+
+func (r *bq2bqDataset) ID() string       { return RuleBQ2BQDataset }
+func (r *bq2bqDataset) Severity() string { return SeverityError }
+
+func (r *bq2bqDataset) Description() string {
+	if len(r.allowed) == 0 {
+		return "bq2bq destinations must be in an approved dataset, configure allowed to enable"
+	}
+	return "bq2bq destinations must be in one of " + strings.Join(r.allowed, ", ")
+}
+
+func (r *bq2bqDataset) Check(f *spec.File) []spec.Diagnostic {
+	if len(r.allowed) == 0 || !strings.EqualFold(f.Job.Task.Name, "bq2bq") {
+		return nil
+	}
+	project, dataset := f.Job.Task.Config["PROJECT"], f.Job.Task.Config["DATASET"]
+	if dataset == "" {
+		return nil
+	}
+	for _, a := range r.allowed {
+		if a == dataset || a == project+"."+dataset {
+			return nil
+		}
+	}
+	return []spec.Diagnostic{{
+		Path:    f.Path,
+		Line:    f.Line("task", "config", "DATASET"),
+		Field:   "task.config.DATASET",
+		Message: fmt.Sprintf("destination dataset %s.%s is not one of %s", project, dataset, strings.Join(r.allowed, ", ")),
+	}}
+}
+
+// hourlyMonthlyWindow flags jobs running at least hourly which read a month of data
+type hourlyMonthlyWindow struct{}
+
+func (hourlyMonthlyWindow) ID() string       { return RuleHourlyMonthlyWindow }
+func (hourlyMonthlyWindow) Severity() string { return SeverityWarning }
+func (hourlyMonthlyWindow) Description() string {
+	return "jobs running hourly or more often must not have a monthly window"
+}
+
+func (hourlyMonthlyWindow) Check(f *spec.File) []spec.Diagnostic {
+	w := f.Job.Task.Window
+	window, err := job.NewDataWindow(w.Size, w.Offset, w.TruncateTo)
+	if err != nil || (window.TruncateTo != "M" && window.Size < job.HoursInMonth) {
+		return nil
+	}
+	schedule, err := cron.ParseStandard(f.Job.Schedule.Interval)
+	if err != nil || !runsHourly(schedule) {
+		return nil
+	}
+	return []spec.Diagnostic{{
+		Path:    f.Path,
+		Line:    f.Line("task", "window"),
+		Field:   "task.window",
+		Message: fmt.Sprintf("job runs hourly with a monthly window (size %s, truncate_to %s), every run reprocesses the month", w.Size, w.TruncateTo),
+	}}
+}
+
+// runsHourly reports whether two runs of a day are at most an hour apart
+func runsHourly(schedule cron.Schedule) bool {
+	t := schedule.Next(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC))
+	for i := 0; i < 24 && !t.IsZero(); i++ {
+		next := schedule.Next(t)
+		if !next.IsZero() && next.Sub(t) <= time.Hour {
+			return true
+		}
+		t = next
+	}
+	return false
+}
+
+// cronMinuteZero flags schedules starting at minute 0, where most jobs of the
+// scheduler start and queue behind each other
+type cronMinuteZero struct{}
+
+func (cronMinuteZero) ID() string       { return RuleCronMinuteZero }
+func (cronMinuteZero) Severity() string { return SeverityInfo }
+func (cronMinuteZero) Description() string {
+	return "schedules should not start at minute 0, spread jobs over the hour"
+}
+
+func (cronMinuteZero) Check(f *spec.File) []spec.Diagnostic {
+	_, interval := job.SplitTimezone(f.Job.Schedule.Interval)
+	fields := strings.Fields(interval)
+	switch {
+	case len(fields) == 5 && fields[0] == "0":
+	case interval == "@hourly" || interval == "@daily" || interval == "@midnight" ||
+		interval == "@weekly" || interval == "@monthly" || interval == "@yearly" || interval == "@annually":
+	default:
+		return nil
+	}
+	return []spec.Diagnostic{{
+		Path:    f.Path,
+		Line:    f.Line("schedule", "interval"),
+		Field:   "schedule.interval",
+		Message: fmt.Sprintf("schedule %s starts at minute 0 along with most jobs, pick another minute", interval),
+	}}
+}
@@ -0,0 +1,153 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sbchaos/mirage/spec"
+)
+
+func writeSpec(t *testing.T, content string) *spec.File {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), spec.FileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := spec.LoadFile(path, "sample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// lintLines returns the lines of the findings of rule in content
+func lintLines(t *testing.T, cfg *Config, rule, content string) []int {
+	t.Helper()
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := l.Lint([]*spec.File{writeSpec(t, content)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	for _, f := range findings {
+		if f.Rule == rule {
+			lines = append(lines, f.Line)
+		}
+	}
+	return lines
+}
+
+func equalLines(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuiltinRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		cfg     *Config
+		content string
+		want    []int
+	}{
+		{
+			name:    "owner is an email",
+			rule:    RuleOwnerEmail,
+			content: "name: a\nowner: a@example.com\n",
+		},
+		{
+			name:    "owner is not an email",
+			rule:    RuleOwnerEmail,
+			content: "name: a\nowner: team-a\n",
+			want:    []int{2},
+		},
+		{
+			name:    "owner outside the team domain",
+			rule:    RuleOwnerEmail,
+			cfg:     &Config{Rules: map[string]RuleConfig{RuleOwnerEmail: {Pattern: `@example\.com$`}}},
+			content: "name: a\nowner: a@other.com\n",
+			want:    []int{2},
+		},
+		{
+			name:    "dataset without allowed datasets",
+			rule:    RuleBQ2BQDataset,
+			content: "name: a\ntask:\n  name: bq2bq\n  config:\n    PROJECT: p\n    DATASET: scratch\n",
+		},
+		{
+			name:    "allowed project and dataset",
+			rule:    RuleBQ2BQDataset,
+			cfg:     &Config{Rules: map[string]RuleConfig{RuleBQ2BQDataset: {Allowed: []string{"p.mart"}}}},
+			content: "name: a\ntask:\n  name: bq2bq\n  config:\n    PROJECT: p\n    DATASET: mart\n",
+		},
+		{
+			name:    "dataset not allowed",
+			rule:    RuleBQ2BQDataset,
+			cfg:     &Config{Rules: map[string]RuleConfig{RuleBQ2BQDataset: {Allowed: []string{"mart"}}}},
+			content: "name: a\ntask:\n  name: bq2bq\n  config:\n    PROJECT: p\n    DATASET: scratch\n",
+			want:    []int{6},
+		},
+		{
+			name:    "dataset of another task",
+			rule:    RuleBQ2BQDataset,
+			cfg:     &Config{Rules: map[string]RuleConfig{RuleBQ2BQDataset: {Allowed: []string{"mart"}}}},
+			content: "name: a\ntask:\n  name: python\n  config:\n    DATASET: scratch\n",
+		},
+		{
+			name:    "hourly job with a monthly window",
+			rule:    RuleHourlyMonthlyWindow,
+			content: "name: a\nschedule:\n  interval: 5 * * * *\ntask:\n  name: bq2bq\n  window:\n    size: 720h\n    truncate_to: M\n",
+			want:    []int{6},
+		},
+		{
+			name:    "hourly job with a daily window",
+			rule:    RuleHourlyMonthlyWindow,
+			content: "name: a\nschedule:\n  interval: 5 * * * *\ntask:\n  name: bq2bq\n  window:\n    size: 24h\n    truncate_to: d\n",
+		},
+		{
+			name:    "daily job with a monthly window",
+			rule:    RuleHourlyMonthlyWindow,
+			content: "name: a\nschedule:\n  interval: 5 2 * * *\ntask:\n  name: bq2bq\n  window:\n    size: 720h\n    truncate_to: M\n",
+		},
+		{
+			name:    "schedule at minute 0",
+			rule:    RuleCronMinuteZero,
+			content: "name: a\nschedule:\n  interval: 0 2 * * *\n",
+			want:    []int{3},
+		},
+		{
+			name:    "descriptor at minute 0 with a timezone",
+			rule:    RuleCronMinuteZero,
+			content: "name: a\nschedule:\n  interval: CRON_TZ=Asia/Jakarta @daily\n",
+			want:    []int{3},
+		},
+		{
+			name:    "schedule at another minute",
+			rule:    RuleCronMinuteZero,
+			content: "name: a\nschedule:\n  interval: 10 2 * * *\n",
+		},
+		{
+			name:    "disabled rule",
+			rule:    RuleCronMinuteZero,
+			cfg:     &Config{Rules: map[string]RuleConfig{RuleCronMinuteZero: {Disabled: true}}},
+			content: "name: a\nschedule:\n  interval: 0 2 * * *\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lintLines(t, tt.cfg, tt.rule, tt.content); !equalLines(got, tt.want) {
+				t.Errorf("%s reported lines %v, want %v", tt.rule, got, tt.want)
+			}
+		})
+	}
+}
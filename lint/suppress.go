@@ -0,0 +1,69 @@
+package lint
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Findings are suppressed by comments in job.yaml. A comment on the line of a
+// finding or alone on the line above it suppresses the listed rules there:
+//
+//	owner: someone # mirage:ignore owner-email
+//
+// and a comment anywhere in the file suppresses them for the whole file:
+//
+//	# mirage:ignore-file cron-minute-zero, hourly-monthly-window
+var suppressPattern = regexp.MustCompile(`#\s*mirage:(ignore|ignore-file)\s+([\w\-, ]+)`)
+
+type suppressions struct {
+	// lines holds the suppressed rules by line number
+	lines map[int]map[string]bool
+	file  map[string]bool
+}
+
+func loadSuppressions(path string) (*suppressions, error) {
+	s := &suppressions{lines: map[int]map[string]bool{}, file: map[string]bool{}}
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		m := suppressPattern.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		rules := strings.FieldsFunc(m[2], func(r rune) bool { return r == ',' || r == ' ' })
+		if m[1] == "ignore-file" {
+			for _, r := range rules {
+				s.file[r] = true
+			}
+			continue
+		}
+
+		target := line
+		if strings.HasPrefix(strings.TrimSpace(text), "#") {
+			// a comment on its own line applies to the next line
+			target = line + 1
+		}
+		if s.lines[target] == nil {
+			s.lines[target] = map[string]bool{}
+		}
+		for _, r := range rules {
+			s.lines[target][r] = true
+		}
+	}
+	return s, scanner.Err()
+}
+
+func (s *suppressions) covers(rule string, line int) bool {
+	return s.file[rule] || s.file["all"] || s.lines[line][rule] || s.lines[line]["all"]
+}
@@ -0,0 +1,58 @@
+package lint
+
+import "testing"
+
+func TestSuppressions(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []int
+	}{
+		{
+			name:    "not suppressed",
+			content: "name: a\nowner: team-a\n",
+			want:    []int{2},
+		},
+		{
+			name:    "same line",
+			content: "name: a\nowner: team-a # mirage:ignore owner-email\n",
+		},
+		{
+			name:    "line above",
+			content: "name: a\n# mirage:ignore owner-email\nowner: team-a\n",
+		},
+		{
+			name:    "line above only covers the next line",
+			content: "# mirage:ignore owner-email\nname: a\nowner: team-a\n",
+			want:    []int{3},
+		},
+		{
+			name:    "other rule",
+			content: "name: a\nowner: team-a # mirage:ignore cron-minute-zero\n",
+			want:    []int{2},
+		},
+		{
+			name:    "listed with other rules",
+			content: "name: a\nowner: team-a # mirage:ignore cron-minute-zero, owner-email\n",
+		},
+		{
+			name:    "all rules on the line",
+			content: "name: a\nowner: team-a # mirage:ignore all\n",
+		},
+		{
+			name:    "whole file",
+			content: "name: a\nowner: team-a\n# mirage:ignore-file owner-email\n",
+		},
+		{
+			name:    "all rules in the file",
+			content: "# mirage:ignore-file all\nname: a\nowner: team-a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lintLines(t, nil, RuleOwnerEmail, tt.content); !equalLines(got, tt.want) {
+				t.Errorf("%s reported lines %v, want %v", RuleOwnerEmail, got, tt.want)
+			}
+		})
+	}
+}
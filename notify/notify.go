@@ -0,0 +1,291 @@
+// Package notify detects failed runs and missed SLAs of watched jobs and
+// runs the command configured to be told about them.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/sla"
+)
+
+const (
+	EventFailure = "failure"
+	EventSLAMiss = "sla_miss"
+
+	// maxScheduleLookback bounds the search for the previous scheduled time
+	maxScheduleLookback = 400 * 24 * time.Hour
+)
+
+// Event is a failed run or a missed SLA of a watched job
+type Event struct {
+	Kind      string
+	Project   string
+	Namespace string
+	Spec      optimus.JobSpec
+
+	// Run is nil when the run missing its SLA was not created yet
+	Run         *optimus.JobRun
+	ScheduledAt time.Time
+
+	SLA      time.Duration
+	Deadline time.Time
+}
+
+// Message describes the event in a single line
+func (e Event) Message() string {
+	if e.Kind == EventFailure {
+		msg := e.Spec.Name + " failed for " + e.ScheduledAt.Local().Format("2006-01-02 15:04")
+		if e.Run != nil && e.Run.Attempt > 1 {
+			msg += fmt.Sprintf(" after %d attempts", e.Run.Attempt)
+		}
+		return msg
+	}
+	return fmt.Sprintf("%s missed its SLA of %s for %s", e.Spec.Name, formatDuration(e.SLA), e.ScheduledAt.Local().Format("2006-01-02 15:04"))
+}
+
+// formatDuration drops the zero units time.Duration prints, 2h0m0s becomes 2h
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// Env returns the details of the event as environment variables for the command
+func (e Event) Env(host string) []string {
+	env := []string{
+		"MIRAGE_EVENT=" + e.Kind,
+		"MIRAGE_MESSAGE=" + e.Message(),
+		"MIRAGE_HOST=" + host,
+		"MIRAGE_PROJECT=" + e.Project,
+		"MIRAGE_NAMESPACE=" + e.Namespace,
+		"MIRAGE_JOB=" + e.Spec.Name,
+		"MIRAGE_OWNER=" + e.Spec.Owner,
+		"MIRAGE_SCHEDULED_AT=" + e.ScheduledAt.UTC().Format(time.RFC3339),
+	}
+	if e.Run != nil {
+		env = append(env,
+			"MIRAGE_RUN_STATE="+e.Run.State,
+			"MIRAGE_ATTEMPT="+strconv.Itoa(e.Run.Attempt),
+		)
+	}
+	if e.SLA > 0 {
+		env = append(env,
+			"MIRAGE_SLA="+formatDuration(e.SLA),
+			"MIRAGE_DEADLINE="+e.Deadline.UTC().Format(time.RFC3339),
+		)
+	}
+	return env
+}
+
+// key identifies the event, so every failure and miss is notified once
+func (e Event) key() string {
+	k := strings.Join([]string{e.Kind, e.Project, e.Namespace, e.Spec.Name, e.ScheduledAt.UTC().Format(time.RFC3339)}, "/")
+	if e.Run != nil && e.Kind == EventFailure {
+		k += "/" + strconv.Itoa(e.Run.Attempt)
+	}
+	return k
+}
+
+// Notifier finds the events of watched jobs in the states fetched by a watching view
+type Notifier struct {
+	cfg  config.NotifyConfig
+	host string
+
+	seen map[string]bool
+	// baselined holds the namespaces checked before, events found in the
+	// first check of a namespace happened before watching and are skipped
+	baselined map[string]bool
+}
+
+func New(cfg config.NotifyConfig, host string) *Notifier {
+	return &Notifier{
+		cfg:       cfg,
+		host:      host,
+		seen:      map[string]bool{},
+		baselined: map[string]bool{},
+	}
+}
+
+// Enabled reports if any job is watched
+func (n *Notifier) Enabled() bool {
+	return len(n.cfg.Jobs) > 0 || len(n.cfg.Filters) > 0
+}
+
+// Bell reports if the terminal bell should be rung for events
+func (n *Notifier) Bell() bool {
+	return n.cfg.RingBell()
+}
+
+// Watches reports if the job is in the list or matches a filter of the config
+func (n *Notifier) Watches(spec optimus.JobSpec) bool {
+	for _, name := range n.cfg.Jobs {
+		if name == spec.Name {
+			return true
+		}
+	}
+	for _, f := range n.cfg.Filters {
+		if matches(f, spec) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(f config.JobFilter, spec optimus.JobSpec) bool {
+	return !f.Empty() && f.Match(spec.Name, spec.Owner, spec.Labels)
+}
+
+// SLA returns the SLA of the job, from its spec or the default of the config
+func (n *Notifier) SLA(spec optimus.JobSpec) time.Duration {
+	if d, ok := sla.FromSpec(spec); ok {
+		return d
+	}
+	return n.cfg.SLA
+}
+
+// Check returns the events of watched jobs which were not returned before
+func (n *Notifier) Check(project, namespace string, states []optimus.JobState, now time.Time) []Event {
+	var events []Event
+	for _, state := range states {
+		if !n.Watches(state.Spec) {
+			continue
+		}
+		if e, ok := failure(project, namespace, state); ok {
+			events = append(events, e)
+		}
+		if e, ok := n.slaMiss(project, namespace, state, now); ok {
+			events = append(events, e)
+		}
+	}
+
+	baselined := n.baselined[project+"/"+namespace]
+	n.baselined[project+"/"+namespace] = true
+
+	var fresh []Event
+	for _, e := range events {
+		if n.seen[e.key()] {
+			continue
+		}
+		n.seen[e.key()] = true
+		if baselined {
+			fresh = append(fresh, e)
+		}
+	}
+	return fresh
+}
+
+func failure(project, namespace string, state optimus.JobState) (Event, bool) {
+	run := state.LastRun
+	if run == nil || run.State != optimus.RunStateFailed {
+		return Event{}, false
+	}
+	return Event{
+		Kind:        EventFailure,
+		Project:     project,
+		Namespace:   namespace,
+		Spec:        state.Spec,
+		Run:         run,
+		ScheduledAt: run.ScheduledAt,
+	}, true
+}
+
+// slaMiss checks the latest run whose deadline passed, it misses the SLA when
+// it was not created, has not succeeded or succeeded after the deadline
+func (n *Notifier) slaMiss(project, namespace string, state optimus.JobState, now time.Time) (Event, bool) {
+	d := n.SLA(state.Spec)
+	if d <= 0 {
+		return Event{}, false
+	}
+	scheduledAt, ok := previousSchedule(state.Spec, now.Add(-d))
+	if !ok {
+		return Event{}, false
+	}
+
+	e := Event{
+		Kind:        EventSLAMiss,
+		Project:     project,
+		Namespace:   namespace,
+		Spec:        state.Spec,
+		ScheduledAt: scheduledAt,
+		SLA:         d,
+		Deadline:    scheduledAt.Add(d),
+	}
+	run := state.LastRun
+	switch {
+	case run == nil || run.ScheduledAt.Before(scheduledAt):
+		return e, true
+	case run.ScheduledAt.After(scheduledAt):
+		// only the latest run is known, the one at scheduledAt is not
+		return Event{}, false
+	}
+	e.Run = run
+	if run.State == optimus.RunStateSuccess && !run.EndTime.After(e.Deadline) {
+		return Event{}, false
+	}
+	return e, true
+}
+
+// previousSchedule returns the latest scheduled time of the job at or before t,
+// schedules are evaluated in UTC like optimus does
+func previousSchedule(spec optimus.JobSpec, t time.Time) (time.Time, bool) {
+	t = t.UTC()
+	schedule, err := cron.ParseStandard(spec.Interval)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if start, err := time.Parse("2006-01-02", spec.StartDate); err == nil && t.Before(start) {
+		return time.Time{}, false
+	}
+
+	for lookback := time.Hour; ; lookback *= 2 {
+		// the last attempt looks back the whole bound, doubling would stop short of it
+		if lookback > maxScheduleLookback {
+			lookback = maxScheduleLookback
+		}
+		next := schedule.Next(t.Add(-lookback))
+		if next.IsZero() || next.After(t) {
+			if lookback == maxScheduleLookback {
+				return time.Time{}, false
+			}
+			continue
+		}
+		for {
+			after := schedule.Next(next)
+			if after.IsZero() || after.After(t) {
+				return next, true
+			}
+			next = after
+		}
+	}
+}
+
+// Exec runs the configured command for the event, it is a no-op without one
+func (n *Notifier) Exec(ctx context.Context, e Event) error {
+	if n.cfg.Command == "" {
+		return nil
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.cfg.Command)
+	cmd.Env = append(os.Environ(), e.Env(n.host)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("notify command failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("notify command failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sbchaos/mirage/config"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+func daily(name string) optimus.JobSpec {
+	return optimus.JobSpec{Name: name, Owner: "data@example.com", Interval: "0 2 * * *", StartDate: "2022-01-01"}
+}
+
+func withSLA(spec optimus.JobSpec, duration string) optimus.JobSpec {
+	spec.Behavior = &optimus.JobBehavior{Notify: []optimus.JobNotifier{
+		{On: "sla_miss", Config: map[string]string{"duration": duration}},
+	}}
+	return spec
+}
+
+func runAt(state string, scheduledAt time.Time, attempt int) *optimus.JobRun {
+	return &optimus.JobRun{
+		State:       state,
+		ScheduledAt: scheduledAt,
+		StartTime:   scheduledAt.Add(time.Minute),
+		EndTime:     scheduledAt.Add(30 * time.Minute),
+		Attempt:     attempt,
+	}
+}
+
+func TestPreviousSchedule(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   optimus.JobSpec
+		t      time.Time
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "later the same day",
+			spec:   daily("a"),
+			t:      time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC),
+			want:   time.Date(2022, 8, 1, 2, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "at the scheduled time",
+			spec:   daily("a"),
+			t:      time.Date(2022, 8, 1, 2, 0, 0, 0, time.UTC),
+			want:   time.Date(2022, 8, 1, 2, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "before the scheduled time",
+			spec:   daily("a"),
+			t:      time.Date(2022, 8, 1, 1, 59, 0, 0, time.UTC),
+			want:   time.Date(2022, 7, 31, 2, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "evaluated in UTC",
+			spec:   daily("a"),
+			t:      time.Date(2022, 8, 1, 4, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60)),
+			want:   time.Date(2022, 7, 31, 2, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "monthly beyond the first lookback",
+			spec:   optimus.JobSpec{Name: "m", Interval: "0 0 1 * *"},
+			t:      time.Date(2022, 8, 20, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "yearly",
+			spec:   optimus.JobSpec{Name: "y", Interval: "0 0 1 1 *"},
+			t:      time.Date(2022, 12, 31, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name: "before the start date",
+			spec: daily("a"),
+			t:    time.Date(2021, 12, 31, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "never scheduled",
+			spec: optimus.JobSpec{Name: "n", Interval: "0 0 30 2 *"},
+			t:    time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "invalid interval",
+			spec: optimus.JobSpec{Name: "i", Interval: "every day"},
+			t:    time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := previousSchedule(tt.spec, tt.t)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("previousSchedule(%s) = %s, %v, want %s, %v", tt.t, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestSLAMiss(t *testing.T) {
+	now := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	// the deadline of the run at 02:00 with a 3h SLA passed at 05:00
+	scheduledAt := time.Date(2022, 8, 1, 2, 0, 0, 0, time.UTC)
+	spec := withSLA(daily("a"), "3h")
+
+	late := runAt(optimus.RunStateSuccess, scheduledAt, 1)
+	late.EndTime = scheduledAt.Add(4 * time.Hour)
+
+	tests := []struct {
+		name     string
+		cfg      config.NotifyConfig
+		state    optimus.JobState
+		wantMiss bool
+		wantRun  bool
+	}{
+		{
+			name:  "succeeded in time",
+			state: optimus.JobState{Spec: spec, LastRun: runAt(optimus.RunStateSuccess, scheduledAt, 1)},
+		},
+		{
+			name:     "succeeded after the deadline",
+			state:    optimus.JobState{Spec: spec, LastRun: late},
+			wantMiss: true,
+			wantRun:  true,
+		},
+		{
+			name:     "still running",
+			state:    optimus.JobState{Spec: spec, LastRun: runAt(optimus.RunStateRunning, scheduledAt, 1)},
+			wantMiss: true,
+			wantRun:  true,
+		},
+		{
+			name:     "run not created",
+			state:    optimus.JobState{Spec: spec, LastRun: runAt(optimus.RunStateSuccess, scheduledAt.AddDate(0, 0, -1), 1)},
+			wantMiss: true,
+		},
+		{
+			name:     "no run at all",
+			state:    optimus.JobState{Spec: spec},
+			wantMiss: true,
+		},
+		{
+			name:  "only a later run is known",
+			state: optimus.JobState{Spec: spec, LastRun: runAt(optimus.RunStateRunning, scheduledAt.Add(time.Hour), 1)},
+		},
+		{
+			name:     "default SLA of the config",
+			cfg:      config.NotifyConfig{SLA: 3 * time.Hour},
+			state:    optimus.JobState{Spec: daily("a"), LastRun: runAt(optimus.RunStateFailed, scheduledAt, 1)},
+			wantMiss: true,
+			wantRun:  true,
+		},
+		{
+			name:  "without SLA",
+			state: optimus.JobState{Spec: daily("a")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New(tt.cfg, "")
+			e, miss := n.slaMiss("sample", "analytics", tt.state, now)
+			if miss != tt.wantMiss {
+				t.Fatalf("slaMiss() = %v, want %v", miss, tt.wantMiss)
+			}
+			if !miss {
+				return
+			}
+			if e.Kind != EventSLAMiss || !e.ScheduledAt.Equal(scheduledAt) || !e.Deadline.Equal(scheduledAt.Add(3*time.Hour)) {
+				t.Errorf("event %s at %s with deadline %s, want %s at %s with deadline %s",
+					e.Kind, e.ScheduledAt, e.Deadline, EventSLAMiss, scheduledAt, scheduledAt.Add(3*time.Hour))
+			}
+			if (e.Run != nil) != tt.wantRun {
+				t.Errorf("event has run %v, want %v", e.Run != nil, tt.wantRun)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	scheduledAt := time.Date(2022, 8, 1, 2, 0, 0, 0, time.UTC)
+	failed := optimus.JobState{Spec: daily("sample.failing"), LastRun: runAt(optimus.RunStateFailed, scheduledAt, 1)}
+	retried := optimus.JobState{Spec: daily("sample.failing"), LastRun: runAt(optimus.RunStateFailed, scheduledAt, 2)}
+	ok := optimus.JobState{Spec: daily("sample.ok"), LastRun: runAt(optimus.RunStateSuccess, scheduledAt, 1)}
+	unwatched := optimus.JobState{Spec: daily("other.failing"), LastRun: runAt(optimus.RunStateFailed, scheduledAt, 1)}
+	missed := optimus.JobState{Spec: withSLA(daily("sample.missed"), "3h")}
+
+	type check struct {
+		namespace string
+		states    []optimus.JobState
+		want      []string
+	}
+	tests := []struct {
+		name   string
+		checks []check
+	}{
+		{
+			name: "first check is the baseline",
+			checks: []check{
+				{namespace: "analytics", states: []optimus.JobState{failed, ok}},
+				{namespace: "analytics", states: []optimus.JobState{failed, ok}},
+			},
+		},
+		{
+			name: "new failure after the baseline",
+			checks: []check{
+				{namespace: "analytics", states: []optimus.JobState{ok}},
+				{namespace: "analytics", states: []optimus.JobState{failed, ok}, want: []string{"failure/sample.failing"}},
+				{namespace: "analytics", states: []optimus.JobState{failed, ok}},
+			},
+		},
+		{
+			name: "another attempt fails again",
+			checks: []check{
+				{namespace: "analytics", states: []optimus.JobState{failed}},
+				{namespace: "analytics", states: []optimus.JobState{retried}, want: []string{"failure/sample.failing"}},
+			},
+		},
+		{
+			name: "baseline per namespace",
+			checks: []check{
+				{namespace: "analytics", states: []optimus.JobState{ok}},
+				{namespace: "ingestion", states: []optimus.JobState{failed}},
+				{namespace: "analytics", states: []optimus.JobState{failed}, want: []string{"failure/sample.failing"}},
+			},
+		},
+		{
+			name: "jobs not watched",
+			checks: []check{
+				{namespace: "analytics", states: nil},
+				{namespace: "analytics", states: []optimus.JobState{unwatched}},
+			},
+		},
+		{
+			name: "missed SLA",
+			checks: []check{
+				{namespace: "analytics", states: nil},
+				{namespace: "analytics", states: []optimus.JobState{missed}, want: []string{"sla_miss/sample.missed"}},
+				{namespace: "analytics", states: []optimus.JobState{missed}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := New(config.NotifyConfig{Filters: []config.JobFilter{{Name: "sample.*"}}}, "")
+			for i, c := range tt.checks {
+				var got []string
+				for _, e := range n.Check("sample", c.namespace, c.states, now) {
+					got = append(got, e.Kind+"/"+e.Spec.Name)
+				}
+				if !equal(got, c.want) {
+					t.Errorf("check %d = %v, want %v", i, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEventKey(t *testing.T) {
+	scheduledAt := time.Date(2022, 8, 1, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		e    Event
+		want string
+	}{
+		{
+			name: "failure with its attempt",
+			e:    Event{Kind: EventFailure, Project: "sample", Namespace: "analytics", Spec: daily("a"), Run: runAt(optimus.RunStateFailed, scheduledAt, 2), ScheduledAt: scheduledAt},
+			want: "failure/sample/analytics/a/2022-08-01T02:00:00Z/2",
+		},
+		{
+			name: "sla miss of any attempt",
+			e:    Event{Kind: EventSLAMiss, Project: "sample", Namespace: "analytics", Spec: daily("a"), Run: runAt(optimus.RunStateRunning, scheduledAt, 2), ScheduledAt: scheduledAt},
+			want: "sla_miss/sample/analytics/a/2022-08-01T02:00:00Z",
+		},
+		{
+			name: "scheduled time in UTC",
+			e:    Event{Kind: EventSLAMiss, Project: "sample", Namespace: "analytics", Spec: daily("a"), ScheduledAt: scheduledAt.In(time.FixedZone("UTC+2", 2*60*60))},
+			want: "sla_miss/sample/analytics/a/2022-08-01T02:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.e.key(); got != tt.want {
+				t.Errorf("key() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,249 @@
+package optimus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	apiPrefix      = "/api/v1beta1"
+	defaultTimeout = 30 * time.Second
+	timeLayout     = time.RFC3339
+)
+
+// Client talks to the REST gateway of an optimus server
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	auth       Authenticator
+
+	// timeout replaces the one of the http client once every option is applied
+	timeout time.Duration
+}
+
+// Authenticator adds credentials to the requests made by the client
+type Authenticator interface {
+	Authenticate(ctx context.Context, req *http.Request) error
+}
+
+type ClientOption func(*Client)
+
+// WithAuth attaches the credentials of auth to every request
+func WithAuth(auth Authenticator) ClientOption {
+	return func(c *Client) {
+		c.auth = auth
+	}
+}
+
+// WithHTTPClient replaces the http client used for requests, nil keeps the default one
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTimeout sets the timeout for every request made by the client, whatever
+// the order of the options. It is set on a copy of the http client so one
+// shared with other code is left untouched.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// NewClient creates a client for the optimus server at host, host can be
+// given with or without the scheme, https is used when it is left out
+func NewClient(host string, opts ...ClientOption) (*Client, error) {
+	if host == "" {
+		return nil, fmt.Errorf("optimus host is not configured")
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	base, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid optimus host %s: %w", host, err)
+	}
+
+	c := &Client{
+		baseURL:    base,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if c.timeout > 0 {
+		hc := *c.httpClient
+		hc.Timeout = c.timeout
+		c.httpClient = &hc
+	}
+	return c, nil
+}
+
+// Host returns the address of the server the client talks to
+func (c *Client) Host() string {
+	return c.baseURL.String()
+}
+
+func (c *Client) ListProjects(ctx context.Context) ([]Project, error) {
+	var resp struct {
+		Projects []Project `json:"projects"`
+	}
+	if err := c.get(ctx, "/project", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Projects, nil
+}
+
+func (c *Client) ListNamespaces(ctx context.Context, project string) ([]Namespace, error) {
+	var resp struct {
+		Namespaces []Namespace `json:"namespaces"`
+	}
+	path := fmt.Sprintf("/project/%s/namespace", url.PathEscape(project))
+	if err := c.get(ctx, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Namespaces, nil
+}
+
+func (c *Client) ListJobs(ctx context.Context, project, namespace string) ([]Job, error) {
+	var resp struct {
+		Jobs []Job `json:"jobs"`
+	}
+	if err := c.get(ctx, namespacePath(project, namespace)+"/job", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Jobs, nil
+}
+
+// ListJobSpecs returns the complete specifications of every job in the namespace
+func (c *Client) ListJobSpecs(ctx context.Context, project, namespace string) ([]JobSpec, error) {
+	var resp struct {
+		Jobs []JobSpec `json:"jobs"`
+	}
+	if err := c.get(ctx, namespacePath(project, namespace)+"/job/specification", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Jobs, nil
+}
+
+func (c *Client) GetJobSpec(ctx context.Context, project, namespace, name string) (*JobSpec, error) {
+	var resp struct {
+		Spec JobSpec `json:"spec"`
+	}
+	path := namespacePath(project, namespace) + "/job/" + url.PathEscape(name)
+	if err := c.get(ctx, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Spec, nil
+}
+
+func (c *Client) ListJobRuns(ctx context.Context, project, job string, filter JobRunFilter) ([]JobRun, error) {
+	query := url.Values{}
+	if !filter.StartDate.IsZero() {
+		query.Set("start_date", filter.StartDate.Format(timeLayout))
+	}
+	if !filter.EndDate.IsZero() {
+		query.Set("end_date", filter.EndDate.Format(timeLayout))
+	}
+	for _, state := range filter.States {
+		query.Add("filter", state)
+	}
+
+	var resp struct {
+		JobRuns []JobRun `json:"job_runs"`
+	}
+	path := fmt.Sprintf("/project/%s/job/%s/run", url.PathEscape(project), url.PathEscape(job))
+	if err := c.get(ctx, path, query, &resp); err != nil {
+		return nil, err
+	}
+	return resp.JobRuns, nil
+}
+
+func (c *Client) ListResources(ctx context.Context, project, namespace, datastore string) ([]Resource, error) {
+	var resp struct {
+		Resources []Resource `json:"resources"`
+	}
+	path := namespacePath(project, namespace) + "/datastore/" + url.PathEscape(datastore) + "/resource"
+	if err := c.get(ctx, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
+
+func namespacePath(project, namespace string) string {
+	return fmt.Sprintf("/project/%s/namespace/%s", url.PathEscape(project), url.PathEscape(namespace))
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+func (c *Client) post(ctx context.Context, path string, body, out interface{}) error {
+	return c.do(ctx, http.MethodPost, path, nil, body, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	endpoint := *c.baseURL
+	endpoint.Path = strings.TrimSuffix(endpoint.Path, "/") + apiPrefix + path
+	endpoint.RawQuery = query.Encode()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		if err := c.auth.Authenticate(ctx, req); err != nil {
+			return err
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return decodeError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response of %s: %w", path, err)
+	}
+	return nil
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	payload, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err := json.Unmarshal(payload, apiErr); err != nil {
+		apiErr.Message = strings.TrimSpace(string(payload))
+	}
+	return apiErr
+}
@@ -0,0 +1,252 @@
+package optimus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/optimus/fake"
+)
+
+func newServer(t *testing.T) *fake.Server {
+	t.Helper()
+	srv := fake.NewServer()
+	srv.AddProject(optimus.Project{Name: "sample"})
+	srv.AddNamespace("sample", optimus.Namespace{Name: "ingestion"})
+	srv.AddNamespace("sample", optimus.Namespace{Name: "analytics"})
+	srv.AddJob("sample", "ingestion", optimus.JobSpec{Name: "orders", Interval: "0 2 * * *"})
+	srv.AddJob("sample", "analytics", optimus.JobSpec{
+		Name:         "revenue",
+		Interval:     "0 3 * * *",
+		Dependencies: []optimus.JobDependency{{Name: "orders"}},
+	})
+	srv.AddJob("sample", "analytics", optimus.JobSpec{
+		Name:         "report",
+		Interval:     "0 4 * * *",
+		Dependencies: []optimus.JobDependency{{Name: "sample/revenue"}, {Name: "other/users"}},
+	})
+	return srv
+}
+
+func newClient(t *testing.T, srv *fake.Server) *optimus.Client {
+	t.Helper()
+	host := srv.Start()
+	t.Cleanup(srv.Close)
+	client, err := optimus.NewClient(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestListNamespaces(t *testing.T) {
+	client := newClient(t, newServer(t))
+
+	namespaces, err := client.ListNamespaces(context.Background(), "sample")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, ns := range namespaces {
+		names = append(names, ns.Name)
+	}
+	if len(names) != 2 || names[0] != "analytics" || names[1] != "ingestion" {
+		t.Errorf("namespaces are %v, want [analytics ingestion]", names)
+	}
+}
+
+type tokenAuth string
+
+func (a tokenAuth) Authenticate(_ context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(a))
+	return nil
+}
+
+func TestErrors(t *testing.T) {
+	srv := newServer(t)
+	client := newClient(t, srv)
+
+	// the fake accepts every request, the gateway in front of optimus is
+	// the one rejecting requests without a valid token
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, `{"code": 16, "message": "invalid token"}`, http.StatusUnauthorized)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	}))
+	t.Cleanup(gateway.Close)
+	unauthorized, err := optimus.NewClient(gateway.URL, optimus.WithAuth(tokenAuth("expired")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		call    func(ctx context.Context) error
+		want    error
+		message string
+	}{
+		{
+			name: "unknown project",
+			call: func(ctx context.Context) error {
+				_, err := client.ListNamespaces(ctx, "missing")
+				return err
+			},
+			want:    optimus.ErrNotFound,
+			message: "project missing not found",
+		},
+		{
+			name: "unknown job",
+			call: func(ctx context.Context) error {
+				_, err := client.GetJobSpec(ctx, "sample", "ingestion", "missing")
+				return err
+			},
+			want:    optimus.ErrNotFound,
+			message: "job missing not found",
+		},
+		{
+			name: "invalid replay dates",
+			call: func(ctx context.Context) error {
+				_, err := client.Replay(ctx, "sample", "ingestion", optimus.ReplayRequest{JobName: "orders", StartDate: "yesterday", EndDate: "2022-01-02"})
+				return err
+			},
+			want: optimus.ErrInvalid,
+		},
+		{
+			name: "expired token",
+			call: func(ctx context.Context) error {
+				_, err := unauthorized.ListProjects(ctx)
+				return err
+			},
+			want:    optimus.ErrUnauthorized,
+			message: "invalid token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(context.Background())
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+			var apiErr *optimus.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got error %T, want *optimus.APIError", err)
+			}
+			if tt.message != "" && apiErr.Message != tt.message {
+				t.Errorf("got message %q, want %q", apiErr.Message, tt.message)
+			}
+		})
+	}
+
+	t.Run("server failing", func(t *testing.T) {
+		srv.SetFaults(fake.Faults{ErrorRate: 1})
+		defer srv.SetFaults(fake.Faults{})
+
+		_, err := client.ListProjects(context.Background())
+		if !errors.Is(err, optimus.ErrUnavailable) {
+			t.Errorf("got error %v, want %v", err, optimus.ErrUnavailable)
+		}
+		if errors.Is(err, optimus.ErrNotFound) {
+			t.Errorf("error %v matches %v", err, optimus.ErrNotFound)
+		}
+	})
+}
+
+func TestReplay(t *testing.T) {
+	srv := newServer(t)
+	srv.SetReplayStep(time.Hour)
+	client := newClient(t, srv)
+	ctx := context.Background()
+
+	id, err := client.Replay(ctx, "sample", "ingestion", optimus.ReplayRequest{JobName: "orders", StartDate: "2022-01-01", EndDate: "2022-01-02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := client.GetReplayStatus(ctx, "sample", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != optimus.ReplayStateInProgress {
+		t.Errorf("state is %s, want %s", status.State, optimus.ReplayStateInProgress)
+	}
+
+	// every job runs on both days, dependents are replayed across
+	// namespaces and dependencies on other projects are left out
+	var walk func(node *optimus.ReplayNode)
+	var got []string
+	walk = func(node *optimus.ReplayNode) {
+		got = append(got, node.JobName)
+		if len(node.Runs) != 2 {
+			t.Errorf("%s has %d runs, want 2", node.JobName, len(node.Runs))
+		}
+		for _, d := range node.Dependents {
+			walk(d)
+		}
+	}
+	walk(status.Tree)
+	if len(got) != 3 || got[0] != "orders" || got[1] != "revenue" || got[2] != "report" {
+		t.Errorf("replayed %v, want [orders revenue report]", got)
+	}
+	if first := status.Tree.Runs[0].Run; !first.Equal(time.Date(2022, 1, 1, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("first run at %s, want 2022-01-01T02:00:00Z", first)
+	}
+}
+
+func TestWithTimeoutKeepsSharedClient(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+	if _, err := optimus.NewClient("localhost:9100", optimus.WithHTTPClient(shared), optimus.WithTimeout(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if shared.Timeout != time.Minute {
+		t.Errorf("timeout of the shared client is %s, want 1m0s", shared.Timeout)
+	}
+}
+
+func TestWithTimeoutInAnyOrder(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+	tests := []struct {
+		name string
+		opts []optimus.ClientOption
+	}{
+		{"timeout first", []optimus.ClientOption{optimus.WithTimeout(time.Second), optimus.WithHTTPClient(shared)}},
+		{"nil http client", []optimus.ClientOption{optimus.WithHTTPClient(nil), optimus.WithTimeout(time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := optimus.NewClient("localhost:9100", tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if shared.Timeout != time.Minute {
+				t.Errorf("timeout of the shared client is %s, want 1m0s", shared.Timeout)
+			}
+		})
+	}
+}
+
+func TestNewClientScheme(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"optimus.example.com", "https://optimus.example.com"},
+		{"localhost:9100", "https://localhost:9100"},
+		{"http://localhost:9100", "http://localhost:9100"},
+		{"https://optimus.example.com/base", "https://optimus.example.com/base"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			client, err := optimus.NewClient(tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := client.Host(); got != tt.want {
+				t.Errorf("Host() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
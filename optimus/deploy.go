@@ -0,0 +1,25 @@
+package optimus
+
+import (
+	"context"
+	"fmt"
+)
+
+// DeployJobSpec creates the job in the namespace or replaces its spec
+func (c *Client) DeployJobSpec(ctx context.Context, project, namespace string, spec JobSpec) error {
+	req := struct {
+		Spec JobSpec `json:"spec"`
+	}{Spec: spec}
+
+	var resp struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if err := c.post(ctx, namespacePath(project, namespace)+"/job", req, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("optimus rejected %s: %s", spec.Name, resp.Message)
+	}
+	return nil
+}
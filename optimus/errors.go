@@ -0,0 +1,43 @@
+package optimus
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrInvalid      = errors.New("invalid request")
+	ErrUnavailable  = errors.New("server unavailable")
+)
+
+// APIError is returned when optimus responds with a non 2xx status
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("optimus: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("optimus: %s: %s", http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is allows matching an APIError against the sentinel errors of the package
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrInvalid:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
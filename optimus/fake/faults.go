@@ -0,0 +1,116 @@
+package fake
+
+import (
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// controlPrefix is the path of the endpoints changing the behaviour of the server
+const controlPrefix = "/_dev"
+
+// Faults are failures injected into every api request
+type Faults struct {
+	// Latency delays every response
+	Latency time.Duration `json:"latency"`
+
+	// ErrorRate is the share of requests, between 0 and 1, answered with
+	// service unavailable
+	ErrorRate float64 `json:"error_rate"`
+
+	// PathFilter limits the faults to requests whose path contains it
+	PathFilter string `json:"path_filter,omitempty"`
+}
+
+// faultsJSON is the wire form of Faults, with the latency as a duration string
+type faultsJSON struct {
+	Latency    string  `json:"latency"`
+	ErrorRate  float64 `json:"error_rate"`
+	PathFilter string  `json:"path_filter,omitempty"`
+}
+
+// SetFaults replaces the failures injected into api requests
+func (s *Server) SetFaults(f Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = f
+}
+
+// applyFaults delays the request and reports false when it should fail
+func (s *Server) applyFaults(r *http.Request) bool {
+	s.mu.RLock()
+	f := s.faults
+	s.mu.RUnlock()
+
+	if f.PathFilter != "" && !strings.Contains(r.URL.Path, f.PathFilter) {
+		return true
+	}
+	if f.Latency > 0 {
+		time.Sleep(f.Latency)
+	}
+	return f.ErrorRate <= 0 || rand.Float64() >= f.ErrorRate
+}
+
+// serveControl handles the endpoints below controlPrefix:
+//
+//	GET  /_dev/faults                 current faults
+//	POST /_dev/faults                 replace the faults
+//	POST /_dev/fail/deploy/{job}      fail deployments of the job with the body as message
+//	POST /_dev/fail/replay/{job}      fail replayed runs of the job with the body as message
+//	POST /_dev/reset                  remove all injected failures
+func (s *Server) serveControl(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, controlPrefix), "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "faults" && r.Method == http.MethodGet:
+		s.mu.RLock()
+		f := s.faults
+		s.mu.RUnlock()
+		writeJSON(w, faultsJSON{Latency: f.Latency.String(), ErrorRate: f.ErrorRate, PathFilter: f.PathFilter})
+	case len(parts) == 1 && parts[0] == "faults" && r.Method == http.MethodPost:
+		var req faultsJSON
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid faults: "+err.Error())
+			return
+		}
+		f := Faults{ErrorRate: req.ErrorRate, PathFilter: req.PathFilter}
+		if req.Latency != "" {
+			latency, err := time.ParseDuration(req.Latency)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid latency: "+err.Error())
+				return
+			}
+			f.Latency = latency
+		}
+		s.SetFaults(f)
+		writeJSON(w, req)
+	case len(parts) == 3 && parts[0] == "fail" && r.Method == http.MethodPost:
+		body, _ := io.ReadAll(io.LimitReader(r.Body, 4096))
+		msg := strings.TrimSpace(string(body))
+		if msg == "" {
+			msg = "injected failure"
+		}
+		switch parts[1] {
+		case "deploy":
+			s.FailDeploy(parts[2], msg)
+		case "replay":
+			s.FailReplayRuns(parts[2], msg)
+		default:
+			writeError(w, http.StatusNotFound, "unknown failure "+parts[1])
+			return
+		}
+		writeJSON(w, map[string]string{"job": parts[2], "message": msg})
+	case len(parts) == 1 && parts[0] == "reset" && r.Method == http.MethodPost:
+		s.mu.Lock()
+		s.faults = Faults{}
+		s.deployFailures = map[string]string{}
+		s.replayFailures = map[string]string{}
+		s.mu.Unlock()
+		writeJSON(w, map[string]string{})
+	default:
+		writeError(w, http.StatusNotFound, "unknown path "+r.URL.Path)
+	}
+}
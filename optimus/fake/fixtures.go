@@ -0,0 +1,128 @@
+package fake
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+)
+
+const (
+	namespacesDir = "namespaces"
+	runsDir       = "runs"
+)
+
+// runFixture is a run in runs/<job>.yaml of a project
+type runFixture struct {
+	ScheduledAt time.Time `yaml:"scheduled_at"`
+	State       string    `yaml:"state"`
+	StartTime   time.Time `yaml:"start_time"`
+	EndTime     time.Time `yaml:"end_time"`
+	Attempt     int       `yaml:"attempt"`
+	Type        string    `yaml:"type"`
+	Logs        []struct {
+		Source     string `yaml:"source"`
+		SourceType string `yaml:"source_type"`
+		Message    string `yaml:"message"`
+	} `yaml:"logs"`
+}
+
+// LoadFixtures seeds the server from a directory laid out as
+//
+//	<project>/namespaces/<namespace>/<job>/job.yaml
+//	<project>/runs/<job>.yaml
+//
+// job directories follow the layout of an optimus repository, assets included
+func (s *Server) LoadFixtures(dir string) error {
+	projects, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		if !p.IsDir() {
+			continue
+		}
+		projectDir := filepath.Join(dir, p.Name())
+		s.AddProject(optimus.Project{Name: p.Name()})
+
+		namespaces, err := os.ReadDir(filepath.Join(projectDir, namespacesDir))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		for _, ns := range namespaces {
+			if !ns.IsDir() {
+				continue
+			}
+			s.AddNamespace(p.Name(), optimus.Namespace{Name: ns.Name()})
+			files, errs := spec.Load(filepath.Join(projectDir, namespacesDir, ns.Name()), ns.Name())
+			if len(errs) > 0 {
+				return errs[0]
+			}
+			for _, f := range files {
+				s.AddJob(p.Name(), ns.Name(), f.Optimus())
+			}
+		}
+
+		runFiles, err := filepath.Glob(filepath.Join(projectDir, runsDir, "*.yaml"))
+		if err != nil {
+			return err
+		}
+		for _, path := range runFiles {
+			if err := s.loadRuns(p.Name(), path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) loadRuns(project, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fixture struct {
+		Runs []runFixture `yaml:"runs"`
+	}
+	if err := yaml.Unmarshal(content, &fixture); err != nil {
+		return fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	job := filepath.Base(path[:len(path)-len(filepath.Ext(path))])
+	for _, r := range fixture.Runs {
+		s.AddJobRun(project, job, optimus.JobRun{
+			State:       r.State,
+			ScheduledAt: r.ScheduledAt,
+			StartTime:   r.StartTime,
+			EndTime:     r.EndTime,
+			Attempt:     r.Attempt,
+			Type:        r.Type,
+		})
+
+		at := r.StartTime
+		if at.IsZero() {
+			at = r.ScheduledAt
+		}
+		var lines []optimus.LogLine
+		for i, l := range r.Logs {
+			lines = append(lines, optimus.LogLine{
+				Timestamp:  at.Add(time.Duration(i) * time.Second),
+				Source:     l.Source,
+				SourceType: l.SourceType,
+				Attempt:    r.Attempt,
+				Message:    l.Message,
+			})
+		}
+		if len(lines) > 0 {
+			s.AddJobRunLogs(project, job, r.ScheduledAt, lines...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package fake
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// runningSteps is the number of Advance calls a generated running run takes to finish
+const runningSteps = 10
+
+// GenerateHistory creates scheduled runs for the past days of every job
+// without runs. Outcomes are derived from the job name and schedule so the
+// history is the same on every start, the latest run of some jobs is left running.
+func (s *Server) GenerateHistory(days int, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for projectName, p := range s.projects {
+		for _, ns := range p.namespaces {
+			for _, spec := range ns.jobs {
+				key := projectName + "/" + spec.Name
+				if len(s.runs[key]) > 0 {
+					continue
+				}
+				s.generateRuns(projectName, spec, now.AddDate(0, 0, -days), now)
+			}
+		}
+	}
+}
+
+func (s *Server) generateRuns(projectName string, spec optimus.JobSpec, from, to time.Time) {
+	schedule, err := cron.ParseStandard(spec.Interval)
+	if err != nil {
+		return
+	}
+
+	key := projectName + "/" + spec.Name
+	var last time.Time
+	for at := schedule.Next(from); !at.IsZero() && at.Before(to); at = schedule.Next(at) {
+		last = at
+		run := optimus.JobRun{
+			State:       optimus.RunStateSuccess,
+			ScheduledAt: at.UTC(),
+			StartTime:   at.UTC().Add(time.Minute),
+			Attempt:     1,
+			Type:        optimus.RunTypeScheduled,
+		}
+		roll := seed(spec.Name, at) % 100
+		if roll < 8 {
+			run.State = optimus.RunStateFailed
+			run.Attempt = 2
+		}
+		run.EndTime = run.StartTime.Add(time.Duration(5+roll%40) * time.Minute)
+
+		s.runs[key] = append(s.runs[key], run)
+		s.logs[runKey(projectName, spec.Name, at)] = generateLogs(spec, run)
+	}
+
+	// leave the latest run of about a quarter of the jobs in progress
+	if !last.IsZero() && seed(spec.Name, time.Time{})%4 == 0 {
+		runs := s.runs[key]
+		latest := &runs[len(runs)-1]
+		latest.State = optimus.RunStateRunning
+		latest.EndTime = time.Time{}
+		s.logs[runKey(projectName, spec.Name, last)] = generateLogs(spec, *latest)[:2]
+	}
+}
+
+func generateLogs(spec optimus.JobSpec, run optimus.JobRun) []optimus.LogLine {
+	line := func(offset time.Duration, source, sourceType, message string) optimus.LogLine {
+		return optimus.LogLine{
+			Timestamp:  run.StartTime.Add(offset),
+			Source:     source,
+			SourceType: sourceType,
+			Attempt:    run.Attempt,
+			Message:    message,
+		}
+	}
+
+	lines := []optimus.LogLine{
+		line(0, spec.TaskName, optimus.LogSourceTask, fmt.Sprintf("starting %s for %s", spec.TaskName, run.ScheduledAt.Format(time.RFC3339))),
+		line(time.Second, spec.TaskName, optimus.LogSourceTask, fmt.Sprintf("window size %s, offset %s, truncate to %s", spec.WindowSize, spec.WindowOffset, spec.WindowTruncateTo)),
+	}
+	if run.State == optimus.RunStateFailed {
+		lines = append(lines,
+			line(2*time.Second, spec.TaskName, optimus.LogSourceTask, "ERROR: query failed: resource exhausted, retrying"),
+			line(3*time.Second, spec.TaskName, optimus.LogSourceTask, "ERROR: attempt 2 failed: resource exhausted"),
+		)
+		return lines
+	}
+
+	lines = append(lines, line(2*time.Second, spec.TaskName, optimus.LogSourceTask, fmt.Sprintf("wrote %d rows", seed(spec.Name, run.ScheduledAt)%100000)))
+	for i, hook := range spec.Hooks {
+		lines = append(lines, line(time.Duration(3+i)*time.Second, hook.Name, optimus.LogSourceHook, "hook "+hook.Name+" finished"))
+	}
+	return lines
+}
+
+// Advance moves every running run one step forward, appending a log line and
+// finishing the run once it took runningSteps steps
+func (s *Server) Advance(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, runs := range s.runs {
+		for i := range runs {
+			run := &runs[i]
+			if run.State != optimus.RunStateRunning {
+				continue
+			}
+
+			project, job := splitKey(key)
+			logKey := runKey(project, job, run.ScheduledAt)
+			step := len(s.logs[logKey])
+			line := optimus.LogLine{Timestamp: now, Source: "task", SourceType: optimus.LogSourceTask, Attempt: run.Attempt}
+			if logs := s.logs[logKey]; len(logs) > 0 {
+				line.Source = logs[0].Source
+			}
+
+			if step >= runningSteps {
+				run.State = optimus.RunStateSuccess
+				run.EndTime = now
+				line.Message = "finished"
+			} else {
+				line.Message = fmt.Sprintf("processed batch %d", step-1)
+			}
+			s.logs[logKey] = append(s.logs[logKey], line)
+		}
+	}
+}
+
+func splitKey(key string) (string, string) {
+	for i := range key {
+		if key[i] == '/' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return "", key
+}
+
+// seed derives a stable number from a job and a time
+func seed(name string, at time.Time) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte(at.UTC().Format(time.RFC3339)))
+	return h.Sum32()
+}
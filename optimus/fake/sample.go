@@ -0,0 +1,137 @@
+package fake
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/spec"
+)
+
+// SampleProject is the project seeded by LoadSample
+const SampleProject = "sample"
+
+type sampleJob struct {
+	namespace string
+	job       spec.Job
+	assets    map[string]string
+}
+
+func sampleJobs() []sampleJob {
+	daily := spec.Window{Size: "24h", Offset: "0", TruncateTo: "d"}
+	hourly := spec.Window{Size: "1h", Offset: "0", TruncateTo: "h"}
+	query := func(table string) map[string]string {
+		return map[string]string{"query.sql": "select * from " + table + "\nwhere event_timestamp >= '{{.DSTART}}'\n  and event_timestamp < '{{.DEND}}'\n"}
+	}
+	bq := func(table string) map[string]string {
+		return map[string]string{"PROJECT": SampleProject, "DATASET": "analytics", "TABLE": table, "LOAD_METHOD": "REPLACE"}
+	}
+
+	return []sampleJob{
+		{
+			namespace: "ingestion",
+			job: spec.Job{
+				Version: 1, Name: "sample.raw_events", Owner: "ingestion@example.com",
+				Description: "Loads raw events from the event stream",
+				Schedule:    spec.Schedule{StartDate: "2022-01-01", Interval: "0 * * * *"},
+				Task:        spec.Task{Name: "bq2bq", Config: bq("raw_events"), Window: hourly},
+			},
+			assets: query("stream.events"),
+		},
+		{
+			namespace: "ingestion",
+			job: spec.Job{
+				Version: 1, Name: "sample.raw_orders", Owner: "ingestion@example.com",
+				Description: "Loads orders from the transactional database",
+				Schedule:    spec.Schedule{StartDate: "2022-01-01", Interval: "30 * * * *"},
+				Behavior:    spec.Behavior{Retry: &spec.Retry{Count: 2, Delay: "5m"}},
+				Task:        spec.Task{Name: "bq2bq", Config: bq("raw_orders"), Window: hourly},
+			},
+			assets: query("orders.orders"),
+		},
+		{
+			namespace: "analytics",
+			job: spec.Job{
+				Version: 1, Name: "sample.daily_sessions", Owner: "analytics@example.com",
+				Description:  "Sessions per user and day",
+				Schedule:     spec.Schedule{StartDate: "2022-01-01", Interval: "0 2 * * *"},
+				Task:         spec.Task{Name: "bq2bq", Config: bq("daily_sessions"), Window: daily},
+				Dependencies: []spec.Dependency{{Job: "sample.raw_events"}},
+			},
+			assets: query("analytics.raw_events"),
+		},
+		{
+			namespace: "analytics",
+			job: spec.Job{
+				Version: 1, Name: "sample.daily_revenue", Owner: "analytics@example.com",
+				Description:  "Revenue per market and day",
+				Schedule:     spec.Schedule{StartDate: "2022-01-01", Interval: "0 3 * * *"},
+				Behavior:     spec.Behavior{Notify: []spec.Notifier{{On: "failure", Channels: []string{"slack://#analytics-alerts"}}}},
+				Task:         spec.Task{Name: "bq2bq", Config: bq("daily_revenue"), Window: daily},
+				Dependencies: []spec.Dependency{{Job: "sample.raw_orders"}},
+				Hooks:        []spec.Hook{{Name: "transporter"}},
+			},
+			assets: query("analytics.raw_orders"),
+		},
+		{
+			namespace: "analytics",
+			job: spec.Job{
+				Version: 1, Name: "sample.daily_report", Owner: "analytics@example.com",
+				Description: "Joins sessions and revenue for the daily report",
+				Schedule:    spec.Schedule{StartDate: "2022-01-01", Interval: "0 4 * * *"},
+				Task:        spec.Task{Name: "bq2bq", Config: bq("daily_report"), Window: daily},
+				Labels:      map[string]string{"team": "analytics"},
+				Dependencies: []spec.Dependency{
+					{Job: "sample.daily_sessions"},
+					{Job: "sample.daily_revenue"},
+				},
+			},
+			assets: query("analytics.daily_revenue"),
+		},
+		{
+			namespace: "analytics",
+			job: spec.Job{
+				Version: 1, Name: "sample.weekly_summary", Owner: "analytics@example.com",
+				Description:  "Weekly rollup of the daily report",
+				Schedule:     spec.Schedule{StartDate: "2022-01-01", Interval: "0 6 * * 1"},
+				Task:         spec.Task{Name: "bq2bq", Config: bq("weekly_summary"), Window: spec.Window{Size: "168h", Offset: "0", TruncateTo: "w"}},
+				Dependencies: []spec.Dependency{{Job: "sample.daily_report"}},
+			},
+			assets: query("analytics.daily_report"),
+		},
+	}
+}
+
+// LoadSample seeds the server with a small project of dependent jobs
+func (s *Server) LoadSample() {
+	s.AddProject(optimus.Project{Name: SampleProject})
+	for _, sj := range sampleJobs() {
+		s.AddNamespace(SampleProject, optimus.Namespace{Name: sj.namespace})
+		s.AddJob(SampleProject, sj.namespace, sj.job.ToOptimus(sj.assets))
+	}
+}
+
+// WriteSample writes the jobs of LoadSample to dir in the layout read by LoadFixtures
+func WriteSample(dir string) error {
+	for _, sj := range sampleJobs() {
+		jobDir := filepath.Join(dir, SampleProject, namespacesDir, sj.namespace, sj.job.Name)
+		if err := os.MkdirAll(filepath.Join(jobDir, "assets"), 0o755); err != nil {
+			return err
+		}
+		content, err := yaml.Marshal(sj.job)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(jobDir, spec.FileName), content, 0o644); err != nil {
+			return err
+		}
+		for name, asset := range sj.assets {
+			if err := os.WriteFile(filepath.Join(jobDir, "assets", name), []byte(asset), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return os.MkdirAll(filepath.Join(dir, SampleProject, runsDir), 0o755)
+}
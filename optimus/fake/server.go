@@ -0,0 +1,663 @@
+// Package fake provides an in-memory optimus server implementing the subset
+// of the REST api used by mirage.
+package fake
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const apiPrefix = "/api/v1beta1"
+
+type namespace struct {
+	spec      optimus.Namespace
+	jobs      map[string]optimus.JobSpec
+	resources map[string][]optimus.Resource
+}
+
+type project struct {
+	spec       optimus.Project
+	namespaces map[string]*namespace
+}
+
+type replayState struct {
+	id        string
+	project   string
+	namespace string
+	request   optimus.ReplayRequest
+	createdAt time.Time
+	tree      *optimus.ReplayNode
+}
+
+// Server keeps the state of projects, jobs and runs in memory
+type Server struct {
+	mu       sync.RWMutex
+	projects map[string]*project
+	runs     map[string][]optimus.JobRun
+	logs     map[string][]optimus.LogLine
+	replays  map[string]*replayState
+
+	// replayStep is the time every replayed run takes in the simulation
+	replayStep     time.Duration
+	replayFailures map[string]string
+
+	deployFailures map[string]string
+	faults         Faults
+
+	httpServer *httptest.Server
+}
+
+func NewServer() *Server {
+	return &Server{
+		projects: map[string]*project{},
+		runs:     map[string][]optimus.JobRun{},
+		logs:     map[string][]optimus.LogLine{},
+		replays:  map[string]*replayState{},
+
+		replayStep:     2 * time.Second,
+		replayFailures: map[string]string{},
+		deployFailures: map[string]string{},
+	}
+}
+
+// Start serves the api on a random local port and returns its address
+func (s *Server) Start() string {
+	s.httpServer = httptest.NewServer(s)
+	return s.httpServer.URL
+}
+
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+func (s *Server) AddProject(p optimus.Project) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addProject(p.Name).spec = p
+}
+
+func (s *Server) AddNamespace(projectName string, ns optimus.Namespace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addNamespace(projectName, ns.Name).spec = ns
+}
+
+func (s *Server) AddJob(projectName, namespaceName string, spec optimus.JobSpec) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addNamespace(projectName, namespaceName).jobs[spec.Name] = spec
+}
+
+func (s *Server) AddJobRun(projectName, jobName string, run optimus.JobRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := projectName + "/" + jobName
+	s.runs[key] = append(s.runs[key], run)
+}
+
+// AddJobRunLogs appends log lines to the run of the job scheduled at scheduledAt,
+// lines added while the run is in progress are picked up by clients following it
+func (s *Server) AddJobRunLogs(projectName, jobName string, scheduledAt time.Time, lines ...optimus.LogLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := runKey(projectName, jobName, scheduledAt)
+	s.logs[key] = append(s.logs[key], lines...)
+}
+
+// SetJobRunState changes the state of a run, finishing a run completes its logs
+func (s *Server) SetJobRunState(projectName, jobName string, scheduledAt time.Time, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := s.runs[projectName+"/"+jobName]
+	for i := range runs {
+		if runs[i].ScheduledAt.Equal(scheduledAt) {
+			runs[i].State = state
+		}
+	}
+}
+
+func runKey(projectName, jobName string, scheduledAt time.Time) string {
+	return projectName + "/" + jobName + "/" + scheduledAt.UTC().Format(time.RFC3339)
+}
+
+func (s *Server) AddResource(projectName, namespaceName string, r optimus.Resource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns := s.addNamespace(projectName, namespaceName)
+	ns.resources[r.Datastore] = append(ns.resources[r.Datastore], r)
+}
+
+// SetReplayStep sets how long every run of a replay takes to complete
+func (s *Server) SetReplayStep(step time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replayStep = step
+}
+
+// FailReplayRuns makes every replayed run of the job fail with message
+func (s *Server) FailReplayRuns(jobName, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replayFailures[jobName] = message
+}
+
+// FailDeploy makes every deployment of the job fail with message
+func (s *Server) FailDeploy(jobName, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deployFailures[jobName] = message
+}
+
+// Namespaces returns the sorted namespace names of every project
+func (s *Server) Namespaces() map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := map[string][]string{}
+	for projectName, p := range s.projects {
+		names[projectName] = []string{}
+		for name := range p.namespaces {
+			names[projectName] = append(names[projectName], name)
+		}
+		sort.Strings(names[projectName])
+	}
+	return names
+}
+
+func (s *Server) addProject(name string) *project {
+	p, ok := s.projects[name]
+	if !ok {
+		p = &project{
+			spec:       optimus.Project{Name: name},
+			namespaces: map[string]*namespace{},
+		}
+		s.projects[name] = p
+	}
+	return p
+}
+
+func (s *Server) addNamespace(projectName, name string) *namespace {
+	p := s.addProject(projectName)
+	ns, ok := p.namespaces[name]
+	if !ok {
+		ns = &namespace{
+			spec:      optimus.Namespace{Name: name},
+			jobs:      map[string]optimus.JobSpec{},
+			resources: map[string][]optimus.Resource{},
+		}
+		p.namespaces[name] = ns
+	}
+	return ns
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, controlPrefix+"/") {
+		s.serveControl(w, r)
+		return
+	}
+	if !s.applyFaults(r) {
+		writeError(w, http.StatusServiceUnavailable, "injected failure")
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, apiPrefix+"/") {
+		writeError(w, http.StatusNotFound, "unknown path "+r.URL.Path)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiPrefix), "/"), "/")
+
+	if r.Method == http.MethodGet {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	} else {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	s.route(w, r, parts)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request, parts []string) {
+	match := func(method string, pattern ...string) bool {
+		if method != r.Method || len(pattern) != len(parts) {
+			return false
+		}
+		for i, p := range pattern {
+			if p != "*" && p != parts[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	get, post := http.MethodGet, http.MethodPost
+	switch {
+	case match(get, "project"):
+		s.listProjects(w)
+	case match(get, "project", "*", "namespace"):
+		s.listNamespaces(w, parts[1])
+	case match(get, "project", "*", "namespace", "*", "job"):
+		s.listJobs(w, parts[1], parts[3])
+	case match(get, "project", "*", "namespace", "*", "job", "specification"):
+		s.listJobSpecs(w, parts[1], parts[3])
+	case match(get, "project", "*", "namespace", "*", "job", "*"):
+		s.getJobSpec(w, parts[1], parts[3], parts[5])
+	case match(get, "project", "*", "job", "*", "run"):
+		s.listJobRuns(w, r, parts[1], parts[3])
+	case match(get, "project", "*", "job", "*", "run", "*", "log"):
+		s.getJobRunLogs(w, r, parts[1], parts[3], parts[5])
+	case match(get, "project", "*", "namespace", "*", "datastore", "*", "resource"):
+		s.listResources(w, parts[1], parts[3], parts[5])
+	case match(post, "project", "*", "namespace", "*", "job"):
+		s.deployJobSpec(w, r, parts[1], parts[3])
+	case match(post, "project", "*", "namespace", "*", "replay"):
+		s.createReplay(w, r, parts[1], parts[3])
+	case match(get, "project", "*", "replay", "*"):
+		s.getReplayStatus(w, parts[1], parts[3])
+	default:
+		writeError(w, http.StatusNotFound, "unknown path "+r.URL.Path)
+	}
+}
+
+func (s *Server) listProjects(w http.ResponseWriter) {
+	projects := []optimus.Project{}
+	for _, p := range s.projects {
+		projects = append(projects, p.spec)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Name < projects[j].Name })
+	writeJSON(w, map[string]interface{}{"projects": projects})
+}
+
+func (s *Server) listNamespaces(w http.ResponseWriter, projectName string) {
+	p, ok := s.projects[projectName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "project "+projectName+" not found")
+		return
+	}
+	namespaces := []optimus.Namespace{}
+	for _, ns := range p.namespaces {
+		namespaces = append(namespaces, ns.spec)
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+	writeJSON(w, map[string]interface{}{"namespaces": namespaces})
+}
+
+func (s *Server) namespace(w http.ResponseWriter, projectName, namespaceName string) (*namespace, bool) {
+	p, ok := s.projects[projectName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "project "+projectName+" not found")
+		return nil, false
+	}
+	ns, ok := p.namespaces[namespaceName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "namespace "+namespaceName+" not found")
+		return nil, false
+	}
+	return ns, true
+}
+
+func (s *Server) sortedSpecs(ns *namespace) []optimus.JobSpec {
+	specs := []optimus.JobSpec{}
+	for _, spec := range ns.jobs {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs
+}
+
+func (s *Server) listJobs(w http.ResponseWriter, projectName, namespaceName string) {
+	ns, ok := s.namespace(w, projectName, namespaceName)
+	if !ok {
+		return
+	}
+	jobs := []optimus.Job{}
+	for _, spec := range s.sortedSpecs(ns) {
+		jobs = append(jobs, optimus.Job{
+			Name:      spec.Name,
+			Namespace: namespaceName,
+			Owner:     spec.Owner,
+			Interval:  spec.Interval,
+			TaskName:  spec.TaskName,
+		})
+	}
+	writeJSON(w, map[string]interface{}{"jobs": jobs})
+}
+
+func (s *Server) listJobSpecs(w http.ResponseWriter, projectName, namespaceName string) {
+	ns, ok := s.namespace(w, projectName, namespaceName)
+	if !ok {
+		return
+	}
+	writeJSON(w, map[string]interface{}{"jobs": s.sortedSpecs(ns)})
+}
+
+func (s *Server) getJobSpec(w http.ResponseWriter, projectName, namespaceName, jobName string) {
+	ns, ok := s.namespace(w, projectName, namespaceName)
+	if !ok {
+		return
+	}
+	spec, ok := ns.jobs[jobName]
+	if !ok {
+		writeError(w, http.StatusNotFound, "job "+jobName+" not found")
+		return
+	}
+	writeJSON(w, map[string]interface{}{"spec": spec})
+}
+
+func (s *Server) listJobRuns(w http.ResponseWriter, r *http.Request, projectName, jobName string) {
+	if _, ok := s.projects[projectName]; !ok {
+		writeError(w, http.StatusNotFound, "project "+projectName+" not found")
+		return
+	}
+
+	query := r.URL.Query()
+	var start, end time.Time
+	var err error
+	if v := query.Get("start_date"); v != "" {
+		if start, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid start_date: "+err.Error())
+			return
+		}
+	}
+	if v := query.Get("end_date"); v != "" {
+		if end, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid end_date: "+err.Error())
+			return
+		}
+	}
+	states := map[string]bool{}
+	for _, state := range query["filter"] {
+		states[state] = true
+	}
+
+	runs := []optimus.JobRun{}
+	for _, run := range s.runs[projectName+"/"+jobName] {
+		if !start.IsZero() && run.ScheduledAt.Before(start) {
+			continue
+		}
+		if !end.IsZero() && run.ScheduledAt.After(end) {
+			continue
+		}
+		if len(states) > 0 && !states[run.State] {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ScheduledAt.After(runs[j].ScheduledAt) })
+	writeJSON(w, map[string]interface{}{"job_runs": runs})
+}
+
+func (s *Server) getJobRunLogs(w http.ResponseWriter, r *http.Request, projectName, jobName, scheduled string) {
+	scheduledAt, err := time.Parse(time.RFC3339, scheduled)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid scheduled_at: "+err.Error())
+		return
+	}
+
+	var run *optimus.JobRun
+	for i, candidate := range s.runs[projectName+"/"+jobName] {
+		if candidate.ScheduledAt.Equal(scheduledAt) {
+			run = &s.runs[projectName+"/"+jobName][i]
+		}
+	}
+	if run == nil {
+		writeError(w, http.StatusNotFound, "run of "+jobName+" at "+scheduled+" not found")
+		return
+	}
+
+	offset := 0
+	if v := r.URL.Query().Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			writeError(w, http.StatusBadRequest, "invalid offset "+v)
+			return
+		}
+	}
+	lines := s.logs[runKey(projectName, jobName, scheduledAt)]
+	if offset > len(lines) {
+		offset = len(lines)
+	}
+	writeJSON(w, optimus.RunLogs{
+		Lines:    append([]optimus.LogLine{}, lines[offset:]...),
+		Next:     len(lines),
+		Complete: run.State == optimus.RunStateSuccess || run.State == optimus.RunStateFailed,
+	})
+}
+
+func (s *Server) listResources(w http.ResponseWriter, projectName, namespaceName, datastore string) {
+	ns, ok := s.namespace(w, projectName, namespaceName)
+	if !ok {
+		return
+	}
+	resources := append([]optimus.Resource{}, ns.resources[datastore]...)
+	writeJSON(w, map[string]interface{}{"resources": resources})
+}
+
+func (s *Server) deployJobSpec(w http.ResponseWriter, r *http.Request, projectName, namespaceName string) {
+	ns, ok := s.namespace(w, projectName, namespaceName)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Spec optimus.JobSpec `json:"spec"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job specification: "+err.Error())
+		return
+	}
+	if req.Spec.Name == "" {
+		writeError(w, http.StatusBadRequest, "job name is required")
+		return
+	}
+	if msg, ok := s.deployFailures[req.Spec.Name]; ok {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	ns.jobs[req.Spec.Name] = req.Spec
+	writeJSON(w, map[string]interface{}{"success": true, "message": "job " + req.Spec.Name + " deployed"})
+}
+
+func (s *Server) createReplay(w http.ResponseWriter, r *http.Request, projectName, namespaceName string) {
+	ns, ok := s.namespace(w, projectName, namespaceName)
+	if !ok {
+		return
+	}
+
+	var req optimus.ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid replay request: "+err.Error())
+		return
+	}
+	if _, ok := ns.jobs[req.JobName]; !ok {
+		writeError(w, http.StatusNotFound, "job "+req.JobName+" not found")
+		return
+	}
+	start, err := time.Parse(optimus.ReplayDateFormat, req.StartDate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid start_date: "+err.Error())
+		return
+	}
+	end, err := time.Parse(optimus.ReplayDateFormat, req.EndDate)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid end_date: "+err.Error())
+		return
+	}
+	if end.Before(start) {
+		writeError(w, http.StatusBadRequest, "end_date is before start_date")
+		return
+	}
+
+	tree, err := s.replayTree(projectName, req.JobName, start, end.AddDate(0, 0, 1).Add(-time.Second))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rp := &replayState{
+		id:        newID(),
+		project:   projectName,
+		namespace: namespaceName,
+		request:   req,
+		createdAt: time.Now(),
+		tree:      tree,
+	}
+	s.replays[rp.id] = rp
+	writeJSON(w, map[string]interface{}{"id": rp.id})
+}
+
+// replayTree plans the runs of the job and builds the tree of the jobs of the
+// project depending on it, directly or transitively
+func (s *Server) replayTree(projectName, jobName string, start, end time.Time) (*optimus.ReplayNode, error) {
+	specs := map[string]optimus.JobSpec{}
+	dependents := map[string][]string{}
+	for _, ns := range s.projects[projectName].namespaces {
+		for _, spec := range s.sortedSpecs(ns) {
+			specs[spec.Name] = spec
+			for _, dep := range spec.Dependencies {
+				// dependencies on jobs of other projects are not replayed
+				upstream := strings.TrimPrefix(dep.Name, projectName+"/")
+				if !strings.Contains(upstream, "/") {
+					dependents[upstream] = append(dependents[upstream], spec.Name)
+				}
+			}
+		}
+	}
+	if _, ok := specs[jobName]; !ok {
+		return nil, fmt.Errorf("job %s not found", jobName)
+	}
+
+	visited := map[string]bool{}
+	var build func(name string) (*optimus.ReplayNode, error)
+	build = func(name string) (*optimus.ReplayNode, error) {
+		visited[name] = true
+		spec := specs[name]
+		schedule, err := cron.ParseStandard(spec.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule of %s: %w", name, err)
+		}
+		node := &optimus.ReplayNode{JobName: name}
+		for at := schedule.Next(start.Add(-time.Second)); !at.IsZero() && !at.After(end); at = schedule.Next(at) {
+			node.Runs = append(node.Runs, optimus.ReplayRun{Run: at})
+		}
+
+		names := dependents[name]
+		sort.Strings(names)
+		for _, dependent := range names {
+			if _, ok := specs[dependent]; !ok || visited[dependent] {
+				continue
+			}
+			child, err := build(dependent)
+			if err != nil {
+				return nil, err
+			}
+			node.Dependents = append(node.Dependents, child)
+		}
+		return node, nil
+	}
+	return build(jobName)
+}
+
+func (s *Server) getReplayStatus(w http.ResponseWriter, projectName, id string) {
+	rp, ok := s.replays[id]
+	if !ok || rp.project != projectName {
+		writeError(w, http.StatusNotFound, "replay "+id+" not found")
+		return
+	}
+
+	// every run progresses in order, taking one step from pending to running
+	// and one more to finish
+	elapsed := time.Since(rp.createdAt)
+	cell := 0
+	counts := map[string]int{}
+	var progress func(node *optimus.ReplayNode) *optimus.ReplayNode
+	progress = func(node *optimus.ReplayNode) *optimus.ReplayNode {
+		out := &optimus.ReplayNode{JobName: node.JobName}
+		for _, run := range node.Runs {
+			start := time.Duration(cell) * s.replayStep
+			switch {
+			case elapsed < start:
+				run.State = optimus.RunStatePending
+			case elapsed < start+s.replayStep:
+				run.State = optimus.RunStateRunning
+			default:
+				run.State = optimus.RunStateSuccess
+				if msg, ok := s.replayFailures[node.JobName]; ok {
+					run.State = optimus.RunStateFailed
+					run.Message = msg
+				}
+			}
+			counts[run.State]++
+			out.Runs = append(out.Runs, run)
+			cell++
+		}
+		for _, dependent := range node.Dependents {
+			out.Dependents = append(out.Dependents, progress(dependent))
+		}
+		return out
+	}
+	tree := progress(rp.tree)
+
+	state := optimus.ReplayStateInProgress
+	switch {
+	case counts[optimus.RunStatePending] == cell:
+		state = optimus.ReplayStateAccepted
+	case counts[optimus.RunStatePending]+counts[optimus.RunStateRunning] > 0:
+		state = optimus.ReplayStateInProgress
+	case counts[optimus.RunStateFailed] > 0:
+		state = optimus.ReplayStateFailed
+	default:
+		state = optimus.ReplayStateSuccess
+	}
+
+	writeJSON(w, optimus.ReplayStatus{
+		ID:        rp.id,
+		JobName:   rp.request.JobName,
+		State:     state,
+		StartDate: rp.request.StartDate,
+		EndDate:   rp.request.EndDate,
+		CreatedAt: rp.createdAt,
+		Tree:      tree,
+	})
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(optimus.APIError{Code: grpcCodes[status], Message: msg})
+}
+
+// grpcCodes maps http status to the code sent by the grpc gateway
+var grpcCodes = map[int]int{
+	http.StatusBadRequest:          3,
+	http.StatusNotFound:            5,
+	http.StatusForbidden:           7,
+	http.StatusMethodNotAllowed:    12,
+	http.StatusInternalServerError: 13,
+	http.StatusServiceUnavailable:  14,
+	http.StatusUnauthorized:        16,
+}
@@ -0,0 +1,103 @@
+package optimus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const fetchConcurrency = 8
+
+// recentIntervals is how many intervals of a job are searched for its latest
+// run before falling back to its whole history
+const recentIntervals = 3
+
+// JobState is the specification of a job together with its latest run
+type JobState struct {
+	Spec    JobSpec
+	LastRun *JobRun
+}
+
+// LastRunState returns the state of the latest run or an empty string
+func (s JobState) LastRunState() string {
+	if s.LastRun == nil {
+		return ""
+	}
+	return s.LastRun.State
+}
+
+// ListJobStates fetches every job spec in the namespace along with its latest
+// run, only the runs of the last few intervals of a job are requested unless
+// none is found there
+func (c *Client) ListJobStates(ctx context.Context, project, namespace string) ([]JobState, error) {
+	specs, err := c.ListJobSpecs(ctx, project, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	states := make([]JobState, len(specs))
+	errs := make([]error, len(specs))
+	sem := make(chan struct{}, fetchConcurrency)
+	var wg sync.WaitGroup
+	for i := range specs {
+		states[i].Spec = specs[i]
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			states[i].LastRun, errs[i] = c.lastRun(ctx, project, specs[i], now)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return states, nil
+}
+
+// lastRun returns the latest run of a job, nil if it never ran
+func (c *Client) lastRun(ctx context.Context, project string, spec JobSpec, now time.Time) (*JobRun, error) {
+	filter := recentRuns(spec.Interval, now)
+	runs, err := c.ListJobRuns(ctx, project, spec.Name, filter)
+	if err != nil || len(runs) > 0 || filter.StartDate.IsZero() {
+		return LatestRun(runs), err
+	}
+	runs, err = c.ListJobRuns(ctx, project, spec.Name, JobRunFilter{})
+	return LatestRun(runs), err
+}
+
+// recentRuns filters the runs of the last intervals of a schedule, measured by
+// the gap between its next two runs, and is empty when interval is not a
+// valid cron expression
+func recentRuns(interval string, now time.Time) JobRunFilter {
+	schedule, err := cron.ParseStandard(interval)
+	if err != nil {
+		return JobRunFilter{}
+	}
+	next := schedule.Next(now)
+	after := schedule.Next(next)
+	if next.IsZero() || after.IsZero() {
+		return JobRunFilter{}
+	}
+	return JobRunFilter{StartDate: now.Add(-recentIntervals * after.Sub(next))}
+}
+
+// LatestRun returns the run with the latest scheduled time, nil if there are none
+func LatestRun(runs []JobRun) *JobRun {
+	var latest *JobRun
+	for i := range runs {
+		if latest == nil || runs[i].ScheduledAt.After(latest.ScheduledAt) {
+			latest = &runs[i]
+		}
+	}
+	return latest
+}
@@ -0,0 +1,73 @@
+package optimus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+func TestListJobStates(t *testing.T) {
+	srv := newServer(t)
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 2, 0, 0, 0, time.UTC)
+	if today.After(now) {
+		today = today.AddDate(0, 0, -1)
+	}
+	// orders ran daily for a month, revenue last ran a month ago and report never ran
+	for i := 0; i < 30; i++ {
+		srv.AddJobRun("sample", "orders", optimus.JobRun{ScheduledAt: today.AddDate(0, 0, -i), State: optimus.RunStateSuccess})
+	}
+	srv.AddJobRun("sample", "orders", optimus.JobRun{ScheduledAt: today.AddDate(0, 0, 1), State: optimus.RunStatePending})
+	srv.AddJobRun("sample", "revenue", optimus.JobRun{ScheduledAt: today.AddDate(0, -1, 0).Add(time.Hour), State: optimus.RunStateFailed})
+
+	var mu sync.Mutex
+	queries := map[string][]string{}
+	recorder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries[r.URL.Path] = append(queries[r.URL.Path], r.URL.RawQuery)
+		mu.Unlock()
+		srv.ServeHTTP(w, r)
+	}))
+	t.Cleanup(recorder.Close)
+	client, err := optimus.NewClient(recorder.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := client.ListJobStates(context.Background(), "sample", "analytics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := client.ListJobStates(context.Background(), "sample", "ingestion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	states = append(states, orders...)
+
+	want := map[string]string{
+		"orders":  optimus.RunStatePending,
+		"revenue": optimus.RunStateFailed,
+		"report":  "",
+	}
+	for _, s := range states {
+		if got := s.LastRunState(); got != want[s.Spec.Name] {
+			t.Errorf("last run of %s is %q, want %q", s.Spec.Name, got, want[s.Spec.Name])
+		}
+	}
+
+	// runs of the last days are requested first, the whole history only
+	// for jobs without a recent run
+	ordersQueries := queries["/api/v1beta1/project/sample/job/orders/run"]
+	if len(ordersQueries) != 1 || ordersQueries[0] == "" {
+		t.Errorf("runs of orders requested with %q, want a single bounded request", ordersQueries)
+	}
+	revenueQueries := queries["/api/v1beta1/project/sample/job/revenue/run"]
+	if len(revenueQueries) != 2 || revenueQueries[0] == "" || revenueQueries[1] != "" {
+		t.Errorf("runs of revenue requested with %q, want a bounded request and one without bounds", revenueQueries)
+	}
+}
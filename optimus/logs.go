@@ -0,0 +1,48 @@
+package optimus
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	LogSourceTask = "task"
+	LogSourceHook = "hook"
+)
+
+// LogLine is a line written by the task or a hook of a job run
+type LogLine struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Source     string    `json:"source"`
+	SourceType string    `json:"source_type"`
+	Attempt    int       `json:"attempt,omitempty"`
+	Message    string    `json:"message"`
+}
+
+// RunLogs is a page of the logs of a run, Next is the offset of the following
+// page and Complete is set once the run finished writing logs
+type RunLogs struct {
+	Lines    []LogLine `json:"lines"`
+	Next     int       `json:"next"`
+	Complete bool      `json:"complete"`
+}
+
+// GetJobRunLogs fetches the log lines of the run scheduled at scheduledAt
+// starting from offset, pass the Next of the previous page to follow a run
+func (c *Client) GetJobRunLogs(ctx context.Context, project, job string, scheduledAt time.Time, offset int) (*RunLogs, error) {
+	query := url.Values{}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+
+	var resp RunLogs
+	path := fmt.Sprintf("/project/%s/job/%s/run/%s/log",
+		url.PathEscape(project), url.PathEscape(job), url.PathEscape(scheduledAt.UTC().Format(time.RFC3339)))
+	if err := c.get(ctx, path, query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,124 @@
+package optimus
+
+import "time"
+
+type Project struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config,omitempty"`
+}
+
+type Namespace struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config,omitempty"`
+}
+
+// Job is the entry returned when listing jobs of a namespace
+type Job struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Owner     string `json:"owner"`
+	Interval  string `json:"interval"`
+	TaskName  string `json:"task_name"`
+}
+
+type JobConfigItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type JobDependency struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+type JobHook struct {
+	Name   string          `json:"name"`
+	Config []JobConfigItem `json:"config,omitempty"`
+}
+
+type JobRetry struct {
+	Count              int    `json:"count,omitempty"`
+	Delay              string `json:"delay,omitempty"`
+	ExponentialBackoff bool   `json:"exponential_backoff,omitempty"`
+}
+
+type JobNotifier struct {
+	On       string            `json:"on"`
+	Channels []string          `json:"channels,omitempty"`
+	Config   map[string]string `json:"config,omitempty"`
+}
+
+type JobBehavior struct {
+	Retry  *JobRetry     `json:"retry,omitempty"`
+	Notify []JobNotifier `json:"notify,omitempty"`
+}
+
+// JobSpec is the complete specification of a job as stored in optimus
+type JobSpec struct {
+	Version          int               `json:"version"`
+	Name             string            `json:"name"`
+	Owner            string            `json:"owner"`
+	Description      string            `json:"description,omitempty"`
+	StartDate        string            `json:"start_date"`
+	EndDate          string            `json:"end_date,omitempty"`
+	Interval         string            `json:"interval"`
+	DependsOnPast    bool              `json:"depends_on_past,omitempty"`
+	CatchUp          bool              `json:"catch_up,omitempty"`
+	TaskName         string            `json:"task_name"`
+	Config           []JobConfigItem   `json:"config,omitempty"`
+	WindowSize       string            `json:"window_size"`
+	WindowOffset     string            `json:"window_offset"`
+	WindowTruncateTo string            `json:"window_truncate_to"`
+	Dependencies     []JobDependency   `json:"dependencies,omitempty"`
+	Assets           map[string]string `json:"assets,omitempty"`
+	Hooks            []JobHook         `json:"hooks,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	Behavior         *JobBehavior      `json:"behavior,omitempty"`
+}
+
+const (
+	RunStatePending = "pending"
+	RunStateRunning = "running"
+	RunStateSuccess = "success"
+	RunStateFailed  = "failed"
+
+	RunTypeScheduled = "scheduled"
+	RunTypeManual    = "manual"
+)
+
+type JobRun struct {
+	State       string    `json:"state"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	Attempt     int       `json:"attempt,omitempty"`
+	Type        string    `json:"type,omitempty"`
+}
+
+// Duration returns how long the run took, or has been running for
+func (r JobRun) Duration() time.Duration {
+	if r.StartTime.IsZero() {
+		return 0
+	}
+	if r.EndTime.IsZero() {
+		return time.Since(r.StartTime)
+	}
+	return r.EndTime.Sub(r.StartTime)
+}
+
+// JobRunFilter narrows down the runs returned for a job
+type JobRunFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	States    []string
+}
+
+type Resource struct {
+	Name      string                 `json:"name"`
+	Datastore string                 `json:"datastore"`
+	Type      string                 `json:"type"`
+	Version   int                    `json:"version,omitempty"`
+	Labels    map[string]string      `json:"labels,omitempty"`
+	Spec      map[string]interface{} `json:"spec,omitempty"`
+	Assets    map[string]string      `json:"assets,omitempty"`
+}
@@ -0,0 +1,76 @@
+package optimus
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ReplayDateFormat is the format of dates accepted by the replay api
+const ReplayDateFormat = "2006-01-02"
+
+// ReplayRequest asks optimus to re-run a job and its downstream for a range of dates
+type ReplayRequest struct {
+	JobName                     string   `json:"job_name"`
+	StartDate                   string   `json:"start_date"`
+	EndDate                     string   `json:"end_date"`
+	Force                       bool     `json:"force,omitempty"`
+	AllowedDownstreamNamespaces []string `json:"allowed_downstream_namespaces,omitempty"`
+}
+
+// Replay submits a replay and returns its id
+func (c *Client) Replay(ctx context.Context, project, namespace string, req ReplayRequest) (string, error) {
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := c.post(ctx, namespacePath(project, namespace)+"/replay", req, &resp); err != nil {
+		return "", err
+	}
+	if resp.ID == "" {
+		return "", fmt.Errorf("optimus did not return an id for the replay of %s", req.JobName)
+	}
+	return resp.ID, nil
+}
+
+const (
+	ReplayStateAccepted   = "accepted"
+	ReplayStateInProgress = "in progress"
+	ReplayStateSuccess    = "success"
+	ReplayStateFailed     = "failed"
+)
+
+// ReplayRun is the state of a single run of a job during a replay
+type ReplayRun struct {
+	Run     time.Time `json:"run"`
+	State   string    `json:"state"`
+	Message string    `json:"message,omitempty"`
+}
+
+// ReplayNode is a job in the replay tree along with its replayed dependents
+type ReplayNode struct {
+	JobName    string        `json:"job_name"`
+	Runs       []ReplayRun   `json:"runs"`
+	Dependents []*ReplayNode `json:"dependents,omitempty"`
+}
+
+// ReplayStatus is the progress of a submitted replay
+type ReplayStatus struct {
+	ID        string      `json:"id"`
+	JobName   string      `json:"job_name"`
+	State     string      `json:"state"`
+	StartDate string      `json:"start_date"`
+	EndDate   string      `json:"end_date"`
+	CreatedAt time.Time   `json:"created_at"`
+	Tree      *ReplayNode `json:"response"`
+}
+
+// GetReplayStatus returns the progress of the replay with id
+func (c *Client) GetReplayStatus(ctx context.Context, project, id string) (*ReplayStatus, error) {
+	var status ReplayStatus
+	path := fmt.Sprintf("/project/%s/replay/%s", url.PathEscape(project), url.PathEscape(id))
+	if err := c.get(ctx, path, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
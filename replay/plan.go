@@ -0,0 +1,105 @@
+// Package replay computes which runs a replay of a job would re-execute.
+package replay
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/graph"
+	"github.com/sbchaos/mirage/job"
+)
+
+// Run is a single execution of a job during the replay
+type Run struct {
+	ScheduledAt time.Time `json:"scheduled_at"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+}
+
+// JobPlan lists the runs of a job which would be replayed
+type JobPlan struct {
+	Project   string `json:"project"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Interval  string `json:"interval"`
+	Runs      []Run  `json:"runs"`
+}
+
+// Plan is the preview of a replay including all downstream jobs
+type Plan struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Job        JobPlan   `json:"job"`
+	Downstream []JobPlan `json:"downstream"`
+}
+
+// TotalRuns returns the count of runs across the job and its downstream
+func (p *Plan) TotalRuns() int {
+	total := len(p.Job.Runs)
+	for _, d := range p.Downstream {
+		total += len(d.Runs)
+	}
+	return total
+}
+
+// NewPlan computes the runs of the job with id between start and end, along
+// with runs of every job depending on it in g
+func NewPlan(g *graph.Graph, id string, start, end time.Time) (*Plan, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("end %s is before start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	node := g.Node(id)
+	if node == nil {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+
+	jobPlan, err := planJob(node, start, end)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{Start: start, End: end, Job: *jobPlan}
+
+	for _, downstreamID := range g.AllDownstream(id) {
+		downstream := g.Node(downstreamID)
+		if downstream == nil {
+			continue
+		}
+		downstreamPlan, err := planJob(downstream, start, end)
+		if err != nil {
+			return nil, err
+		}
+		plan.Downstream = append(plan.Downstream, *downstreamPlan)
+	}
+	return plan, nil
+}
+
+func planJob(node *graph.Node, start, end time.Time) (*JobPlan, error) {
+	spec := node.Spec
+	schedule, err := cron.ParseStandard(spec.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule of %s: %w", spec.Name, err)
+	}
+	window, err := job.NewDataWindow(spec.WindowSize, spec.WindowOffset, spec.WindowTruncateTo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window of %s: %w", spec.Name, err)
+	}
+
+	plan := &JobPlan{
+		Project:   node.Project,
+		Namespace: node.Namespace,
+		Name:      spec.Name,
+		Interval:  spec.Interval,
+		Runs:      []Run{},
+	}
+	for at := schedule.Next(start.Add(-time.Second)); !at.IsZero() && !at.After(end); at = schedule.Next(at) {
+		windowStart, windowEnd := window.GetNextInterval(at)
+		plan.Runs = append(plan.Runs, Run{
+			ScheduledAt: at,
+			WindowStart: windowStart,
+			WindowEnd:   windowEnd,
+		})
+	}
+	return plan, nil
+}
@@ -0,0 +1,267 @@
+// Package sla compares the runs of jobs with the SLA declared in their specs.
+package sla
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const (
+	StatusMet      = "met"
+	StatusBreached = "breached"
+	StatusAtRisk   = "at risk"
+	StatusOnTrack  = "on track"
+
+	// notifierEvent is the notifier of a job spec configuring its SLA in the duration key
+	notifierEvent = "sla_miss"
+
+	// maxResults bounds the scheduled times evaluated for jobs running very often
+	maxResults = 1000
+
+	fetchConcurrency = 8
+)
+
+// severity orders the statuses from the most to the least urgent
+var severity = map[string]int{StatusBreached: 0, StatusAtRisk: 1, StatusOnTrack: 2, StatusMet: 3}
+
+// FromSpec returns the duration of the sla_miss notifier of the spec
+func FromSpec(spec optimus.JobSpec) (time.Duration, bool) {
+	if spec.Behavior == nil {
+		return 0, false
+	}
+	for _, notifier := range spec.Behavior.Notify {
+		if notifier.On != notifierEvent {
+			continue
+		}
+		if d, err := time.ParseDuration(notifier.Config["duration"]); err == nil && d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Result is the outcome of a scheduled time of a job
+type Result struct {
+	ScheduledAt time.Time
+	Deadline    time.Time
+
+	// Run is nil when the run was not created yet
+	Run    *optimus.JobRun
+	Status string
+
+	// Lateness is the completion, or the expected completion of unfinished
+	// runs, relative to the deadline, negative values are the margin left
+	Lateness time.Duration
+}
+
+// Report is the SLA of a job along with the results of its recent runs
+type Report struct {
+	Spec optimus.JobSpec
+	SLA  time.Duration
+
+	// Results are ordered from the latest scheduled time
+	Results []Result
+
+	// Typical is the median time from the scheduled time to the end of successful runs
+	Typical time.Duration
+}
+
+// Current returns the result of the latest scheduled time, nil when there is none
+func (r *Report) Current() *Result {
+	if len(r.Results) == 0 {
+		return nil
+	}
+	return &r.Results[0]
+}
+
+// Breaches returns the number of breached results
+func (r *Report) Breaches() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == StatusBreached {
+			n++
+		}
+	}
+	return n
+}
+
+// Trend returns for each of the last days the worst completion of the day as
+// a share of the SLA, values above 1 are breaches and days without runs are -1.
+// Days are the calendar days of the location of now.
+func (r *Report) Trend(days int, now time.Time) []float64 {
+	trend := make([]float64, days)
+	for i := range trend {
+		trend[i] = -1
+	}
+	today := dayNumber(now, now.Location())
+	for _, res := range r.Results {
+		day := days - 1 - (today - dayNumber(res.ScheduledAt, now.Location()))
+		if day < 0 || day >= days {
+			continue
+		}
+		ratio := float64(res.Deadline.Add(res.Lateness).Sub(res.ScheduledAt)) / float64(r.SLA)
+		if ratio > trend[day] {
+			trend[day] = ratio
+		}
+	}
+	return trend
+}
+
+// dayNumber counts the calendar days in loc up to t, consecutive days differ by
+// one even when a DST transition makes them 23 or 25 hours long
+func dayNumber(t time.Time, loc *time.Location) int {
+	year, month, day := t.In(loc).Date()
+	return int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+}
+
+// Evaluate compares the runs scheduled since from with the SLA of the job
+func Evaluate(spec optimus.JobSpec, sla time.Duration, runs []optimus.JobRun, from, now time.Time) *Report {
+	report := &Report{Spec: spec, SLA: sla, Typical: typical(runs)}
+
+	byTime := map[time.Time]*optimus.JobRun{}
+	for i := range runs {
+		byTime[runs[i].ScheduledAt.UTC()] = &runs[i]
+	}
+	scheduled := map[time.Time]bool{}
+	for t := range byTime {
+		if !t.Before(from) && !t.After(now) {
+			scheduled[t] = true
+		}
+	}
+	if schedule, err := cron.ParseStandard(spec.Interval); err == nil {
+		if start, err := time.Parse("2006-01-02", spec.StartDate); err == nil && from.Before(start) {
+			from = start
+		}
+		for t := schedule.Next(from.UTC().Add(-time.Second)); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+			scheduled[t.UTC()] = true
+		}
+	}
+
+	for t := range scheduled {
+		report.Results = append(report.Results, evaluate(t, sla, byTime[t], report.Typical, now))
+	}
+	sort.Slice(report.Results, func(i, j int) bool {
+		return report.Results[i].ScheduledAt.After(report.Results[j].ScheduledAt)
+	})
+	if len(report.Results) > maxResults {
+		report.Results = report.Results[:maxResults]
+	}
+	return report
+}
+
+func evaluate(scheduledAt time.Time, sla time.Duration, run *optimus.JobRun, typical time.Duration, now time.Time) Result {
+	res := Result{ScheduledAt: scheduledAt, Deadline: scheduledAt.Add(sla), Run: run}
+
+	if run != nil && run.State == optimus.RunStateSuccess && !run.EndTime.IsZero() {
+		res.Lateness = run.EndTime.Sub(res.Deadline)
+		res.Status = StatusMet
+		if res.Lateness > 0 {
+			res.Status = StatusBreached
+		}
+		return res
+	}
+
+	if now.After(res.Deadline) {
+		res.Status = StatusBreached
+		res.Lateness = now.Sub(res.Deadline)
+		return res
+	}
+
+	// unfinished before its deadline, at risk when it is expected to end late
+	expected := now
+	if typical > 0 && scheduledAt.Add(typical).After(expected) {
+		expected = scheduledAt.Add(typical)
+	}
+	res.Lateness = expected.Sub(res.Deadline)
+	res.Status = StatusOnTrack
+	if res.Lateness > 0 || (typical == 0 && -res.Lateness < sla/4) || (run != nil && run.State == optimus.RunStateFailed) {
+		res.Status = StatusAtRisk
+	}
+	return res
+}
+
+// typical returns the median time from the scheduled time to the end of successful runs
+func typical(runs []optimus.JobRun) time.Duration {
+	var durations []time.Duration
+	for _, r := range runs {
+		if r.State == optimus.RunStateSuccess && !r.EndTime.IsZero() {
+			durations = append(durations, r.EndTime.Sub(r.ScheduledAt))
+		}
+	}
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations[len(durations)/2]
+}
+
+// Less orders reports by the urgency of their current result, then by deadline
+func Less(a, b *Report) bool {
+	ca, cb := a.Current(), b.Current()
+	switch {
+	case ca == nil || cb == nil:
+		if (ca == nil) != (cb == nil) {
+			return cb == nil
+		}
+		return a.Spec.Name < b.Spec.Name
+	case severity[ca.Status] != severity[cb.Status]:
+		return severity[ca.Status] < severity[cb.Status]
+	case !ca.Deadline.Equal(cb.Deadline):
+		return ca.Deadline.Before(cb.Deadline)
+	}
+	return a.Spec.Name < b.Spec.Name
+}
+
+// Load evaluates the jobs of the namespace with an SLA over the last days,
+// jobs without an SLA in their spec use defaultSLA and are skipped when it is zero
+func Load(ctx context.Context, client *optimus.Client, project, namespace string, days int, defaultSLA time.Duration, now time.Time) ([]*Report, error) {
+	specs, err := client.ListJobSpecs(ctx, project, namespace)
+	if err != nil {
+		return nil, err
+	}
+	from := now.AddDate(0, 0, -days)
+
+	var reports []*Report
+	var errs []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, fetchConcurrency)
+	for _, spec := range specs {
+		d, ok := FromSpec(spec)
+		if !ok {
+			d = defaultSLA
+		}
+		if d <= 0 {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(spec optimus.JobSpec, d time.Duration) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			runs, err := client.ListJobRuns(ctx, project, spec.Name, optimus.JobRunFilter{StartDate: from, EndDate: now})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			reports = append(reports, Evaluate(spec, d, runs, from, now))
+		}(spec, d)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	sort.Slice(reports, func(i, j int) bool { return Less(reports[i], reports[j]) })
+	return reports, nil
+}
@@ -0,0 +1,59 @@
+package sla
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestTrendAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := func(year int, month time.Month, day, hour int, lateness time.Duration) Result {
+		scheduled := time.Date(year, month, day, hour, 0, 0, 0, loc)
+		return Result{ScheduledAt: scheduled, Deadline: scheduled.Add(time.Hour), Lateness: lateness}
+	}
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		results []Result
+		want    []float64
+	}{
+		{
+			// the days from 2022-03-12 to 2022-03-14 are 24, 23 and 24 hours long
+			name: "spring forward",
+			now:  time.Date(2022, 3, 14, 0, 30, 0, 0, loc),
+			results: []Result{
+				result(2022, 3, 14, 0, 0),
+				result(2022, 3, 13, 23, time.Hour),
+				result(2022, 3, 12, 1, -30*time.Minute),
+			},
+			want: []float64{0.5, 2, 1},
+		},
+		{
+			// the days from 2022-11-05 to 2022-11-07 are 24, 25 and 24 hours long
+			name: "fall back",
+			now:  time.Date(2022, 11, 7, 23, 30, 0, 0, loc),
+			results: []Result{
+				result(2022, 11, 7, 23, 0),
+				result(2022, 11, 6, 0, time.Hour),
+				result(2022, 11, 4, 23, 0),
+			},
+			want: []float64{-1, 2, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{SLA: time.Hour, Results: tt.results}
+			got := r.Trend(len(tt.want), tt.now)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Trend() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
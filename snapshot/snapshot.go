@@ -0,0 +1,125 @@
+// Package snapshot keeps the last state fetched from optimus on disk, so views
+// can render before the server answers and without a connection at all.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const (
+	jobsFile = "jobs.json"
+	runsDir  = "runs"
+)
+
+// Jobs is the last fetched state of the jobs in a namespace
+type Jobs struct {
+	FetchedAt time.Time          `json:"fetched_at"`
+	Jobs      []optimus.JobState `json:"jobs"`
+}
+
+// Runs is the last fetched spec and latest page of runs of a job
+type Runs struct {
+	FetchedAt time.Time        `json:"fetched_at"`
+	Spec      *optimus.JobSpec `json:"spec"`
+	Runs      []optimus.JobRun `json:"runs"`
+	Until     time.Time        `json:"until"`
+}
+
+// Store keeps snapshots of a profile below a directory, laid out as
+// <profile>/<project>/<namespace>/jobs.json and .../runs/<job>.json
+type Store struct {
+	dir string
+}
+
+func NewStore(dir, profile string) *Store {
+	return &Store{dir: filepath.Join(dir, escape(profile))}
+}
+
+func (s *Store) namespaceDir(project, namespace string) string {
+	return filepath.Join(s.dir, escape(project), escape(namespace))
+}
+
+// Jobs returns the snapshot of the namespace, nil when there is none
+func (s *Store) Jobs(project, namespace string) (*Jobs, error) {
+	var snap Jobs
+	found, err := read(filepath.Join(s.namespaceDir(project, namespace), jobsFile), &snap)
+	if !found || err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *Store) SaveJobs(project, namespace string, jobs []optimus.JobState, fetchedAt time.Time) error {
+	return write(filepath.Join(s.namespaceDir(project, namespace), jobsFile), Jobs{FetchedAt: fetchedAt, Jobs: jobs})
+}
+
+// Runs returns the snapshot of the runs of the job, nil when there is none
+func (s *Store) Runs(project, namespace, job string) (*Runs, error) {
+	var snap Runs
+	found, err := read(filepath.Join(s.namespaceDir(project, namespace), runsDir, escape(job)+".json"), &snap)
+	if !found || err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+func (s *Store) SaveRuns(project, namespace string, snap Runs) error {
+	return write(filepath.Join(s.namespaceDir(project, namespace), runsDir, escape(snap.Spec.Name)+".json"), snap)
+}
+
+func read(path string, out interface{}) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := json.Unmarshal(content, out); err != nil {
+		// a snapshot which can not be read is as good as none
+		return false, nil
+	}
+	return true, nil
+}
+
+// write replaces the file through a rename, so readers never see a partial snapshot
+func write(path string, value interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// escape keeps names usable as a single path element
+func escape(name string) string {
+	switch name {
+	case "", ".", "..":
+		return "_" + name
+	}
+	return url.PathEscape(name)
+}
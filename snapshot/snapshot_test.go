@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+func TestJobs(t *testing.T) {
+	fetchedAt := time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+	jobs := []optimus.JobState{
+		{Spec: optimus.JobSpec{Name: "sample.orders"}, LastRun: &optimus.JobRun{State: optimus.RunStateFailed, Attempt: 2}},
+		{Spec: optimus.JobSpec{Name: "sample.report"}},
+	}
+	s := NewStore(t.TempDir(), "default")
+
+	if snap, err := s.Jobs("sample", "analytics"); snap != nil || err != nil {
+		t.Fatalf("Jobs() before saving = %v, %v, want nil", snap, err)
+	}
+	if err := s.SaveJobs("sample", "analytics", jobs, fetchedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := s.Jobs("sample", "analytics")
+	if err != nil || snap == nil {
+		t.Fatalf("Jobs() = %v, %v, want the snapshot", snap, err)
+	}
+	if !snap.FetchedAt.Equal(fetchedAt) || len(snap.Jobs) != 2 || snap.Jobs[0].LastRun.Attempt != 2 || snap.Jobs[1].LastRun != nil {
+		t.Errorf("Jobs() = %+v, want the saved jobs", snap)
+	}
+	if other, err := s.Jobs("sample", "ingestion"); other != nil || err != nil {
+		t.Errorf("Jobs() of another namespace = %v, %v, want nil", other, err)
+	}
+}
+
+func TestRuns(t *testing.T) {
+	until := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	saved := Runs{
+		FetchedAt: until.Add(time.Hour),
+		Spec:      &optimus.JobSpec{Name: "sample/daily report"},
+		Runs:      []optimus.JobRun{{State: optimus.RunStateSuccess, ScheduledAt: until}},
+		Until:     until,
+	}
+	s := NewStore(t.TempDir(), "default")
+
+	if err := s.SaveRuns("sample", "analytics", saved); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := s.Runs("sample", "analytics", "sample/daily report")
+	if err != nil || snap == nil {
+		t.Fatalf("Runs() = %v, %v, want the snapshot", snap, err)
+	}
+	if snap.Spec.Name != saved.Spec.Name || len(snap.Runs) != 1 || !snap.Until.Equal(until) {
+		t.Errorf("Runs() = %+v, want %+v", snap, saved)
+	}
+	if other, err := s.Runs("sample", "analytics", "sample.orders"); other != nil || err != nil {
+		t.Errorf("Runs() of another job = %v, %v, want nil", other, err)
+	}
+}
+
+func TestProfilesAreSeparate(t *testing.T) {
+	dir := t.TempDir()
+	jobs := []optimus.JobState{{Spec: optimus.JobSpec{Name: "sample.orders"}}}
+	if err := NewStore(dir, "staging").SaveJobs("sample", "analytics", jobs, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if snap, err := NewStore(dir, "production").Jobs("sample", "analytics"); snap != nil || err != nil {
+		t.Errorf("Jobs() of another profile = %v, %v, want nil", snap, err)
+	}
+}
+
+func TestUnreadableSnapshot(t *testing.T) {
+	s := NewStore(t.TempDir(), "default")
+	if err := s.SaveJobs("sample", "analytics", nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(s.namespaceDir("sample", "analytics"), jobsFile)
+	if err := os.WriteFile(path, []byte(`{"jobs": [`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if snap, err := s.Jobs("sample", "analytics"); snap != nil || err != nil {
+		t.Errorf("Jobs() of a corrupt snapshot = %v, %v, want nil", snap, err)
+	}
+}
+
+func TestSaveLeavesNoTemporaryFiles(t *testing.T) {
+	s := NewStore(t.TempDir(), "default")
+	for i := 0; i < 2; i++ {
+		if err := s.SaveJobs("sample", "analytics", nil, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(s.namespaceDir("sample", "analytics"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != jobsFile {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("files %v, want only %s", names, jobsFile)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"sample", "sample"},
+		{"", "_"},
+		{".", "_."},
+		{"..", "_.."},
+		{"sample/daily report", "sample%2Fdaily%20report"},
+		{"../profiles", "..%2Fprofiles"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := escape(tt.name); got != tt.want {
+				t.Errorf("escape(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package spec
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// ChangeUnchanged is the status of a job whose local spec matches the server
+const ChangeUnchanged = "unchanged"
+
+// JobDiff is the difference of a job between the local specs and the server
+type JobDiff struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Status    string   `json:"status"`
+	Changes   []Change `json:"changes,omitempty"`
+}
+
+// CompareNamespace matches local and server specs of a namespace by name, jobs
+// only found locally are added and jobs only found on the server are removed
+func CompareNamespace(namespace string, local, remote []optimus.JobSpec) []JobDiff {
+	remoteByName := map[string]optimus.JobSpec{}
+	for _, s := range remote {
+		remoteByName[s.Name] = s
+	}
+
+	var diffs []JobDiff
+	seen := map[string]bool{}
+	for _, l := range local {
+		seen[l.Name] = true
+		r, ok := remoteByName[l.Name]
+		if !ok {
+			diffs = append(diffs, JobDiff{Name: l.Name, Namespace: namespace, Status: ChangeAdded, Changes: Diff(l, optimus.JobSpec{})})
+			continue
+		}
+
+		d := JobDiff{Name: l.Name, Namespace: namespace, Status: ChangeUnchanged, Changes: Diff(l, r)}
+		if len(d.Changes) > 0 {
+			d.Status = ChangeModified
+		}
+		diffs = append(diffs, d)
+	}
+	for _, r := range remote {
+		if !seen[r.Name] {
+			diffs = append(diffs, JobDiff{Name: r.Name, Namespace: namespace, Status: ChangeRemoved, Changes: Diff(optimus.JobSpec{}, r)})
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Name < diffs[j].Name })
+	return diffs
+}
+
+// Multiline reports if the change is shown as a unified diff rather than a
+// pair of values
+func (c Change) Multiline() bool {
+	return strings.HasPrefix(c.Field, "assets.") ||
+		strings.Contains(c.Local, "\n") || strings.Contains(c.Remote, "\n")
+}
@@ -0,0 +1,136 @@
+package spec
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// Kinds of change between a local spec and the one on the server
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Change is a field whose value differs between the local and the server spec,
+// fields are named by their path in job.yaml
+type Change struct {
+	Field  string `json:"field"`
+	Kind   string `json:"kind"`
+	Local  string `json:"local,omitempty"`
+	Remote string `json:"remote,omitempty"`
+
+	// Unified is the line diff of multiline values like assets
+	Unified string `json:"unified,omitempty"`
+}
+
+// Diff compares the fields of a local spec with the spec on the server
+func Diff(local, remote optimus.JobSpec) []Change {
+	l, r := Flatten(local), Flatten(remote)
+
+	fields := make([]string, 0, len(l)+len(r))
+	for f := range l {
+		fields = append(fields, f)
+	}
+	for f := range r {
+		if _, ok := l[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []Change
+	for _, f := range fields {
+		lv, inLocal := l[f]
+		rv, inRemote := r[f]
+		switch {
+		case !inRemote:
+			changes = append(changes, Change{Field: f, Kind: ChangeAdded, Local: lv})
+		case !inLocal:
+			changes = append(changes, Change{Field: f, Kind: ChangeRemoved, Remote: rv})
+		case lv != rv:
+			changes = append(changes, Change{Field: f, Kind: ChangeModified, Local: lv, Remote: rv})
+		default:
+			continue
+		}
+		if c := &changes[len(changes)-1]; c.Multiline() {
+			c.Unified = UnifiedDiff(c.Remote, c.Local)
+		}
+	}
+	return changes
+}
+
+// Flatten maps every non empty field of the spec to its value, keyed by the
+// path of the field in job.yaml
+func Flatten(spec optimus.JobSpec) map[string]string {
+	fields := map[string]string{}
+	set := func(field, value string) {
+		if value != "" {
+			fields[field] = value
+		}
+	}
+	setBool := func(field string, value bool) {
+		if value {
+			fields[field] = "true"
+		}
+	}
+
+	if spec.Version != 0 {
+		set("version", strconv.Itoa(spec.Version))
+	}
+	set("name", spec.Name)
+	set("owner", spec.Owner)
+	set("description", spec.Description)
+	set("schedule.start_date", spec.StartDate)
+	set("schedule.end_date", spec.EndDate)
+	set("schedule.interval", spec.Interval)
+	setBool("behavior.depends_on_past", spec.DependsOnPast)
+	setBool("behavior.catch_up", spec.CatchUp)
+	set("task.name", spec.TaskName)
+	for _, c := range spec.Config {
+		set("task.config."+c.Name, c.Value)
+	}
+	set("task.window.size", spec.WindowSize)
+	set("task.window.offset", spec.WindowOffset)
+	set("task.window.truncate_to", spec.WindowTruncateTo)
+
+	for _, d := range spec.Dependencies {
+		kind := d.Type
+		if kind == "" {
+			kind = "job"
+		}
+		set("dependencies."+d.Name, kind)
+	}
+	for name, content := range spec.Assets {
+		set("assets."+name, content)
+	}
+	for _, h := range spec.Hooks {
+		set("hooks."+h.Name, "enabled")
+		for _, c := range h.Config {
+			set("hooks."+h.Name+".config."+c.Name, c.Value)
+		}
+	}
+	for k, v := range spec.Labels {
+		set("labels."+k, v)
+	}
+
+	if b := spec.Behavior; b != nil {
+		if r := b.Retry; r != nil {
+			if r.Count != 0 {
+				set("behavior.retry.count", strconv.Itoa(r.Count))
+			}
+			set("behavior.retry.delay", r.Delay)
+			setBool("behavior.retry.exponential_backoff", r.ExponentialBackoff)
+		}
+		for _, n := range b.Notify {
+			set("behavior.notify."+n.On+".channels", strings.Join(n.Channels, ", "))
+			for k, v := range n.Config {
+				set("behavior.notify."+n.On+".config."+k, v)
+			}
+		}
+	}
+	return fields
+}
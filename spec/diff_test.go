@@ -0,0 +1,137 @@
+package spec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+func TestDiff(t *testing.T) {
+	remote := optimus.JobSpec{
+		Version:  1,
+		Name:     "sample.report",
+		Owner:    "bi@example.com",
+		Interval: "0 4 * * *",
+		TaskName: "bq2bq",
+		Config:   []optimus.JobConfigItem{{Name: "TABLE", Value: "report"}},
+		Labels:   map[string]string{"team": "bi"},
+		Assets:   map[string]string{"query.sql": "select id\nfrom orders\n"},
+	}
+
+	tests := []struct {
+		name  string
+		edit  func(s *optimus.JobSpec)
+		want  []string
+		lines string
+	}{
+		{
+			name: "equal",
+			edit: func(s *optimus.JobSpec) {},
+		},
+		{
+			name: "modified",
+			edit: func(s *optimus.JobSpec) { s.Interval = "0 5 * * *" },
+			want: []string{"modified schedule.interval 0 4 * * * → 0 5 * * *"},
+		},
+		{
+			name: "added and removed",
+			edit: func(s *optimus.JobSpec) {
+				s.Labels = map[string]string{"tier": "1"}
+				s.Dependencies = []optimus.JobDependency{{Name: "sample.orders"}}
+			},
+			want: []string{
+				"added dependencies.sample.orders  → job",
+				"removed labels.team bi → ",
+				"added labels.tier  → 1",
+			},
+		},
+		{
+			name:  "asset",
+			edit:  func(s *optimus.JobSpec) { s.Assets = map[string]string{"query.sql": "select id\nfrom orders_v2\n"} },
+			want:  []string{"modified assets.query.sql select id\nfrom orders\n → select id\nfrom orders_v2\n"},
+			lines: "@@ -1,2 +1,2 @@\n select id\n-from orders\n+from orders_v2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := remote
+			tt.edit(&local)
+
+			var got []string
+			var unified string
+			for _, c := range Diff(local, remote) {
+				got = append(got, fmt.Sprintf("%s %s %s → %s", c.Kind, c.Field, c.Remote, c.Local))
+				unified += c.Unified
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+			if unified != tt.lines {
+				t.Errorf("unified diff = %q, want %q", unified, tt.lines)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(from, to int) string {
+		s := ""
+		for i := from; i <= to; i++ {
+			s += fmt.Sprintf("line %d\n", i)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		local  string
+		want   string
+	}{
+		{
+			name:   "equal",
+			remote: lines(1, 3),
+			local:  lines(1, 3),
+		},
+		{
+			name:   "added to empty",
+			remote: "",
+			local:  lines(1, 2),
+			want:   "@@ -1,0 +1,2 @@\n+line 1\n+line 2\n",
+		},
+		{
+			name:   "removed line with context",
+			remote: lines(1, 9),
+			local:  lines(1, 4) + lines(6, 9),
+			want:   "@@ -2,7 +2,6 @@\n line 2\n line 3\n line 4\n-line 5\n line 6\n line 7\n line 8\n",
+		},
+		{
+			name:   "changes far apart make two hunks",
+			remote: lines(1, 20),
+			local:  "first\n" + lines(2, 19) + "last\n",
+			want: "@@ -1,4 +1,4 @@\n-line 1\n+first\n line 2\n line 3\n line 4\n" +
+				"@@ -17,4 +17,4 @@\n line 17\n line 18\n line 19\n-line 20\n+last\n",
+		},
+		{
+			name:   "changes close together share a hunk",
+			remote: lines(1, 8),
+			local:  "first\n" + lines(2, 7) + "last\n",
+			want: "@@ -1,8 +1,8 @@\n-line 1\n+first\n line 2\n line 3\n line 4\n line 5\n line 6\n line 7\n" +
+				"-line 8\n+last\n",
+		},
+		{
+			name:   "missing final newline",
+			remote: "a\nb",
+			local:  "a\nc\n",
+			want:   "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff(tt.remote, tt.local); got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
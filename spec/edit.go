@@ -0,0 +1,463 @@
+package spec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sbchaos/mirage/internal/ints"
+)
+
+// Set changes the value at the path of keys, creating the mappings missing
+// along it. Keys are used as in Line. Only the lines of the changed key are
+// rewritten, the rest of the document is kept byte for byte. Edits are kept
+// in memory until Save.
+func (f *File) Set(value interface{}, keys ...string) error {
+	node, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	return f.edit(func(d *document) error { return d.set(node, keys) })
+}
+
+// Delete removes the key at the end of the path, along with the mappings it
+// leaves empty. Deleting a path which does not exist changes nothing.
+func (f *File) Delete(keys ...string) error {
+	return f.edit(func(d *document) error { return d.delete(keys) })
+}
+
+// Append adds value as the last item of the list at the path of keys, the
+// list is created when it does not exist
+func (f *File) Append(value interface{}, keys ...string) error {
+	node, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	return f.edit(func(d *document) error { return d.append(node, keys) })
+}
+
+// Save writes the document as edited to the file
+func (f *File) Save() error {
+	return os.WriteFile(f.Path, f.content, 0o644)
+}
+
+// edit applies change to the lines of the document and parses the result,
+// the file is left as it was when either fails
+func (f *File) edit(change func(d *document) error) error {
+	if f.node == nil || len(f.node.Content) == 0 || f.node.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: document is not a mapping", f.Path)
+	}
+	indent := f.indent
+	if indent == 0 {
+		indent = defaultIndent
+	}
+	d := &document{root: f.node.Content[0], indent: indent}
+	for _, line := range strings.SplitAfter(string(f.content), "\n") {
+		if line != "" {
+			d.lines = append(d.lines, line)
+		}
+	}
+	if err := change(d); err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+
+	content := []byte(strings.Join(d.lines, ""))
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(content, node); err != nil {
+		return &ParseError{Path: f.Path, Err: err}
+	}
+	job := &Job{}
+	if err := node.Decode(job); err != nil {
+		return &ParseError{Path: f.Path, Err: err}
+	}
+	f.Job = job
+	f.node = node
+	f.content = content
+	return nil
+}
+
+func encodeValue(value interface{}) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// document is the text of a job.yaml along with its parsed mapping, lines
+// keep their line breaks and are numbered from 1 like the lines of nodes
+type document struct {
+	lines  []string
+	root   *yaml.Node
+	indent int
+}
+
+// span is the range of lines holding a key with its value, or an item of a
+// list, both ends included
+type span struct {
+	first, last int
+}
+
+// step is a key of a path found in the document, index is the position of
+// the key in its mapping or of the item in its list
+type step struct {
+	container *yaml.Node
+	index     int
+	span      span
+}
+
+func (s step) value() *yaml.Node {
+	if s.container.Kind == yaml.MappingNode {
+		return s.container.Content[s.index+1]
+	}
+	return s.container.Content[s.index]
+}
+
+// resolve follows keys through the block mappings and lists of the document,
+// returning a step per key found. It stops at the first key missing, or at a
+// value in flow style, whose lines cannot be told apart.
+func (d *document) resolve(keys []string) []step {
+	node, within := d.root, span{1, len(d.lines)}
+	var steps []step
+	for _, key := range keys {
+		if isFlow(node) {
+			break
+		}
+		s, ok := d.child(node, key, within)
+		if !ok {
+			break
+		}
+		steps = append(steps, s)
+		node, within = s.value(), s.span
+	}
+	return steps
+}
+
+// child finds key in a block mapping, or the item at the index key in a block list
+func (d *document) child(node *yaml.Node, key string, within span) (step, bool) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return step{node, i, d.pairSpan(node, i, within)}, true
+			}
+		}
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(key)
+		if err == nil && i >= 0 && i < len(node.Content) {
+			return step{node, i, d.itemSpan(node, i, within)}, true
+		}
+	}
+	return step{}, false
+}
+
+// pairSpan returns the lines of the i-th key of a block mapping and its value
+func (d *document) pairSpan(mapping *yaml.Node, i int, within span) span {
+	key := mapping.Content[i]
+	last := within.last
+	if i+2 < len(mapping.Content) {
+		last = mapping.Content[i+2].Line - 1
+	}
+	return d.trim(span{key.Line, last}, key.Column)
+}
+
+// itemSpan returns the lines of the i-th item of a block list
+func (d *document) itemSpan(seq *yaml.Node, i int, within span) span {
+	item := seq.Content[i]
+	last := within.last
+	if i+1 < len(seq.Content) {
+		last = seq.Content[i+1].Line - 1
+	}
+	dash, _ := d.dash(item)
+	return d.trim(span{item.Line, last}, dash)
+}
+
+// trim drops blank lines and comments indented no deeper than column from
+// the end of s, they belong to what follows
+func (d *document) trim(s span, column int) span {
+	for s.last > s.first {
+		line := d.lines[s.last-1]
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !(strings.HasPrefix(trimmed, "#") && len(line)-len(strings.TrimLeft(line, " ")) < column) {
+			break
+		}
+		s.last--
+	}
+	return s
+}
+
+// dash returns the column of the dash of a list item and the distance from
+// it to the item
+func (d *document) dash(item *yaml.Node) (int, int) {
+	line := d.lines[item.Line-1]
+	for c := item.Column - 1; c >= 1; c-- {
+		if c <= len(line) && line[c-1] == '-' {
+			return c, item.Column - c
+		}
+	}
+	return ints.Max(item.Column-2, 1), 2
+}
+
+func (d *document) set(value *yaml.Node, keys []string) error {
+	steps := d.resolve(keys)
+	n := len(steps)
+	if n == len(keys) {
+		last := steps[n-1]
+		return d.replace(last, restyle(value, last.value()))
+	}
+
+	node, within := d.root, span{1, len(d.lines)}
+	if n > 0 {
+		node, within = steps[n-1].value(), steps[n-1].span
+	}
+	if node.Kind == yaml.MappingNode && !isFlow(node) {
+		return d.insertPair(node, within, keys[n], nest(keys[n+1:], value))
+	}
+	if n == 0 {
+		return errors.New("document is not a mapping")
+	}
+	// values in flow style and empty values are rewritten as a whole
+	if !setNode(node, keys[n:], value) {
+		return fmt.Errorf("%s is not a mapping", strings.Join(keys[:n], "."))
+	}
+	return d.replace(steps[n-1], node)
+}
+
+func (d *document) delete(keys []string) error {
+	steps := d.resolve(keys)
+	n := len(steps)
+	if n < len(keys) {
+		if n == 0 || !deleteNode(steps[n-1].value(), keys[n:]) {
+			return nil
+		}
+		if !isEmpty(steps[n-1].value()) {
+			return d.replace(steps[n-1], steps[n-1].value())
+		}
+	}
+
+	// a mapping left without keys is removed along with its own key
+	for n > 1 && isOnly(steps[n-1]) {
+		n--
+	}
+	d.lines = splice(d.lines, steps[n-1].span, nil)
+	return nil
+}
+
+func (d *document) append(value *yaml.Node, keys []string) error {
+	steps := d.resolve(keys)
+	n := len(steps)
+	if n < len(keys) {
+		return d.set(&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{value}}, keys)
+	}
+
+	last := steps[n-1]
+	seq := last.value()
+	switch {
+	case seq.Kind == yaml.SequenceNode && !isFlow(seq) && len(seq.Content) > 0:
+		item := seq.Content[len(seq.Content)-1]
+		after := d.itemSpan(seq, len(seq.Content)-1, last.span)
+		dash, gap := d.dash(item)
+		lines, err := d.renderItem(value, dash, gap)
+		if err != nil {
+			return err
+		}
+		d.insert(after.last, lines)
+		return nil
+	case seq.Kind == yaml.SequenceNode:
+		seq.Content = append(seq.Content, value)
+		return d.replace(last, seq)
+	case seq.Kind == yaml.ScalarNode && seq.Tag == "!!null":
+		return d.replace(last, &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{value}})
+	}
+	return fmt.Errorf("%s is not a list", strings.Join(keys, "."))
+}
+
+// replace rewrites the lines of a step with a new value
+func (d *document) replace(s step, value *yaml.Node) error {
+	var lines []string
+	var err error
+	if s.container.Kind == yaml.MappingNode {
+		key := s.container.Content[s.index]
+		lines, err = d.renderPair(bareKey(key), value, key.Column)
+	} else {
+		dash, gap := d.dash(s.value())
+		lines, err = d.renderItem(value, dash, gap)
+	}
+	if err != nil {
+		return err
+	}
+	d.lines = splice(d.lines, s.span, lines)
+	return nil
+}
+
+// insertPair adds a key after the last key of a block mapping
+func (d *document) insertPair(mapping *yaml.Node, within span, key string, value *yaml.Node) error {
+	after := d.pairSpan(mapping, len(mapping.Content)-2, within)
+	lines, err := d.renderPair(&yaml.Node{Kind: yaml.ScalarNode, Value: key}, value, mapping.Content[0].Column)
+	if err != nil {
+		return err
+	}
+	d.insert(after.last, lines)
+	return nil
+}
+
+// insert adds lines after the line numbered after
+func (d *document) insert(after int, lines []string) {
+	if after > 0 && !strings.HasSuffix(d.lines[after-1], "\n") {
+		d.lines[after-1] += "\n"
+	}
+	d.lines = splice(d.lines, span{after + 1, after}, lines)
+}
+
+func (d *document) renderPair(key, value *yaml.Node, column int) ([]string, error) {
+	return d.render(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{key, value}}, column)
+}
+
+// renderItem renders a list item with its dash at column, gap columns
+// before the value
+func (d *document) renderItem(value *yaml.Node, column, gap int) ([]string, error) {
+	lines, err := d.render(value, 1)
+	if err != nil {
+		return nil, err
+	}
+	pad := strings.Repeat(" ", column-1)
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = pad + "-" + strings.Repeat(" ", gap-1) + line
+		case line != "\n":
+			lines[i] = pad + strings.Repeat(" ", gap) + line
+		}
+	}
+	return lines, nil
+}
+
+// render encodes node with the indentation of the document, every line
+// starting at column
+func (d *document) render(node *yaml.Node, column int) ([]string, error) {
+	b := &bytes.Buffer{}
+	enc := yaml.NewEncoder(b)
+	enc.SetIndent(d.indent)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	pad := strings.Repeat(" ", column-1)
+	var lines []string
+	for _, line := range strings.SplitAfter(b.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		if line != "\n" {
+			line = pad + line
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// splice replaces the lines of s with others, s is empty when its last line
+// comes before the first
+func splice(lines []string, s span, others []string) []string {
+	result := make([]string, 0, len(lines)+len(others))
+	result = append(result, lines[:s.first-1]...)
+	result = append(result, others...)
+	return append(result, lines[s.last:]...)
+}
+
+// bareKey copies a key without the comments above and below it, which stay
+// in place when the lines of its value are rewritten
+func bareKey(key *yaml.Node) *yaml.Node {
+	k := *key
+	k.HeadComment = ""
+	k.FootComment = ""
+	return &k
+}
+
+// restyle gives a new value the style and the comment on the line of the
+// value it replaces
+func restyle(value, old *yaml.Node) *yaml.Node {
+	if value.Kind == old.Kind && (value.Kind != yaml.ScalarNode || value.Tag == old.Tag) {
+		value.Style = old.Style
+	}
+	value.LineComment = old.LineComment
+	return value
+}
+
+// nest wraps value in a mapping per key
+func nest(keys []string, value *yaml.Node) *yaml.Node {
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: keys[i]}, value,
+		}}
+	}
+	return value
+}
+
+func isFlow(node *yaml.Node) bool {
+	return node.Style&yaml.FlowStyle != 0
+}
+
+// isOnly reports whether the step is the only key of its mapping or the
+// only item of its list
+func isOnly(s step) bool {
+	if s.container.Kind == yaml.MappingNode {
+		return len(s.container.Content) == 2
+	}
+	return len(s.container.Content) == 1
+}
+
+func isEmpty(node *yaml.Node) bool {
+	return (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode) && len(node.Content) == 0
+}
+
+// setNode sets the value at the path of keys below node, turning an empty
+// value into a mapping. It reports false when a key is not in a mapping.
+func setNode(node *yaml.Node, keys []string, value *yaml.Node) bool {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		*node = *nest(keys, value)
+		return true
+	}
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != keys[0] {
+			continue
+		}
+		if len(keys) == 1 {
+			node.Content[i+1] = restyle(value, node.Content[i+1])
+			return true
+		}
+		return setNode(node.Content[i+1], keys[1:], value)
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: keys[0]}, nest(keys[1:], value))
+	return true
+}
+
+// deleteNode removes the key at the end of the path below node, reporting
+// whether it existed
+func deleteNode(node *yaml.Node, keys []string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != keys[0] {
+			continue
+		}
+		if len(keys) == 1 {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+		return deleteNode(node.Content[i+1], keys[1:])
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package spec
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const assetsDir = "assets"
+
+// File is a job spec loaded from disk
+type File struct {
+	// Path is the location of job.yaml
+	Path      string
+	Namespace string
+	Job       *Job
+	Assets    map[string]string
+
+	// node is the parsed document, kept to report line numbers
+	node *yaml.Node
+
+	// indent is the indentation of the document, kept when it is written
+	indent int
+
+	// content is the document as edited by Set, Delete and Append
+	content []byte
+}
+
+// Dir returns the directory of the job
+func (f *File) Dir() string {
+	return filepath.Dir(f.Path)
+}
+
+// Optimus returns the spec in the form used by the optimus api
+func (f *File) Optimus() optimus.JobSpec {
+	return f.Job.ToOptimus(f.Assets)
+}
+
+// Line returns the line of the value at the path of keys in job.yaml, or
+// the line of the closest parent which exists. Items of lists are selected
+// by their index, as in Line("dependencies", "1", "job").
+func (f *File) Line(keys ...string) int {
+	_, line, _ := f.lookup(keys)
+	return line
+}
+
+// Value returns the scalar at the path of keys in job.yaml, keys are used as
+// in Line
+func (f *File) Value(keys ...string) (string, bool) {
+	node, _, found := f.lookup(keys)
+	if !found || node.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	return node.Value, true
+}
+
+// lookup walks the document along keys, returning the last node reached with
+// its line and whether the whole path exists
+func (f *File) lookup(keys []string) (*yaml.Node, int, bool) {
+	if f.node == nil || len(f.node.Content) == 0 {
+		return nil, 1, false
+	}
+	current := f.node.Content[0]
+	line := 1
+	for _, key := range keys {
+		if current.Kind == yaml.SequenceNode {
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(current.Content) {
+				return current, line, false
+			}
+			current = current.Content[i]
+			line = current.Line
+			continue
+		}
+		if current.Kind != yaml.MappingNode {
+			return current, line, false
+		}
+		found := false
+		for i := 0; i+1 < len(current.Content); i += 2 {
+			if current.Content[i].Value == key {
+				line = current.Content[i].Line
+				current = current.Content[i+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return current, line, false
+		}
+	}
+	return current, line, true
+}
+
+// ParseError is returned for a job.yaml which can not be parsed
+type ParseError struct {
+	Path string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// Diagnostic locates the parse error at the line reported by the yaml parser
+func (e *ParseError) Diagnostic() Diagnostic {
+	line := 1
+	if m := yamlLinePattern.FindStringSubmatch(e.Err.Error()); m != nil {
+		line, _ = strconv.Atoi(m[1])
+	}
+	return Diagnostic{Path: e.Path, Line: line, Message: e.Err.Error()}
+}
+
+// LoadFile reads a single job.yaml along with the files in its assets directory
+func LoadFile(path, namespace string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(content, node); err != nil {
+		return nil, &ParseError{Path: path, Err: err}
+	}
+	job := &Job{}
+	if err := node.Decode(job); err != nil {
+		return nil, &ParseError{Path: path, Err: err}
+	}
+
+	assets, err := loadAssets(filepath.Join(filepath.Dir(path), assetsDir))
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		Path:      path,
+		Namespace: namespace,
+		Job:       job,
+		Assets:    assets,
+		node:      node,
+		indent:    detectIndent(content),
+		content:   content,
+	}, nil
+}
+
+// Load walks dir and reads every job.yaml in it, files which fail to parse
+// are returned as ParseError along with the specs which could be read
+func Load(dir, namespace string) ([]*File, []error) {
+	var files []*File
+	var errs []error
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() != FileName {
+			return nil
+		}
+		f, err := LoadFile(path, namespace)
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Job.Name < files[j].Job.Name })
+	return files, errs
+}
+
+func loadAssets(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	assets := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		assets[e.Name()] = string(content)
+	}
+	return assets, nil
+}
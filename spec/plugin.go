@@ -0,0 +1,145 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Plugin describes the config and assets a task expects, in the form of the
+// yaml descriptors of optimus plugins
+type Plugin struct {
+	Name        string           `yaml:"name"`
+	Description string           `yaml:"description,omitempty"`
+	Questions   []PluginQuestion `yaml:"questions,omitempty"`
+
+	// Assets are the files the task reads from the assets directory of the job
+	Assets []string `yaml:"assets,omitempty"`
+}
+
+// PluginQuestion is a config key of a task
+type PluginQuestion struct {
+	Name            string   `yaml:"name"`
+	Prompt          string   `yaml:"prompt,omitempty"`
+	Required        bool     `yaml:"required,omitempty"`
+	Regexp          string   `yaml:"regexp,omitempty"`
+	ValidationError string   `yaml:"validationerror,omitempty"`
+	Options         []string `yaml:"options,omitempty"`
+}
+
+// Plugins holds descriptors by lower case task name
+type Plugins map[string]*Plugin
+
+// DefaultPlugins returns the descriptors of the tasks offered by the create command
+func DefaultPlugins() Plugins {
+	return Plugins{
+		"bq2bq": {
+			Name:        "bq2bq",
+			Description: "Run a bigquery query and load its result into a table",
+			Questions: []PluginQuestion{
+				{Name: "PROJECT", Prompt: "Project ID", Required: true, Regexp: `^[a-zA-Z0-9_\-]+$`, ValidationError: "invalid project name, only letters, digits, - and _ are allowed"},
+				{Name: "DATASET", Prompt: "Dataset name", Required: true, Regexp: `^[a-zA-Z0-9_\-]+$`, ValidationError: "invalid dataset name, only letters, digits, - and _ are allowed"},
+				{Name: "TABLE", Prompt: "Table ID", Required: true, Regexp: `^[a-zA-Z0-9_\-]+$`, ValidationError: "invalid table name, only letters, digits, - and _ are allowed"},
+				{Name: "LOAD_METHOD", Prompt: "Load method to use on destination", Required: true, Options: []string{"APPEND", "REPLACE", "REPLACE_MERGE", "MERGE"}},
+			},
+			Assets: []string{"query.sql"},
+		},
+		"python": {
+			Name:        "python",
+			Description: "Run a python script",
+			Assets:      []string{"main.py"},
+		},
+	}
+}
+
+// Get returns the descriptor of a task, task names are not case sensitive
+func (p Plugins) Get(task string) (*Plugin, bool) {
+	plugin, ok := p[strings.ToLower(task)]
+	return plugin, ok
+}
+
+// Names returns the names of the known tasks, sorted
+func (p Plugins) Names() []string {
+	names := make([]string, 0, len(p))
+	for _, plugin := range p {
+		names = append(names, plugin.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadPlugins adds the descriptors of the yaml files in dir to the default
+// plugins, descriptors replace default plugins of the same name
+func LoadPlugins(dir string) (Plugins, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := DefaultPlugins()
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		plugin := &Plugin{}
+		if err := yaml.Unmarshal(content, plugin); err != nil {
+			return nil, fmt.Errorf("invalid plugin %s: %w", path, err)
+		}
+		if plugin.Name == "" {
+			return nil, fmt.Errorf("invalid plugin %s: name is required", path)
+		}
+		for _, q := range plugin.Questions {
+			if _, err := regexp.Compile(q.Regexp); err != nil {
+				return nil, fmt.Errorf("invalid plugin %s: regexp of %s: %w", path, q.Name, err)
+			}
+		}
+		plugins[strings.ToLower(plugin.Name)] = plugin
+	}
+	return plugins, nil
+}
+
+// validate checks a config value against the question, values using macros
+// are only resolved by optimus and are not checked
+func (q PluginQuestion) validate(value string) error {
+	if value == "" {
+		if q.Required {
+			return fmt.Errorf("is required")
+		}
+		return nil
+	}
+	if strings.Contains(value, "{{") {
+		return nil
+	}
+	if len(q.Options) > 0 && !containsString(q.Options, value) {
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(q.Options, ", "))
+	}
+	if q.Regexp != "" {
+		if re, err := regexp.Compile(q.Regexp); err == nil && !re.MatchString(value) {
+			if q.ValidationError != "" {
+				return fmt.Errorf("%s", q.ValidationError)
+			}
+			return fmt.Errorf("%q does not match %s", value, q.Regexp)
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package spec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSpec writes job.yaml with content and the assets into a job directory
+// of its own and loads it
+func writeSpec(t *testing.T, content string, assets map[string]string) *File {
+	t.Helper()
+	dir := t.TempDir()
+	if len(assets) > 0 {
+		if err := os.MkdirAll(filepath.Join(dir, assetsDir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, asset := range assets {
+		if err := os.WriteFile(filepath.Join(dir, assetsDir, name), []byte(asset), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadFile(path, "analytics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// diagnostics formats the diagnostics as line: message
+func diagnostics(diags []Diagnostic) []string {
+	var lines []string
+	for _, d := range diags {
+		lines = append(lines, fmt.Sprintf("%d: %s", d.Line, d.Message))
+	}
+	return lines
+}
+
+func TestPluginQuestionValidate(t *testing.T) {
+	project := PluginQuestion{Name: "PROJECT", Required: true, Regexp: `^[a-z]+$`, ValidationError: "invalid project"}
+	method := PluginQuestion{Name: "LOAD_METHOD", Options: []string{"APPEND", "REPLACE"}}
+	pattern := PluginQuestion{Name: "TABLE", Regexp: `^[a-z]+$`}
+
+	tests := []struct {
+		name     string
+		question PluginQuestion
+		value    string
+		wantErr  string
+	}{
+		{name: "valid", question: project, value: "sample"},
+		{name: "required", question: project, wantErr: "is required"},
+		{name: "optional", question: method},
+		{name: "validation error", question: project, value: "Sample", wantErr: "invalid project"},
+		{name: "regexp without validation error", question: pattern, value: "Orders", wantErr: `"Orders" does not match ^[a-z]+$`},
+		{name: "macro", question: project, value: "{{.GLOBAL__PROJECT}}"},
+		{name: "option", question: method, value: "REPLACE"},
+		{name: "unknown option", question: method, value: "MERGE", wantErr: `"MERGE" is not one of APPEND, REPLACE`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.question.validate(tt.value)
+			if got := fmt.Sprint(err); (err != nil || tt.wantErr != "") && got != tt.wantErr {
+				t.Errorf("validate(%q) = %v, want %s", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPlugins(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "adds plugins",
+			files: map[string]string{
+				"spark.yaml": "name: Spark\nquestions:\n- name: JAR\n  required: true\n",
+				"notes.txt":  "not a plugin",
+			},
+			want: []string{"Spark", "bq2bq", "python"},
+		},
+		{
+			name:  "replaces a default plugin",
+			files: map[string]string{"python.yml": "name: python\nassets:\n- run.py\n"},
+			want:  []string{"bq2bq", "python"},
+		},
+		{
+			name:    "without name",
+			files:   map[string]string{"spark.yaml": "description: Spark\n"},
+			wantErr: "name is required",
+		},
+		{
+			name:    "invalid regexp",
+			files:   map[string]string{"spark.yaml": "name: spark\nquestions:\n- name: JAR\n  regexp: '[a-'\n"},
+			wantErr: "regexp of JAR",
+		},
+		{
+			name:    "invalid yaml",
+			files:   map[string]string{"spark.yaml": "name: [spark\n"},
+			wantErr: "invalid plugin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			plugins, err := LoadPlugins(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadPlugins() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := plugins.Names(); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("Names() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := LoadPlugins(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadPlugins() of a missing directory succeeded, want an error")
+	}
+}
+
+func TestPluginsGet(t *testing.T) {
+	plugins := DefaultPlugins()
+	if p, ok := plugins.Get("BQ2BQ"); !ok || p.Name != "bq2bq" {
+		t.Errorf("Get(BQ2BQ) = %v, %v, want bq2bq", p, ok)
+	}
+	if _, ok := plugins.Get("spark"); ok {
+		t.Error("Get(spark) found a plugin, want none")
+	}
+}
+
+func TestValidateTask(t *testing.T) {
+	const header = "version: 1\nname: sample.report\nowner: bi@example.com\n"
+	tests := []struct {
+		name    string
+		content string
+		assets  map[string]string
+		want    []string
+	}{
+		{
+			name: "valid",
+			content: header + "task:\n  name: bq2bq\n  config:\n    PROJECT: sample\n    DATASET: analytics\n" +
+				"    TABLE: report\n    LOAD_METHOD: REPLACE\n",
+			assets: map[string]string{"query.sql": "select 1"},
+		},
+		{
+			name: "invalid config and missing asset",
+			content: header + "task:\n  name: bq2bq\n  config:\n    PROJECT: sample\n    DATASET: analytics!\n" +
+				"    LOAD_METHOD: UPSERT\n",
+			want: []string{
+				"8: bq2bq config DATASET invalid dataset name, only letters, digits, - and _ are allowed",
+				"6: bq2bq config TABLE is required",
+				`9: bq2bq config LOAD_METHOD "UPSERT" is not one of APPEND, REPLACE, REPLACE_MERGE, MERGE`,
+				"5: bq2bq requires the asset query.sql in assets",
+			},
+		},
+		{
+			name:    "unknown task",
+			content: header + "task:\n  name: spark\n",
+			want:    []string{"5: task spark is not a known plugin, known plugins are bq2bq, python"},
+		},
+		{
+			name:    "without task",
+			content: header,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := writeSpec(t, tt.content, tt.assets)
+			got := diagnostics(f.ValidateTask(DefaultPlugins()))
+			for i := range got {
+				got[i] = strings.ReplaceAll(got[i], filepath.Join(f.Dir(), assetsDir), assetsDir)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ValidateTask() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
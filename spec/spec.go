@@ -0,0 +1,181 @@
+// Package spec reads and writes job specifications kept in a local optimus repository.
+package spec
+
+import (
+	"sort"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// FileName is the name of the file holding the specification of a job
+const FileName = "job.yaml"
+
+type Schedule struct {
+	StartDate string `yaml:"start_date"`
+	EndDate   string `yaml:"end_date,omitempty"`
+	Interval  string `yaml:"interval"`
+}
+
+type Retry struct {
+	Count              int    `yaml:"count,omitempty"`
+	Delay              string `yaml:"delay,omitempty"`
+	ExponentialBackoff bool   `yaml:"exponential_backoff,omitempty"`
+}
+
+type Notifier struct {
+	On       string            `yaml:"on"`
+	Channels []string          `yaml:"channels,omitempty"`
+	Config   map[string]string `yaml:"config,omitempty"`
+}
+
+type Behavior struct {
+	DependsOnPast bool       `yaml:"depends_on_past,omitempty"`
+	CatchUp       bool       `yaml:"catch_up,omitempty"`
+	Retry         *Retry     `yaml:"retry,omitempty"`
+	Notify        []Notifier `yaml:"notify,omitempty"`
+}
+
+type Window struct {
+	Size       string `yaml:"size"`
+	Offset     string `yaml:"offset"`
+	TruncateTo string `yaml:"truncate_to"`
+}
+
+type Task struct {
+	Name   string            `yaml:"name"`
+	Config map[string]string `yaml:"config,omitempty"`
+	Window Window            `yaml:"window"`
+}
+
+type Dependency struct {
+	Job  string `yaml:"job,omitempty"`
+	Type string `yaml:"type,omitempty"`
+}
+
+type Hook struct {
+	Name   string            `yaml:"name"`
+	Config map[string]string `yaml:"config,omitempty"`
+}
+
+// Job is the content of a job.yaml file
+type Job struct {
+	Version      int               `yaml:"version"`
+	Name         string            `yaml:"name"`
+	Owner        string            `yaml:"owner"`
+	Description  string            `yaml:"description,omitempty"`
+	Schedule     Schedule          `yaml:"schedule"`
+	Behavior     Behavior          `yaml:"behavior,omitempty"`
+	Task         Task              `yaml:"task"`
+	Labels       map[string]string `yaml:"labels,omitempty"`
+	Dependencies []Dependency      `yaml:"dependencies,omitempty"`
+	Hooks        []Hook            `yaml:"hooks,omitempty"`
+}
+
+// ToOptimus converts the local spec to the form used by the optimus api
+func (j *Job) ToOptimus(assets map[string]string) optimus.JobSpec {
+	spec := optimus.JobSpec{
+		Version:          j.Version,
+		Name:             j.Name,
+		Owner:            j.Owner,
+		Description:      j.Description,
+		StartDate:        j.Schedule.StartDate,
+		EndDate:          j.Schedule.EndDate,
+		Interval:         j.Schedule.Interval,
+		DependsOnPast:    j.Behavior.DependsOnPast,
+		CatchUp:          j.Behavior.CatchUp,
+		TaskName:         j.Task.Name,
+		Config:           configItems(j.Task.Config),
+		WindowSize:       j.Task.Window.Size,
+		WindowOffset:     j.Task.Window.Offset,
+		WindowTruncateTo: j.Task.Window.TruncateTo,
+		Assets:           assets,
+		Labels:           j.Labels,
+	}
+	for _, d := range j.Dependencies {
+		if d.Job == "" {
+			continue
+		}
+		spec.Dependencies = append(spec.Dependencies, optimus.JobDependency{Name: d.Job, Type: d.Type})
+	}
+	for _, h := range j.Hooks {
+		spec.Hooks = append(spec.Hooks, optimus.JobHook{Name: h.Name, Config: configItems(h.Config)})
+	}
+	if j.Behavior.Retry != nil || len(j.Behavior.Notify) > 0 {
+		spec.Behavior = &optimus.JobBehavior{}
+		if r := j.Behavior.Retry; r != nil {
+			spec.Behavior.Retry = &optimus.JobRetry{Count: r.Count, Delay: r.Delay, ExponentialBackoff: r.ExponentialBackoff}
+		}
+		for _, n := range j.Behavior.Notify {
+			spec.Behavior.Notify = append(spec.Behavior.Notify, optimus.JobNotifier{On: n.On, Channels: n.Channels, Config: n.Config})
+		}
+	}
+	return spec
+}
+
+// FromOptimus converts a spec from the optimus api into its local form
+func FromOptimus(spec optimus.JobSpec) *Job {
+	j := &Job{
+		Version:     spec.Version,
+		Name:        spec.Name,
+		Owner:       spec.Owner,
+		Description: spec.Description,
+		Schedule: Schedule{
+			StartDate: spec.StartDate,
+			EndDate:   spec.EndDate,
+			Interval:  spec.Interval,
+		},
+		Behavior: Behavior{
+			DependsOnPast: spec.DependsOnPast,
+			CatchUp:       spec.CatchUp,
+		},
+		Task: Task{
+			Name:   spec.TaskName,
+			Config: configMap(spec.Config),
+			Window: Window{
+				Size:       spec.WindowSize,
+				Offset:     spec.WindowOffset,
+				TruncateTo: spec.WindowTruncateTo,
+			},
+		},
+		Labels: spec.Labels,
+	}
+	for _, d := range spec.Dependencies {
+		j.Dependencies = append(j.Dependencies, Dependency{Job: d.Name, Type: d.Type})
+	}
+	for _, h := range spec.Hooks {
+		j.Hooks = append(j.Hooks, Hook{Name: h.Name, Config: configMap(h.Config)})
+	}
+	if b := spec.Behavior; b != nil {
+		if r := b.Retry; r != nil {
+			j.Behavior.Retry = &Retry{Count: r.Count, Delay: r.Delay, ExponentialBackoff: r.ExponentialBackoff}
+		}
+		for _, n := range b.Notify {
+			j.Behavior.Notify = append(j.Behavior.Notify, Notifier{On: n.On, Channels: n.Channels, Config: n.Config})
+		}
+	}
+	return j
+}
+
+// configItems converts a config map to items sorted by name
+func configItems(config map[string]string) []optimus.JobConfigItem {
+	if len(config) == 0 {
+		return nil
+	}
+	items := make([]optimus.JobConfigItem, 0, len(config))
+	for name, value := range config {
+		items = append(items, optimus.JobConfigItem{Name: name, Value: value})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items
+}
+
+func configMap(items []optimus.JobConfigItem) map[string]string {
+	if len(items) == 0 {
+		return nil
+	}
+	config := make(map[string]string, len(items))
+	for _, item := range items {
+		config[item.Name] = item.Value
+	}
+	return config
+}
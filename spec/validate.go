@@ -0,0 +1,192 @@
+package spec
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/job"
+)
+
+const dateFormat = "2006-01-02"
+
+// Diagnostic is a problem found in a job spec, located by file and line
+type Diagnostic struct {
+	Path    string
+	Line    int
+	Field   string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s", d.Path, d.Line, d.Message)
+}
+
+// Validate checks the spec has everything optimus needs to schedule the job
+func (f *File) Validate() []Diagnostic {
+	var diags []Diagnostic
+	report := func(message string, keys ...string) {
+		field := ""
+		for i, k := range keys {
+			if i > 0 {
+				field += "."
+			}
+			field += k
+		}
+		diags = append(diags, Diagnostic{Path: f.Path, Line: f.Line(keys...), Field: field, Message: message})
+	}
+
+	j := f.Job
+	if j.Version == 0 {
+		report("version is required", "version")
+	}
+	if j.Name == "" {
+		report("name is required", "name")
+	}
+	if j.Owner == "" {
+		report("owner is required", "owner")
+	}
+
+	start, err := validateDate(j.Schedule.StartDate)
+	if err != nil {
+		report("start_date "+err.Error(), "schedule", "start_date")
+	}
+	if j.Schedule.EndDate != "" {
+		end, err := validateDate(j.Schedule.EndDate)
+		if err != nil {
+			report("end_date "+err.Error(), "schedule", "end_date")
+		} else if !start.IsZero() && end.Before(start) {
+			report("end_date is before start_date", "schedule", "end_date")
+		}
+	}
+
+	if j.Schedule.Interval == "" {
+		report("interval is required", "schedule", "interval")
+	} else if _, err := cron.ParseStandard(j.Schedule.Interval); err != nil {
+		report(fmt.Sprintf("interval %q is not a valid cron expression: %s", j.Schedule.Interval, err), "schedule", "interval")
+	}
+
+	if j.Task.Name == "" {
+		report("task name is required", "task", "name")
+	}
+	w := j.Task.Window
+	if _, err := job.NewDataWindow(w.Size, w.Offset, w.TruncateTo); err != nil {
+		report(err.Error(), "task", "window")
+	}
+
+	for _, d := range j.Dependencies {
+		if d.Job == j.Name && d.Job != "" {
+			report("job depends on itself", "dependencies")
+		}
+	}
+	return diags
+}
+
+// validateDate applies the rules of the start date prompt of the create command
+func validateDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("is required")
+	}
+	date, err := time.Parse(dateFormat, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date of the form %s", value, dateFormat)
+	}
+	if date.Year() < 2000 {
+		return time.Time{}, fmt.Errorf("before 2000 is not allowed")
+	}
+	return date, nil
+}
+
+// ValidateAll validates every file and reports job names used more than once
+func ValidateAll(files []*File) []Diagnostic {
+	var diags []Diagnostic
+	seen := map[string]*File{}
+	for _, f := range files {
+		diags = append(diags, f.Validate()...)
+		if f.Job.Name == "" {
+			continue
+		}
+		if first, ok := seen[f.Job.Name]; ok {
+			diags = append(diags, Diagnostic{
+				Path:    f.Path,
+				Line:    f.Line("name"),
+				Field:   "name",
+				Message: fmt.Sprintf("job %s is also defined in %s", f.Job.Name, first.Path),
+			})
+			continue
+		}
+		seen[f.Job.Name] = f
+	}
+	return diags
+}
+
+// ValidateTask checks the task config and assets against the descriptor of its plugin
+func (f *File) ValidateTask(plugins Plugins) []Diagnostic {
+	task := f.Job.Task
+	if task.Name == "" {
+		return nil
+	}
+	plugin, ok := plugins.Get(task.Name)
+	if !ok {
+		return []Diagnostic{{
+			Path:    f.Path,
+			Line:    f.Line("task", "name"),
+			Field:   "task.name",
+			Message: fmt.Sprintf("task %s is not a known plugin, known plugins are %s", task.Name, strings.Join(plugins.Names(), ", ")),
+		}}
+	}
+
+	var diags []Diagnostic
+	for _, q := range plugin.Questions {
+		if err := q.validate(task.Config[q.Name]); err != nil {
+			diags = append(diags, Diagnostic{
+				Path:    f.Path,
+				Line:    f.Line("task", "config", q.Name),
+				Field:   "task.config." + q.Name,
+				Message: fmt.Sprintf("%s config %s %s", plugin.Name, q.Name, err),
+			})
+		}
+	}
+	for _, asset := range plugin.Assets {
+		if _, ok := f.Assets[asset]; !ok {
+			diags = append(diags, Diagnostic{
+				Path:    f.Path,
+				Line:    f.Line("task", "name"),
+				Field:   "task.name",
+				Message: fmt.Sprintf("%s requires the asset %s in %s", plugin.Name, asset, filepath.Join(f.Dir(), assetsDir)),
+			})
+		}
+	}
+	return diags
+}
+
+// ValidateDependencies checks the dependencies of the files are jobs of the
+// project, dependencies on jobs of other projects are not checked
+func ValidateDependencies(project string, files []*File, jobs map[string]bool) []Diagnostic {
+	var diags []Diagnostic
+	for _, f := range files {
+		for i, d := range f.Job.Dependencies {
+			name := d.Job
+			if p, n, ok := strings.Cut(name, "/"); ok {
+				if p != project {
+					continue
+				}
+				name = n
+			}
+			if name == "" || jobs[name] {
+				continue
+			}
+			diags = append(diags, Diagnostic{
+				Path:    f.Path,
+				Line:    f.Line("dependencies", strconv.Itoa(i), "job"),
+				Field:   "dependencies",
+				Message: fmt.Sprintf("dependency %s is not a job of project %s", d.Job, project),
+			})
+		}
+	}
+	return diags
+}
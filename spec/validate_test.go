@@ -0,0 +1,49 @@
+package spec
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestValidateDependencies(t *testing.T) {
+	const header = "version: 1\nname: sample.report\nowner: bi@example.com\n"
+	jobs := map[string]bool{"sample.orders": true, "sample.report": true}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "jobs of the project",
+			content: header + "dependencies:\n- job: sample.orders\n- job: sample/sample.orders\n",
+		},
+		{
+			name:    "unknown job",
+			content: header + "dependencies:\n- job: sample.orders\n- job: sample.users\n",
+			want:    []string{"6: dependency sample.users is not a job of project sample"},
+		},
+		{
+			name:    "unknown job with the project",
+			content: header + "dependencies:\n- job: sample/sample.users\n",
+			want:    []string{"5: dependency sample/sample.users is not a job of project sample"},
+		},
+		{
+			name:    "job of another project",
+			content: header + "dependencies:\n- job: other/sample.users\n",
+		},
+		{
+			name:    "without job",
+			content: header + "dependencies:\n- type: intra\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := writeSpec(t, tt.content, nil)
+			got := diagnostics(ValidateDependencies("sample", []*File{f}, jobs))
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ValidateDependencies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
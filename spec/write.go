@@ -0,0 +1,134 @@
+package spec
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultIndent is the indentation of documents without nested mappings
+const defaultIndent = 4
+
+// Render encodes job as the content of the file, keeping the comments, the
+// order of keys and the indentation of the document it was loaded from
+func (f *File) Render(job *Job) ([]byte, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(job); err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{node}}
+	if f.node != nil && len(f.node.Content) > 0 {
+		doc.HeadComment = f.node.HeadComment
+		doc.FootComment = f.node.FootComment
+		mergeNode(node, f.node.Content[0])
+	}
+
+	indent := f.indent
+	if indent == 0 {
+		indent = defaultIndent
+	}
+	b := &bytes.Buffer{}
+	enc := yaml.NewEncoder(b)
+	enc.SetIndent(indent)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Update writes job to the file and replaces the job of the file with it
+func (f *File) Update(job *Job) error {
+	content, err := f.Render(job)
+	if err != nil {
+		return err
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(content, node); err != nil {
+		return &ParseError{Path: f.Path, Err: err}
+	}
+	if err := os.WriteFile(f.Path, content, 0o644); err != nil {
+		return err
+	}
+	f.Job = job
+	f.node = node
+	f.content = content
+	return nil
+}
+
+// mergeNode copies the comments and styles of the original node onto the
+// encoded one, keys of mappings keep their original order and new keys follow
+func mergeNode(dst, src *yaml.Node) {
+	dst.HeadComment = src.HeadComment
+	dst.LineComment = src.LineComment
+	dst.FootComment = src.FootComment
+	if dst.Kind != src.Kind {
+		return
+	}
+
+	switch dst.Kind {
+	case yaml.ScalarNode:
+		if dst.Tag == src.Tag {
+			dst.Style = src.Style
+		}
+	case yaml.SequenceNode:
+		dst.Style = src.Style
+		for i := 0; i < len(dst.Content) && i < len(src.Content); i++ {
+			mergeNode(dst.Content[i], src.Content[i])
+		}
+	case yaml.MappingNode:
+		dst.Style = src.Style
+		srcIndex := map[string]int{}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			srcIndex[src.Content[i].Value] = i
+		}
+
+		var kept, added []*yaml.Node
+		order := map[*yaml.Node]int{}
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			key, value := dst.Content[i], dst.Content[i+1]
+			j, ok := srcIndex[key.Value]
+			if !ok {
+				added = append(added, key, value)
+				continue
+			}
+			mergeNode(key, src.Content[j])
+			mergeNode(value, src.Content[j+1])
+			order[key] = j
+			kept = append(kept, key, value)
+		}
+		sortPairs(kept, order)
+		dst.Content = append(kept, added...)
+	}
+}
+
+// sortPairs orders key value pairs by the position of their key in order
+func sortPairs(pairs []*yaml.Node, order map[*yaml.Node]int) {
+	for i := 2; i < len(pairs); i += 2 {
+		for j := i; j >= 2 && order[pairs[j]] < order[pairs[j-2]]; j -= 2 {
+			pairs[j], pairs[j-2] = pairs[j-2], pairs[j]
+			pairs[j+1], pairs[j-1] = pairs[j-1], pairs[j+1]
+		}
+	}
+}
+
+// detectIndent returns the indentation of the first nested key of a document
+func detectIndent(content []byte) int {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,277 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/bulk"
+	"github.com/sbchaos/mirage/internal/ints"
+)
+
+const (
+	bulkListWidth    = 56
+	bulkStatusHeight = 2
+)
+
+// NewBulkModel lets the user pick the jobs an edit is applied to while
+// previewing the changes of each, every job starts selected
+func NewBulkModel(title string, edit bulk.Edit, plan *bulk.Plan) (*bulkModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	selected := map[int]bool{}
+	items := make([]list.Item, len(plan.Targets))
+	for i, t := range plan.Targets {
+		selected[i] = true
+		items[i] = bulkItem{index: i, target: t}
+	}
+
+	m := &bulkModel{
+		width:    width,
+		height:   height,
+		title:    title,
+		summary:  edit.Summary(),
+		plan:     plan,
+		selected: selected,
+	}
+
+	m.jobList = list.New(items, bulkDelegate{selected: selected}, bulkListWidth, ints.Max(height-m.headerHeight()-bulkStatusHeight, 1))
+	m.jobList.SetShowTitle(false)
+	m.jobList.SetShowHelp(false)
+	m.jobList.SetStatusBarItemName("job", "jobs")
+	m.detail = viewport.New(ints.Max(width-bulkListWidth-2, 20), ints.Max(height-m.headerHeight()-bulkStatusHeight, 1))
+	m.updateDetail()
+	return m, nil
+}
+
+type bulkModel struct {
+	width  int
+	height int
+
+	title    string
+	summary  []string
+	plan     *bulk.Plan
+	selected map[int]bool
+
+	confirming bool
+	confirmed  bool
+	current    int
+
+	jobList list.Model
+	detail  viewport.Model
+}
+
+// Ensure that bulkModel fulfils the tea.Model interface.
+var _ tea.Model = (*bulkModel)(nil)
+
+// Selected returns the targets picked by the user once they confirmed, nil
+// when they quit without applying
+func (m *bulkModel) Selected() []*bulk.Target {
+	if !m.confirmed {
+		return nil
+	}
+	var targets []*bulk.Target
+	for i, t := range m.plan.Targets {
+		if m.selected[i] {
+			targets = append(targets, t)
+		}
+	}
+	return targets
+}
+
+func (m *bulkModel) countSelected() int {
+	n := 0
+	for _, ok := range m.selected {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *bulkModel) headerHeight() int {
+	return len(m.summary) + 3
+}
+
+func (m *bulkModel) Init() tea.Cmd {
+	return nil
+}
+
+// updateDetail previews the changes of the job under the cursor
+func (m *bulkModel) updateDetail() {
+	item, ok := m.jobList.SelectedItem().(bulkItem)
+	if !ok {
+		m.current = -1
+		m.detail.SetContent(FeintStyle.Render("No job matches"))
+		return
+	}
+	if item.index == m.current {
+		return
+	}
+	m.current = item.index
+	content := RenderJobDiff(item.target.Diff())
+	if f := item.target.File; f != nil {
+		content += "\n" + FeintStyle.Render(f.Path)
+	}
+	m.detail.SetContent(content)
+	m.detail.GotoTop()
+}
+
+func (m *bulkModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.jobList.SetSize(bulkListWidth, ints.Max(msg.Height-m.headerHeight()-bulkStatusHeight, 1))
+		m.detail.Width = ints.Max(msg.Width-bulkListWidth-2, 20)
+		m.detail.Height = ints.Max(msg.Height-m.headerHeight()-bulkStatusHeight, 1)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		}
+
+		if m.confirming {
+			switch msg.String() {
+			case "y", "enter":
+				m.confirmed = true
+				return m, tea.Quit
+			case "n", "esc", "q":
+				m.confirming = false
+			}
+			return m, nil
+		}
+		if m.jobList.SettingFilter() {
+			break
+		}
+
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case " ", "x":
+			if item, ok := m.jobList.SelectedItem().(bulkItem); ok {
+				m.selected[item.index] = !m.selected[item.index]
+			}
+			return m, nil
+		case "a":
+			// select every visible job, or clear them when all are selected
+			visible := m.jobList.VisibleItems()
+			all := true
+			for _, it := range visible {
+				if !m.selected[it.(bulkItem).index] {
+					all = false
+				}
+			}
+			for _, it := range visible {
+				m.selected[it.(bulkItem).index] = !all
+			}
+			return m, nil
+		case "enter":
+			if m.countSelected() > 0 {
+				m.confirming = true
+			}
+			return m, nil
+		case "J", "pgdown":
+			m.detail.LineDown(1)
+			return m, nil
+		case "K", "pgup":
+			m.detail.LineUp(1)
+			return m, nil
+		}
+	}
+
+	m.jobList, cmd = m.jobList.Update(msg)
+	m.updateDetail()
+	return m, cmd
+}
+
+func (m *bulkModel) View() string {
+	b := &strings.Builder{}
+	b.WriteString(m.renderHeader())
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(bulkListWidth).Render(m.jobList.View()),
+		lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(Feint).
+			PaddingLeft(1).
+			Render(m.detail.View()),
+	))
+	b.WriteString("\n")
+	b.WriteString(m.renderStatus())
+	return b.String()
+}
+
+func (m *bulkModel) renderHeader() string {
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render(m.title) + "  " +
+		FeintStyle.Render(fmt.Sprintf("%d of %s selected", m.countSelected(), plural(len(m.plan.Targets), "job"))))
+	if m.plan.Unchanged > 0 {
+		b.WriteString(FeintStyle.Render(fmt.Sprintf(", %d already up to date", m.plan.Unchanged)))
+	}
+	b.WriteString("\n")
+	for _, line := range m.summary {
+		b.WriteString("  " + diffModifiedStyle.Render("~") + " " + line + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m *bulkModel) renderStatus() string {
+	if m.confirming {
+		return BoldStyle.Render(fmt.Sprintf("Apply the changes to %s? ", plural(m.countSelected(), "job"))) +
+			FeintStyle.Render("y: apply  n: back")
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar("Selected", fmt.Sprintf("%d/%d", m.countSelected(), len(m.plan.Targets))),
+		FeintStyle.Render("space: toggle  a: toggle all  /: filter  J/K: scroll changes  enter: apply  q: quit"),
+	)
+}
+
+type bulkItem struct {
+	index  int
+	target *bulk.Target
+}
+
+func (i bulkItem) FilterValue() string {
+	return i.target.Name() + " " + i.target.Namespace + " " + i.target.Before.Owner
+}
+
+// bulkDelegate renders a job with its selection and the number of changed fields
+type bulkDelegate struct {
+	selected map[int]bool
+}
+
+func (d bulkDelegate) Height() int                               { return 1 }
+func (d bulkDelegate) Spacing() int                              { return 0 }
+func (d bulkDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d bulkDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	i, ok := item.(bulkItem)
+	if !ok {
+		return
+	}
+
+	cursor := "  "
+	nameStyle := TextStyle
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+		nameStyle = BoldStyle.Copy().Foreground(Teal)
+	}
+	check := FeintStyle.Render("[ ]")
+	if d.selected[i.index] {
+		check = lipgloss.NewStyle().Foreground(Green).Render("[x]")
+	}
+
+	fmt.Fprint(w, cursor+check+" "+nameStyle.Render(column(i.target.Name(), bulkListWidth-22))+
+		FeintStyle.Render(column(i.target.Namespace, 12)+fmt.Sprintf("%d", len(i.target.Changes))))
+}
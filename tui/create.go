@@ -13,6 +13,11 @@ import (
 	humancron "github.com/lnquy/cron"
 	"github.com/robfig/cron/v3"
 	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/congestion"
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/job"
+	"github.com/sbchaos/mirage/spec"
 )
 
 type state int
@@ -22,6 +27,7 @@ const (
 	stateAskOwner
 	stateAskTrigger
 	stateAskStartDate
+	stateAskTimezone
 	stateAskCron
 	stateAskWindow
 	stateAskTask
@@ -34,14 +40,19 @@ const (
 	startDatePlace  = "Specify the start date of schedule?"
 	cronPlaceholder = "Specify the cron schedule, eg. '0 2 * * *' for 2AM every day."
 
+	// schedulerTimezone is the timezone the scheduler runs jobs in unless
+	// their interval has a CRON_TZ= prefix
+	schedulerTimezone = "UTC"
+
 	dateFormat = "2006-01-02"
 
 	triggerManual    = "Manual"
 	triggerScheduled = "Scheduled"
 )
 
-// NewCreateModel renders the UI for creating a new job
-func NewCreateModel() (*createModel, error) {
+// NewCreateModel renders the UI for creating a new job, the schedules of jobs
+// are used to propose less congested minutes for the cron schedule
+func NewCreateModel(jobs []congestion.Job) (*createModel, error) {
 	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
 
 	f := &createModel{
@@ -77,12 +88,22 @@ func NewCreateModel() (*createModel, error) {
 	}, list.NewDefaultDelegate(), width, lineHeight)
 	f.taskList.Title = "List of installed task"
 
+	f.timezoneList = list.New(timezoneItems(), list.NewDefaultDelegate(), width, lineHeight)
+
 	f.textinput.Focus()
 	f.textinput.CharLimit = 256
 	f.textinput.Width = width
 	f.textinput.Prompt = "→  "
 
+	if len(jobs) > 0 {
+		start := congestion.PeriodStart(time.Now().UTC(), congestion.PeriodWeek)
+		f.congestion, _ = congestion.New(jobs, start, congestion.PeriodWeek, time.Minute)
+	}
+
 	hideListChrome(&f.triggerList)
+	f.timezoneList.SetShowHelp(false)
+	f.timezoneList.SetShowStatusBar(false)
+	f.timezoneList.SetShowTitle(false)
 
 	return f, nil
 }
@@ -105,19 +126,30 @@ type createModel struct {
 	startDate    time.Time
 	startDateErr error
 
+	// timezone is the IANA name of the timezone the job is scheduled in, a
+	// CRON_TZ= prefix of the cron expression takes precedence
+	timezone string
+
 	cron      string
 	humanCron string
 	cronError error
 	nextCron  time.Time
 
+	// congestion counts the runs of existing jobs per minute of the week,
+	// cronRuns is the busiest minute the schedule shares with them
+	congestion  *congestion.Heatmap
+	cronRuns    int
+	suggestions []congestion.Suggestion
+
 	window string
 
 	taskName string
 
-	windowView  *DataWindow
-	textinput   textinput.Model
-	triggerList list.Model
-	taskList    list.Model
+	windowView   *DataWindow
+	textinput    textinput.Model
+	triggerList  list.Model
+	taskList     list.Model
+	timezoneList list.Model
 }
 
 // Ensure that createModel fulfils the tea.Model interface.
@@ -125,6 +157,7 @@ var _ tea.Model = (*createModel)(nil)
 
 func (c *createModel) Init() tea.Cmd {
 	c.windowView, _ = NewDataWindow(c.width, c.height-25, time.Now())
+	c.windowView.Init()
 	return tea.Batch()
 }
 
@@ -151,6 +184,7 @@ func (c *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		c.width = msg.Width
 		c.height = msg.Height
+		c.timezoneList.SetSize(msg.Width, ints.Max(msg.Height-25, 5))
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
@@ -158,7 +192,7 @@ func (c *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return c, tea.Quit
 		}
 
-		if msg.String() == "q" {
+		if msg.String() == "q" && !c.timezoneList.SettingFilter() {
 			c.state = stateDone
 			return c, tea.Quit
 		}
@@ -175,6 +209,8 @@ func (c *createModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return c.updateTrigger(msg)
 		case stateAskStartDate:
 			return c.updateStartDate(msg)
+		case stateAskTimezone:
+			return c.updateTimezone(msg)
 		case stateAskCron:
 			return c.updateCron(msg)
 		case stateAskWindow:
@@ -293,11 +329,28 @@ func (c *createModel) updateStartDate(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyEnter && c.startDateErr == nil {
-		c.textinput.Placeholder = cronPlaceholder
 		c.textinput.SetValue("")
-		c.state = stateAskCron
+		c.state = stateAskTimezone
+	}
+
+	return c, cmd
+}
+
+func (c *createModel) updateTimezone(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	// enter applies the filter while searching and selects the timezone after
+	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyEnter && !c.timezoneList.SettingFilter() {
+		if item := c.timezoneList.SelectedItem(); item != nil {
+			c.timezone = item.FilterValue()
+			c.textinput.Placeholder = cronPlaceholder
+			c.textinput.SetValue("")
+			c.state = stateAskCron
+		}
+		return c, nil
 	}
 
+	c.timezoneList, cmd = c.timezoneList.Update(msg)
 	return c, cmd
 }
 func (c *createModel) updateWindow(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -318,24 +371,38 @@ func (c *createModel) updateWindow(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (c *createModel) updateCron(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	c.textinput.Placeholder = cronPlaceholder
+	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyTab && len(c.suggestions) > 0 {
+		// take the least congested suggestion
+		c.textinput.SetValue(c.suggestedCron(c.suggestions[0]))
+		c.textinput.CursorEnd()
+	} else {
+		c.textinput, cmd = c.textinput.Update(msg)
+	}
 	c.cron = c.textinput.Value()
-	c.textinput, cmd = c.textinput.Update(msg)
 
 	schedule, err := cron.ParseStandard(c.cron)
 	if err != nil {
 		c.cronError = fmt.Errorf("Cron expression is not valid")
 		c.humanCron = ""
 		c.nextCron = time.Time{}
+		c.cronRuns, c.suggestions = 0, nil
 	} else {
 		c.cronError = nil
-		if desc, err := humancron.NewDescriptor(); err == nil {
-			c.humanCron, _ = desc.ToDescription(c.cron, humancron.Locale_en)
-		}
-		start := time.Now()
-		if start.Before(c.startDate) {
-			start = c.startDate
+		c.humanCron = DescribeCron(c.cron, humancron.Locale_en)
+
+		// runs are computed in the zone of the job, the schedule converts
+		// to the zone of its CRON_TZ= prefix itself
+		loc := c.cronLocation()
+		start := time.Now().In(loc)
+		if first := time.Date(c.startDate.Year(), c.startDate.Month(), c.startDate.Day(), 0, 0, 0, 0, loc); start.Before(first) {
+			start = first
 		}
 		c.nextCron = schedule.Next(start)
+
+		if c.congestion != nil {
+			c.cronRuns, _ = c.congestion.Score(c.interval())
+			c.suggestions, _ = c.congestion.Suggest(c.interval(), 3)
+		}
 	}
 
 	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyEnter && c.cron != "" && c.cronError == nil {
@@ -363,6 +430,8 @@ func (c *createModel) View() string {
 		b.WriteString(c.renderTrigger())
 	case stateAskStartDate:
 		b.WriteString(c.renderStartDate())
+	case stateAskTimezone:
+		b.WriteString(c.renderTimezone())
 	case stateAskCron:
 		b.WriteString(c.renderCron())
 	case stateAskWindow:
@@ -413,6 +482,10 @@ func (c *createModel) renderState() string {
 		write("Start Date: " + BoldStyle.Render(c.startDate.Format(dateFormat)) + "\n")
 	}
 
+	if c.timezone != "" && c.state != stateAskTimezone {
+		write("Timezone: " + BoldStyle.Render(c.timezone) + "\n")
+	}
+
 	if c.cron != "" && c.state != stateAskCron {
 		write("Cron schedule: " + BoldStyle.Render(c.cron) + " (" + c.humanCron + ")\n")
 	}
@@ -477,16 +550,144 @@ func (c *createModel) renderCron() string {
 	}
 	if !c.nextCron.IsZero() {
 		b.WriteString("\n")
-		dur := humanizeDuration(time.Until(c.nextCron))
+		dur := humanizeDuration(time.Until(c.nextCron).Truncate(time.Minute))
 
 		if c.humanCron != "" {
 			b.WriteString(TextStyle.Copy().Foreground(Feint).Bold(true).Render(c.humanCron) + ". ")
 		}
-		b.WriteString(TextStyle.Copy().Foreground(Feint).Render("This would next run at: " + c.nextCron.Format(time.RFC3339) + " (in " + dur + ")\n"))
+		next := c.nextCron.Format(cronRunFormat)
+		if zone, _ := c.nextCron.Zone(); zone != c.nextCron.Location().String() {
+			next += " (" + c.nextCron.Location().String() + ")"
+		}
+		if local := c.nextCron.In(time.Local); local.Format(cronRunFormat) != c.nextCron.Format(cronRunFormat) {
+			next += ", " + local.Format(cronRunFormat) + " local time"
+		}
+		b.WriteString(TextStyle.Copy().Foreground(Feint).Render("This would next run at: "+next+" (in "+dur+")") + "\n")
+	}
+	if c.cronError == nil && c.cronRuns > 0 && len(c.suggestions) > 0 {
+		var proposals []string
+		for _, s := range c.suggestions {
+			proposals = append(proposals, BoldStyle.Render(c.suggestedCron(s))+fmt.Sprintf(" (%s)", plural(s.Runs, "run")))
+		}
+		b.WriteString(RenderWarning(fmt.Sprintf("%s of other jobs start in the same minute", plural(c.cronRuns, "run"))) + "\n")
+		b.WriteString(FeintStyle.Render("Less congested: ") + strings.Join(proposals, FeintStyle.Render(", ")) +
+			FeintStyle.Render("  tab: use the first") + "\n")
+	}
+	return b.String()
+}
+
+// suggestedCron returns the cron schedule of a suggestion as the user would
+// type it, without the timezone prefix unless they typed one
+func (c *createModel) suggestedCron(s congestion.Suggestion) string {
+	if tz, _ := job.SplitTimezone(c.cron); tz != "" {
+		return s.Interval
 	}
+	_, expr := job.SplitTimezone(s.Interval)
+	return expr
+}
+
+func (c *createModel) renderTimezone() string {
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render(fmt.Sprintf("%d. Timezone of the schedule:", c.questions)) + "\n")
+	b.WriteString(FeintStyle.Render("/ to search, a CRON_TZ= prefix of the cron schedule takes precedence") + "\n\n")
+	b.WriteString(c.timezoneList.View())
 	return b.String()
 }
 
+// cronLocation returns the timezone the cron schedule is evaluated in
+func (c *createModel) cronLocation() *time.Location {
+	name := c.timezone
+	if tz, _ := job.SplitTimezone(c.cron); tz != "" {
+		name = tz
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "" {
+		return time.UTC
+	}
+	return loc
+}
+
+// interval returns the cron schedule of the job, prefixed with the timezone
+// when the job is not scheduled in the timezone of the scheduler
+func (c *createModel) interval() string {
+	if tz, _ := job.SplitTimezone(c.cron); tz != "" || c.timezone == "" || c.timezone == schedulerTimezone {
+		return c.cron
+	}
+	return "CRON_TZ=" + c.timezone + " " + c.cron
+}
+
+// Done reports whether every question was answered
+func (c *createModel) Done() bool {
+	return c.taskName != ""
+}
+
+// Job returns the spec of the job described by the answers
+func (c *createModel) Job() *spec.Job {
+	j := &spec.Job{
+		Version: 1,
+		Name:    c.name,
+		Owner:   c.owner,
+		Task:    spec.Task{Name: strings.ToLower(c.taskName)},
+	}
+	if c.triggerType == triggerScheduled {
+		j.Schedule = spec.Schedule{StartDate: c.startDate.Format(dateFormat), Interval: c.interval()}
+	}
+	if w := c.windowView; w != nil && w.size > 0 {
+		j.Task.Window = spec.Window{
+			Size:       windowDuration(w.size),
+			Offset:     windowDuration(w.offset),
+			TruncateTo: w.truncateTo,
+		}
+	}
+	return j
+}
+
+// windowDuration formats the size or the offset of a window in hours, with
+// the minutes of offsets moved by less than an hour
+func windowDuration(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return strings.TrimSuffix(d.String(), "0s")
+}
+
+// timezoneItems lists the timezones to schedule a job in, the timezone of
+// the scheduler and of the user first
+func timezoneItems() []list.Item {
+	now := time.Now()
+	item := func(name, description string) list.Item {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return listItem{name: name, description: description}
+		}
+		zone, offset := now.In(loc).Zone()
+		d := fmt.Sprintf("%s, UTC%+03d:%02d", zone, offset/3600, abs(offset%3600/60))
+		if description != "" {
+			d = description + ", " + d
+		}
+		return listItem{name: name, description: d}
+	}
+
+	items := []list.Item{item(schedulerTimezone, "timezone of the scheduler")}
+	local := job.LocalTimezone()
+	if local != schedulerTimezone {
+		items = append(items, item(local, "your timezone"))
+	}
+	for _, name := range job.Timezones() {
+		if name != schedulerTimezone && name != local {
+			items = append(items, item(name, ""))
+		}
+	}
+	return items
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
 func humanizeDuration(duration time.Duration) string {
 	days := int64(duration.Hours() / 24)
 	hours := int64(math.Mod(duration.Hours(), 24))
@@ -0,0 +1,340 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	humancron "github.com/lnquy/cron"
+	"github.com/robfig/cron/v3"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/job"
+)
+
+const (
+	cronLabelWidth    = 14
+	cronRelativeWidth = 14
+	cronGapWidth      = 10
+	cronRunFormat     = "Mon 2006-01-02 15:04 MST"
+)
+
+// cronLocales are cycled through by the cron explorer
+var cronLocales = []humancron.LocaleType{
+	humancron.Locale_en, humancron.Locale_de, humancron.Locale_es, humancron.Locale_fr,
+	humancron.Locale_it, humancron.Locale_nl, humancron.Locale_pt_BR, humancron.Locale_ja,
+	humancron.Locale_zh_CN,
+}
+
+// cronDescriptors are the expressions equivalent to the descriptors of
+// robfig/cron, which lnquy/cron cannot describe
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// DescribeCron describes a cron expression in a language, it is empty when
+// the expression cannot be described
+func DescribeCron(expr string, locale humancron.LocaleType) string {
+	_, expr = job.SplitTimezone(expr)
+	if every := strings.TrimPrefix(expr, "@every "); every != expr {
+		if d, err := time.ParseDuration(strings.TrimSpace(every)); err == nil {
+			return "Every " + job.FormatGap(d)
+		}
+		return ""
+	}
+	if e, ok := cronDescriptors[expr]; ok {
+		expr = e
+	}
+	desc, err := humancron.NewDescriptor(humancron.SetLocales(locale))
+	if err != nil {
+		return ""
+	}
+	human, err := desc.ToDescription(expr, locale)
+	if err != nil {
+		return ""
+	}
+	return human
+}
+
+// CronOptions is the expression explored and how its runs are shown
+type CronOptions struct {
+	Expression string
+	Timezone   *time.Location
+	Locale     humancron.LocaleType
+	Count      int
+}
+
+// RenderCron describes a cron expression with its next and previous runs,
+// the gaps between them and warnings about the schedule
+func RenderCron(opts CronOptions, now time.Time) (string, error) {
+	schedule, err := cron.ParseStandard(opts.Expression)
+	if err != nil {
+		return "", err
+	}
+	loc := opts.Timezone
+	if tz, _ := job.SplitTimezone(opts.Expression); tz != "" {
+		// the runs of expressions with a timezone are shown in it
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return "", err
+		}
+	}
+	now = now.In(loc)
+
+	b := &strings.Builder{}
+	write := func(label, value string) {
+		b.WriteString(FeintStyle.Render(column(label, cronLabelWidth)))
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+
+	if desc := DescribeCron(opts.Expression, opts.Locale); desc != "" {
+		write("Description", BoldStyle.Render(desc))
+	}
+	zone, _ := now.Zone()
+	write("Timezone", loc.String()+FeintStyle.Render(" ("+zone+")"))
+
+	next := job.NextRuns(schedule, now, opts.Count)
+	previous := job.PreviousRuns(schedule, now, opts.Count)
+	if _, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		// @every counts from when the scheduler starts, it has no past runs
+		previous = nil
+	}
+
+	b.WriteString("\n")
+	if loc.String() != time.Local.String() {
+		b.WriteString(BoldStyle.Render(column("Next runs", 2+len(cronRunFormat)+2+cronRelativeWidth+cronGapWidth)) +
+			FeintStyle.Render(fmt.Sprintf("local (%s)", localZone(now))) + "\n")
+	} else {
+		b.WriteString(BoldStyle.Render("Next runs") + "\n")
+	}
+	if len(next) == 0 {
+		b.WriteString(FeintStyle.Render("  none within the next five years") + "\n")
+	}
+	for i, t := range next {
+		prev := time.Time{}
+		if i > 0 {
+			prev = next[i-1]
+		} else if len(previous) > 0 {
+			prev = previous[len(previous)-1]
+		}
+		b.WriteString(renderCronRun(t, prev, "in "+formatSLADuration(t.Sub(now)), loc))
+	}
+
+	b.WriteString("\n" + BoldStyle.Render("Previous runs") + "\n")
+	if _, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		b.WriteString(FeintStyle.Render("  @every counts from when the scheduler starts") + "\n")
+	} else if len(previous) == 0 {
+		b.WriteString(FeintStyle.Render("  none within the last five years") + "\n")
+	}
+	for i := len(previous) - 1; i >= 0; i-- {
+		prev := time.Time{}
+		if i > 0 {
+			prev = previous[i-1]
+		}
+		b.WriteString(renderCronRun(previous[i], prev, formatSLADuration(now.Sub(previous[i]))+" ago", loc))
+	}
+
+	runs := append(append([]time.Time{}, previous...), next...)
+	if gaps := job.DistinctGaps(runs); len(gaps) > 0 {
+		b.WriteString("\n")
+		if len(gaps) == 1 {
+			write("Gaps", "every "+job.FormatGap(gaps[0]))
+		} else {
+			names := make([]string, len(gaps))
+			for i, g := range gaps {
+				names[i] = job.FormatGap(g)
+			}
+			write("Gaps", fmt.Sprintf("%s to %s", names[0], names[len(names)-1])+
+				FeintStyle.Render(fmt.Sprintf(" (%d distinct: %s)", len(gaps), strings.Join(names, ", "))))
+		}
+	}
+
+	if warnings := job.ScheduleWarnings(opts.Expression, schedule, now); len(warnings) > 0 {
+		b.WriteString("\n" + BoldStyle.Render("Warnings") + "\n")
+		for _, w := range warnings {
+			b.WriteString(lipgloss.NewStyle().Foreground(Orange).Render("  ! ") + w + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func localZone(t time.Time) string {
+	zone, _ := t.In(time.Local).Zone()
+	return zone
+}
+
+// renderCronRun renders a run with the time relative to now, the gap to the
+// run before it and the run in the local timezone when it differs
+func renderCronRun(t, prev time.Time, relative string, loc *time.Location) string {
+	gap := ""
+	if !prev.IsZero() {
+		gap = "+" + job.FormatGap(t.Sub(prev))
+	}
+	line := "  " + column(t.Format(cronRunFormat), len(cronRunFormat)+2) +
+		FeintStyle.Render(column(relative, cronRelativeWidth)+column(gap, cronGapWidth))
+	if loc.String() != time.Local.String() {
+		line += FeintStyle.Render(t.In(time.Local).Format(cronRunFormat))
+	}
+	return line + "\n"
+}
+
+// NewCronModel lets the user type a cron expression and a timezone and see
+// how the schedule behaves
+func NewCronModel(opts CronOptions) (*cronModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	m := &cronModel{
+		width:  width,
+		height: height,
+		opts:   opts,
+		expr:   textinput.New(),
+		tz:     textinput.New(),
+	}
+	for i, l := range cronLocales {
+		if l == opts.Locale {
+			m.locale = i
+		}
+	}
+
+	m.expr.Prompt = "→  "
+	m.expr.Placeholder = "0 2 * * *"
+	m.expr.CharLimit = 256
+	m.expr.SetValue(opts.Expression)
+	m.expr.Focus()
+
+	m.tz.Prompt = "→  "
+	m.tz.Placeholder = "Local"
+	m.tz.CharLimit = 64
+	m.tz.SetValue(opts.Timezone.String())
+	m.update()
+	return m, nil
+}
+
+type cronModel struct {
+	width  int
+	height int
+
+	opts   CronOptions
+	locale int
+
+	expr textinput.Model
+	tz   textinput.Model
+
+	report string
+	err    error
+}
+
+// Ensure that cronModel fulfils the tea.Model interface.
+var _ tea.Model = (*cronModel)(nil)
+
+func (m *cronModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// update renders the report of the expression and timezone as typed
+func (m *cronModel) update() {
+	m.report, m.err = "", nil
+
+	m.opts.Expression = strings.TrimSpace(m.expr.Value())
+	if m.opts.Expression == "" {
+		return
+	}
+	tz := strings.TrimSpace(m.tz.Value())
+	if tz == "" {
+		tz = "Local"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		m.err = fmt.Errorf("unknown timezone %s", tz)
+		return
+	}
+	m.opts.Timezone = loc
+	if m.report, err = RenderCron(m.opts, time.Now()); err != nil {
+		m.err = err
+	}
+}
+
+func (m *cronModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash, tea.KeyEsc:
+			return m, tea.Quit
+		case tea.KeyTab, tea.KeyShiftTab:
+			if m.expr.Focused() {
+				m.expr.Blur()
+				m.tz.Focus()
+				m.tz.CursorEnd()
+			} else {
+				m.tz.Blur()
+				m.expr.Focus()
+				m.expr.CursorEnd()
+			}
+			return m, nil
+		case tea.KeyCtrlL:
+			m.locale = (m.locale + 1) % len(cronLocales)
+			m.opts.Locale = cronLocales[m.locale]
+			m.update()
+			return m, nil
+		case tea.KeyUp:
+			m.opts.Count++
+			m.update()
+			return m, nil
+		case tea.KeyDown:
+			if m.opts.Count > 1 {
+				m.opts.Count--
+				m.update()
+			}
+			return m, nil
+		}
+	}
+
+	if m.expr.Focused() {
+		m.expr, cmd = m.expr.Update(msg)
+	} else {
+		m.tz, cmd = m.tz.Update(msg)
+	}
+	m.update()
+	return m, cmd
+}
+
+func (m *cronModel) View() string {
+	b := &strings.Builder{}
+	b.WriteString("\n")
+	b.WriteString(BoldStyle.Render("Cron expression") + "\n")
+	b.WriteString(m.expr.View() + "\n")
+	b.WriteString(BoldStyle.Render("Timezone") + "\n")
+	b.WriteString(m.tz.View() + "\n\n")
+
+	switch {
+	case m.err != nil:
+		b.WriteString(RenderWarning(m.err.Error()) + "\n")
+	case m.report != "":
+		b.WriteString(m.report)
+	default:
+		b.WriteString(FeintStyle.Render("Type an expression like '0 2 * * *', 'CRON_TZ=Asia/Jakarta 30 6 * * 1-5' or '@hourly'") + "\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar("Locale", string(m.opts.Locale), "Runs", fmt.Sprintf("%d", m.opts.Count)),
+		FeintStyle.Render("tab: switch field  ctrl+l: locale  ↑/↓: runs  esc: quit"),
+	))
+	return b.String()
+}
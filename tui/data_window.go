@@ -235,25 +235,16 @@ func (e *DataWindow) renderHeader() string {
 }
 
 func (e *DataWindow) renderStatus() string {
-	statusStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFDF5")).
-		Background(lipgloss.Color("#6124DF")).
-		Padding(0, 1)
-
-	encodingStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#FFFDF5")).
-		Background(lipgloss.Color("#A550DF")).
-		Padding(0, 1).
-		MarginRight(1).
+	encodingStyle := statusValueStyle.Copy().
 		Align(lipgloss.Right).
 		Width(10)
 
 	return lipgloss.JoinHorizontal(lipgloss.Top,
-		statusStyle.Render("Size"),
+		statusKeyStyle.Render("Size"),
 		encodingStyle.Render(e.size.String()),
-		statusStyle.Render("Offset"),
+		statusKeyStyle.Render("Offset"),
 		encodingStyle.Render(e.offset.String()),
-		statusStyle.Render("TruncateTo"),
+		statusKeyStyle.Render("TruncateTo"),
 		encodingStyle.Render(e.truncateTo),
 	)
 }
@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+// deployConcurrency is the number of jobs deployed at the same time
+const deployConcurrency = 4
+
+type deployState int
+
+const (
+	deployPending deployState = iota
+	deployRunning
+	deploySuccess
+	deployFailed
+)
+
+// DeployJob is a job spec to deploy into a namespace
+type DeployJob struct {
+	Namespace string
+	Spec      optimus.JobSpec
+}
+
+type deployItem struct {
+	job     DeployJob
+	state   deployState
+	err     error
+	elapsed time.Duration
+}
+
+type deployDoneMsg struct {
+	index   int
+	err     error
+	elapsed time.Duration
+}
+
+// NewDeployModel deploys the jobs to the project and reports the outcome of each
+func NewDeployModel(client *optimus.Client, project string, jobs []DeployJob) (*deployModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	items := make([]*deployItem, len(jobs))
+	for i, j := range jobs {
+		items[i] = &deployItem{job: j}
+	}
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	return &deployModel{
+		width:    width,
+		height:   height,
+		client:   client,
+		project:  project,
+		items:    items,
+		spinner:  s,
+		progress: progress.New(progress.WithSolidFill(string(Green)), progress.WithWidth(40)),
+	}, nil
+}
+
+type deployModel struct {
+	width  int
+	height int
+
+	client  *optimus.Client
+	project string
+
+	items   []*deployItem
+	next    int
+	done    int
+	started time.Time
+
+	spinner  spinner.Model
+	progress progress.Model
+}
+
+// Ensure that deployModel fulfils the tea.Model interface.
+var _ tea.Model = (*deployModel)(nil)
+
+func (m *deployModel) Init() tea.Cmd {
+	m.started = time.Now()
+	if len(m.items) == 0 {
+		return tea.Quit
+	}
+
+	cmds := []tea.Cmd{m.spinner.Tick}
+	for i := 0; i < deployConcurrency; i++ {
+		cmds = append(cmds, m.deployNext())
+	}
+	return tea.Batch(cmds...)
+}
+
+// deployNext starts the deployment of the next pending job
+func (m *deployModel) deployNext() tea.Cmd {
+	if m.next >= len(m.items) {
+		return nil
+	}
+	index := m.next
+	item := m.items[index]
+	item.state = deployRunning
+	m.next++
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		start := time.Now()
+		err := m.client.DeployJobSpec(ctx, m.project, item.job.Namespace, item.job.Spec)
+		return deployDoneMsg{index: index, err: err, elapsed: time.Since(start)}
+	}
+}
+
+// Failed returns the number of jobs which could not be deployed
+func (m *deployModel) Failed() int {
+	failed := 0
+	for _, item := range m.items {
+		if item.state != deploySuccess {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (m *deployModel) finished() bool {
+	return m.done == len(m.items)
+}
+
+func (m *deployModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case deployDoneMsg:
+		item := m.items[msg.index]
+		item.err = msg.err
+		item.elapsed = msg.elapsed
+		item.state = deploySuccess
+		if msg.err != nil {
+			item.state = deployFailed
+		}
+		m.done++
+		if m.finished() {
+			return m, tea.Quit
+		}
+		return m, m.deployNext()
+	case spinner.TickMsg:
+		if m.finished() {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m *deployModel) View() string {
+	b := &strings.Builder{}
+	b.WriteString("\n")
+	b.WriteString(BoldStyle.Render(fmt.Sprintf("Deploying %s to %s", plural(len(m.items), "job"), m.project)) + "\n\n")
+
+	for _, item := range m.visibleItems() {
+		b.WriteString(m.renderItem(item))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	percent := 0.0
+	if len(m.items) > 0 {
+		percent = float64(m.done) / float64(len(m.items))
+	}
+	b.WriteString(m.progress.ViewAs(percent) + fmt.Sprintf("  %d/%d", m.done, len(m.items)) + "\n")
+
+	if m.finished() {
+		b.WriteString("\n")
+		b.WriteString(m.renderSummary())
+	}
+	return b.String()
+}
+
+// visibleItems returns the jobs which fit on the screen, once the deployment
+// finished only the failures are listed with the summary
+func (m *deployModel) visibleItems() []*deployItem {
+	var visible []*deployItem
+	for _, item := range m.items {
+		if m.finished() {
+			if item.state == deployFailed {
+				visible = append(visible, item)
+			}
+			continue
+		}
+		if item.state != deployPending {
+			visible = append(visible, item)
+		}
+	}
+
+	limit := ints.Max(m.height-10, 5)
+	if !m.finished() && len(visible) > limit {
+		visible = visible[len(visible)-limit:]
+	}
+	return visible
+}
+
+func (m *deployModel) renderItem(item *deployItem) string {
+	name := column(item.job.Spec.Name, nameColumnWidth)
+	namespace := FeintStyle.Render(column(item.job.Namespace, taskColumnWidth))
+
+	switch item.state {
+	case deployRunning:
+		return " " + m.spinner.View() + " " + name + namespace
+	case deploySuccess:
+		return lipgloss.NewStyle().Foreground(Green).Render(" ✓ ") + name + namespace +
+			FeintStyle.Render(item.elapsed.Round(time.Millisecond).String())
+	case deployFailed:
+		msg := truncate.StringWithTail(item.err.Error(), uint(ints.Max(m.width-nameColumnWidth-taskColumnWidth-6, 20)), "…")
+		return lipgloss.NewStyle().Foreground(Red).Render(" ✗ ") + name + namespace +
+			lipgloss.NewStyle().Foreground(Red).Render(msg)
+	}
+	return "   " + name + namespace
+}
+
+func (m *deployModel) renderSummary() string {
+	failed := m.Failed()
+	elapsed := time.Since(m.started).Round(time.Millisecond)
+	deployed := len(m.items) - failed
+
+	summary := lipgloss.NewStyle().Foreground(Green).Render(fmt.Sprintf("%d deployed", deployed))
+	if failed > 0 {
+		summary += ", " + lipgloss.NewStyle().Foreground(Red).Render(fmt.Sprintf("%d failed", failed))
+	}
+	return BoldStyle.Render("Done") + " " + summary + FeintStyle.Render(" in "+elapsed.String()) + "\n"
+}
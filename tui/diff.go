@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/spec"
+)
+
+const (
+	diffListWidth    = 44
+	diffHeaderHeight = 3
+	diffStatusHeight = 2
+)
+
+var (
+	diffAddedStyle    = TextStyle.Copy().Foreground(Green)
+	diffRemovedStyle  = TextStyle.Copy().Foreground(Red)
+	diffModifiedStyle = TextStyle.Copy().Foreground(Orange)
+)
+
+// diffSymbol returns the marker of a job or field status, coloured
+func diffSymbol(status string) string {
+	switch status {
+	case spec.ChangeAdded:
+		return diffAddedStyle.Render("+")
+	case spec.ChangeRemoved:
+		return diffRemovedStyle.Render("-")
+	case spec.ChangeModified:
+		return diffModifiedStyle.Render("~")
+	}
+	return FeintStyle.Render("=")
+}
+
+// RenderJobDiff renders the changed fields of a job, values spanning several
+// lines are shown as unified diffs
+func RenderJobDiff(d spec.JobDiff) string {
+	b := &strings.Builder{}
+	b.WriteString(diffSymbol(d.Status) + " " + BoldStyle.Render(d.Name) + FeintStyle.Render("  "+d.Namespace+", "+d.Status) + "\n")
+
+	for _, c := range d.Changes {
+		b.WriteString("    " + diffSymbol(c.Kind) + " " + c.Field)
+		if c.Unified != "" {
+			b.WriteString("\n")
+			for _, line := range strings.Split(strings.TrimSuffix(c.Unified, "\n"), "\n") {
+				b.WriteString("        " + renderDiffLine(line) + "\n")
+			}
+			continue
+		}
+
+		switch c.Kind {
+		case spec.ChangeAdded:
+			b.WriteString(": " + diffAddedStyle.Render(c.Local))
+		case spec.ChangeRemoved:
+			b.WriteString(": " + diffRemovedStyle.Render(c.Remote))
+		default:
+			b.WriteString(": " + diffRemovedStyle.Render(c.Remote) + " → " + diffAddedStyle.Render(c.Local))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return FeintStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return diffAddedStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return diffRemovedStyle.Render(line)
+	}
+	return line
+}
+
+// NewDiffModel browses the differences between local specs and the server
+func NewDiffModel(title string, diffs []spec.JobDiff) (*diffModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	l := list.New(nil, diffDelegate{}, diffListWidth, height-diffHeaderHeight-diffStatusHeight)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	l.SetStatusBarItemName("job", "jobs")
+
+	m := &diffModel{
+		width:   width,
+		height:  height,
+		title:   title,
+		diffs:   diffs,
+		jobList: l,
+		detail:  viewport.New(ints.Max(width-diffListWidth-2, 20), height-diffHeaderHeight-diffStatusHeight),
+	}
+	m.refreshItems()
+	return m, nil
+}
+
+type diffModel struct {
+	width  int
+	height int
+
+	title string
+	diffs []spec.JobDiff
+
+	showUnchanged bool
+	selected      string
+
+	jobList list.Model
+	detail  viewport.Model
+}
+
+// Ensure that diffModel fulfils the tea.Model interface.
+var _ tea.Model = (*diffModel)(nil)
+
+func (m *diffModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *diffModel) refreshItems() tea.Cmd {
+	var items []list.Item
+	for _, d := range m.diffs {
+		if d.Status == spec.ChangeUnchanged && !m.showUnchanged {
+			continue
+		}
+		items = append(items, diffItem{diff: d})
+	}
+	cmd := m.jobList.SetItems(items)
+	m.updateDetail()
+	return cmd
+}
+
+// updateDetail shows the changes of the job under the cursor
+func (m *diffModel) updateDetail() {
+	item, ok := m.jobList.SelectedItem().(diffItem)
+	if !ok {
+		m.selected = ""
+		m.detail.SetContent(FeintStyle.Render("No differences"))
+		return
+	}
+	key := item.diff.Namespace + "/" + item.diff.Name
+	if key == m.selected {
+		return
+	}
+	m.selected = key
+	m.detail.SetContent(RenderJobDiff(item.diff))
+	m.detail.GotoTop()
+}
+
+func (m *diffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.jobList.SetSize(diffListWidth, msg.Height-diffHeaderHeight-diffStatusHeight)
+		m.detail.Width = ints.Max(msg.Width-diffListWidth-2, 20)
+		m.detail.Height = msg.Height - diffHeaderHeight - diffStatusHeight
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		}
+		if m.jobList.SettingFilter() {
+			break
+		}
+
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "u":
+			m.showUnchanged = !m.showUnchanged
+			return m, m.refreshItems()
+		case "J", "pgdown":
+			m.detail.LineDown(1)
+			return m, nil
+		case "K", "pgup":
+			m.detail.LineUp(1)
+			return m, nil
+		}
+	}
+
+	m.jobList, cmd = m.jobList.Update(msg)
+	m.updateDetail()
+	return m, cmd
+}
+
+func (m *diffModel) View() string {
+	b := &strings.Builder{}
+	b.WriteString(m.renderHeader())
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(diffListWidth).Render(m.jobList.View()),
+		lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(Feint).
+			PaddingLeft(1).
+			Render(m.detail.View()),
+	))
+	b.WriteString("\n")
+	b.WriteString(m.renderStatus())
+	return b.String()
+}
+
+func (m *diffModel) renderHeader() string {
+	counts := map[string]int{}
+	for _, d := range m.diffs {
+		counts[d.Status]++
+	}
+	return BoldStyle.Render(m.title) + "  " +
+		diffAddedStyle.Render(fmt.Sprintf("%d added", counts[spec.ChangeAdded])) + "  " +
+		diffRemovedStyle.Render(fmt.Sprintf("%d removed", counts[spec.ChangeRemoved])) + "  " +
+		diffModifiedStyle.Render(fmt.Sprintf("%d modified", counts[spec.ChangeModified])) + "  " +
+		FeintStyle.Render(fmt.Sprintf("%d unchanged", counts[spec.ChangeUnchanged])) + "\n\n"
+}
+
+func (m *diffModel) renderStatus() string {
+	unchanged := "hidden"
+	if m.showUnchanged {
+		unchanged = "shown"
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar("Unchanged", unchanged),
+		FeintStyle.Render("↑/↓: jobs  J/K: scroll changes  u: toggle unchanged  /: filter  q: quit"),
+	)
+}
+
+type diffItem struct {
+	diff spec.JobDiff
+}
+
+func (i diffItem) FilterValue() string { return i.diff.Name + " " + i.diff.Namespace }
+
+// diffDelegate renders a job with its status and the number of changed fields
+type diffDelegate struct{}
+
+func (d diffDelegate) Height() int                               { return 1 }
+func (d diffDelegate) Spacing() int                              { return 0 }
+func (d diffDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d diffDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	i, ok := item.(diffItem)
+	if !ok {
+		return
+	}
+
+	cursor := "  "
+	nameStyle := TextStyle
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+		nameStyle = BoldStyle.Copy().Foreground(Teal)
+	}
+
+	count := ""
+	if i.diff.Status == spec.ChangeModified {
+		count = FeintStyle.Render(fmt.Sprintf("%d", len(i.diff.Changes)))
+	}
+	fmt.Fprint(w, cursor+diffSymbol(i.diff.Status)+" "+nameStyle.Render(column(i.diff.Name, diffListWidth-8))+count)
+}
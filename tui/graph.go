@@ -0,0 +1,443 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/graph"
+	"github.com/sbchaos/mirage/internal/ints"
+)
+
+const (
+	graphHeaderHeight = 4
+	graphStatusHeight = 2
+)
+
+type graphDirection int
+
+const (
+	graphUpstream graphDirection = iota
+	graphDownstream
+)
+
+func (d graphDirection) String() string {
+	if d == graphUpstream {
+		return "Upstream"
+	}
+	return "Downstream"
+}
+
+// graphTreeNode is a job in the tree of dependencies, children are built when
+// the node is expanded for the first time
+type graphTreeNode struct {
+	id        string
+	direction graphDirection
+	depth     int
+	parent    *graphTreeNode
+
+	expanded bool
+	built    bool
+	children []*graphTreeNode
+
+	// cycle marks a job which is already one of its own ancestors in the tree
+	cycle bool
+}
+
+// ancestor reports if id appears on the path from the root to n
+func (n *graphTreeNode) ancestor(id string) bool {
+	for p := n; p != nil; p = p.parent {
+		if p.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+type graphRow struct {
+	node   *graphTreeNode
+	prefix string
+
+	// section is set for the rows heading the upstream and downstream trees
+	section *graphTreeNode
+}
+
+// GraphOptions selects the job at the root of the explorer
+type GraphOptions struct {
+	Project string
+	Job     string
+
+	// Depth is the number of levels expanded when the explorer opens
+	Depth int
+
+	// Source describes where the graph was resolved from
+	Source string
+}
+
+// NewGraphModel explores the upstream and downstream dependencies of a job
+func NewGraphModel(g *graph.Graph, opts GraphOptions) (*graphModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	root := opts.Job
+	if !strings.Contains(root, "/") {
+		root = graph.ID(opts.Project, opts.Job)
+	}
+	if g.Node(root) == nil {
+		return nil, fmt.Errorf("job %s not found", root)
+	}
+
+	m := &graphModel{
+		width:   width,
+		height:  height,
+		graph:   g,
+		project: graph.Project(root),
+		root:    root,
+		source:  opts.Source,
+		detail:  viewport.New(width, height-graphStatusHeight),
+	}
+
+	related := map[string]bool{root: true}
+	for _, id := range append(g.AllUpstream(root), g.AllDownstream(root)...) {
+		related[id] = true
+	}
+	for _, cycle := range g.Cycles() {
+		if related[cycle[0]] {
+			m.cycles = append(m.cycles, cycle)
+		}
+	}
+
+	for _, direction := range []graphDirection{graphUpstream, graphDownstream} {
+		section := &graphTreeNode{id: root, direction: direction, expanded: true}
+		m.expand(section, opts.Depth)
+		m.sections = append(m.sections, section)
+	}
+	m.flatten()
+	return m, nil
+}
+
+type graphModel struct {
+	width  int
+	height int
+
+	graph   *graph.Graph
+	project string
+	root    string
+	source  string
+	cycles  [][]string
+
+	sections []*graphTreeNode
+	rows     []graphRow
+	cursor   int
+	offset   int
+
+	showDetail bool
+	detail     viewport.Model
+}
+
+// Ensure that graphModel fulfils the tea.Model interface.
+var _ tea.Model = (*graphModel)(nil)
+
+func (m *graphModel) Init() tea.Cmd {
+	return nil
+}
+
+// build creates the children of n from the edges in its direction
+func (m *graphModel) build(n *graphTreeNode) {
+	if n.built || n.cycle {
+		return
+	}
+	n.built = true
+
+	edges := m.graph.Upstream(n.id)
+	if n.direction == graphDownstream {
+		edges = m.graph.Downstream(n.id)
+	}
+	for _, id := range edges {
+		n.children = append(n.children, &graphTreeNode{
+			id:        id,
+			direction: n.direction,
+			depth:     n.depth + 1,
+			parent:    n,
+			cycle:     n.ancestor(id),
+		})
+	}
+}
+
+// expand opens n and its descendants down to levels below it
+func (m *graphModel) expand(n *graphTreeNode, levels int) {
+	m.build(n)
+	if levels <= 0 || len(n.children) == 0 {
+		return
+	}
+	n.expanded = true
+	for _, child := range n.children {
+		m.expand(child, levels-1)
+	}
+}
+
+// flatten lists the visible rows of both trees
+func (m *graphModel) flatten() {
+	m.rows = nil
+	var walk func(n *graphTreeNode, prefix, childPrefix string)
+	walk = func(n *graphTreeNode, prefix, childPrefix string) {
+		m.rows = append(m.rows, graphRow{node: n, prefix: prefix})
+		if !n.expanded {
+			return
+		}
+		for i, child := range n.children {
+			if i == len(n.children)-1 {
+				walk(child, childPrefix+"└─ ", childPrefix+"   ")
+			} else {
+				walk(child, childPrefix+"├─ ", childPrefix+"│  ")
+			}
+		}
+	}
+
+	for _, section := range m.sections {
+		m.rows = append(m.rows, graphRow{section: section})
+		if !section.expanded {
+			continue
+		}
+		for i, child := range section.children {
+			if i == len(section.children)-1 {
+				walk(child, "└─ ", "   ")
+			} else {
+				walk(child, "├─ ", "│  ")
+			}
+		}
+	}
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+}
+
+// selected returns the tree node under the cursor, sections count as their root
+func (m *graphModel) selected() *graphTreeNode {
+	row := m.rows[m.cursor]
+	if row.section != nil {
+		return row.section
+	}
+	return row.node
+}
+
+func (m *graphModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.detail.Width = msg.Width
+		m.detail.Height = msg.Height - graphStatusHeight
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		}
+		if msg.String() == "q" {
+			return m, tea.Quit
+		}
+
+		if m.showDetail {
+			if msg.Type == tea.KeyEsc || msg.Type == tea.KeyBackspace {
+				m.showDetail = false
+				return m, nil
+			}
+			m.detail, cmd = m.detail.Update(msg)
+			return m, cmd
+		}
+		m.updateTree(msg)
+	}
+	return m, nil
+}
+
+func (m *graphModel) updateTree(key tea.KeyMsg) {
+	n := m.selected()
+	switch key.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.rows)-1 {
+			m.cursor++
+		}
+	case "right", "l":
+		m.build(n)
+		n.expanded = len(n.children) > 0
+	case "left", "h":
+		if n.expanded {
+			n.expanded = false
+		} else if n.parent != nil {
+			m.moveTo(n.parent)
+		}
+	case " ":
+		m.build(n)
+		n.expanded = !n.expanded && len(n.children) > 0
+	case "e":
+		m.expand(n, 1<<10)
+	case "enter":
+		if node := m.graph.Node(n.id); node != nil {
+			m.detail.SetContent(m.renderDetail(node))
+			m.detail.GotoTop()
+			m.showDetail = true
+		}
+	}
+	m.flatten()
+}
+
+// moveTo places the cursor on the row of n
+func (m *graphModel) moveTo(n *graphTreeNode) {
+	m.flatten()
+	for i, row := range m.rows {
+		if row.node == n || row.section == n {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m *graphModel) View() string {
+	b := &strings.Builder{}
+	if m.showDetail {
+		b.WriteString(m.detail.View())
+	} else {
+		b.WriteString(m.renderHeader())
+		b.WriteString(m.renderTree())
+	}
+	b.WriteString("\n")
+	b.WriteString(m.renderStatus())
+	return b.String()
+}
+
+func (m *graphModel) renderHeader() string {
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render("Dependencies of "+m.root) + "\n")
+	for _, cycle := range m.cycles {
+		b.WriteString(RenderWarning("cycle " + strings.Join(cycle, " → ")))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// renderTree renders the rows around the cursor which fit on the screen
+func (m *graphModel) renderTree() string {
+	visible := m.height - graphHeaderHeight - graphStatusHeight - len(m.cycles)
+	if visible < 1 {
+		visible = 1
+	}
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+visible {
+		m.offset = m.cursor - visible + 1
+	}
+
+	b := &strings.Builder{}
+	end := ints.Min(m.offset+visible, len(m.rows))
+	for i := m.offset; i < end; i++ {
+		b.WriteString(m.renderRow(m.rows[i], i == m.cursor))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (m *graphModel) renderRow(row graphRow, selected bool) string {
+	cursor := "  "
+	nameStyle := TextStyle
+	if selected {
+		cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+		nameStyle = BoldStyle.Copy().Foreground(Teal)
+	}
+
+	if row.section != nil {
+		marker := "▸ "
+		if row.section.expanded {
+			marker = "▾ "
+		}
+		count := len(m.graph.AllUpstream(m.root))
+		if row.section.direction == graphDownstream {
+			count = len(m.graph.AllDownstream(m.root))
+		}
+		return cursor + nameStyle.Copy().Bold(true).Render(marker+row.section.direction.String()) +
+			FeintStyle.Render(fmt.Sprintf("  %s", plural(count, "job")))
+	}
+
+	n := row.node
+	marker := "• "
+	switch {
+	case n.expanded:
+		marker = "▾ "
+	case !n.built || len(n.children) > 0:
+		marker = "▸ "
+	}
+	if n.cycle {
+		marker = "↺ "
+	}
+
+	name := graph.Name(n.id)
+	line := cursor + FeintStyle.Render(row.prefix) + marker + nameStyle.Render(name)
+
+	node := m.graph.Node(n.id)
+	if node != nil {
+		line += FeintStyle.Render("  " + node.Namespace)
+	}
+	if project := graph.Project(n.id); project != m.project {
+		line += lipgloss.NewStyle().Foreground(Orange).Render("  ⇄ " + project)
+	}
+	if node == nil {
+		line += FeintStyle.Render("  (not found)")
+	}
+	if n.cycle {
+		line += lipgloss.NewStyle().Foreground(Red).Render("  (cycle)")
+	}
+	return line
+}
+
+func (m *graphModel) renderDetail(node *graph.Node) string {
+	b := &strings.Builder{}
+	b.WriteString(FeintStyle.Render(node.Project+" / "+node.Namespace) + "\n")
+	b.WriteString(renderJobDetail(*node.Spec, time.Now()))
+	b.WriteString("\n")
+
+	b.WriteString(BoldStyle.Render("Upstream") + "\n")
+	b.WriteString(renderIDs(m.graph.Upstream(node.ID())))
+	b.WriteString(BoldStyle.Render("Downstream") + "\n")
+	b.WriteString(renderIDs(m.graph.Downstream(node.ID())))
+	return b.String()
+}
+
+func renderIDs(ids []string) string {
+	if len(ids) == 0 {
+		return FeintStyle.Render("  none") + "\n"
+	}
+	b := &strings.Builder{}
+	for _, id := range ids {
+		b.WriteString("  " + id + "\n")
+	}
+	return b.String()
+}
+
+func (m *graphModel) renderStatus() string {
+	help := "↑/↓: move  →/←: expand/collapse  e: expand all  enter: details  q: quit"
+	if m.showDetail {
+		help = "↑/↓: scroll  esc: back  q: quit"
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar(
+			"Project", m.project,
+			"Source", m.source,
+			"Cycles", fmt.Sprint(len(m.cycles)),
+		),
+		FeintStyle.Render(help),
+	)
+}
@@ -0,0 +1,430 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/congestion"
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const (
+	heatmapLabelWidth   = 11
+	heatmapHeaderHeight = 4
+	heatmapDetailHeight = 9
+)
+
+// heatmapShades are the cells of slots by their share of the busiest slot
+var heatmapShades = []struct {
+	char  string
+	color lipgloss.Color
+}{
+	{"░", Green},
+	{"▒", Teal},
+	{"▓", Orange},
+	{"█", Red},
+}
+
+// HeatmapOptions is the project along with the period and the slots the
+// heatmap starts with
+type HeatmapOptions struct {
+	Project   string
+	Namespace string
+	Period    string
+	Slot      string
+	Timezone  *time.Location
+
+	// Client loads the jobs of another project picked in the switcher, the
+	// switcher is disabled without it, like for jobs read from local specs
+	Client *optimus.Client
+
+	// OnSwitch is called when another project is picked in the switcher
+	OnSwitch SwitchFunc
+}
+
+type heatmapLoadedMsg struct {
+	project string
+	jobs    []congestion.Job
+	err     error
+}
+
+// NewHeatmapModel shows how many runs of jobs start in each slot of a day or
+// a week, the jobs of the slot under the cursor are listed below the heatmap
+func NewHeatmapModel(jobs []congestion.Job, opts HeatmapOptions) (*heatmapModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	m := &heatmapModel{
+		width:    width,
+		height:   height,
+		jobs:     jobs,
+		opts:     opts,
+		switcher: newSwitcherOverlay(opts.Client, opts.OnSwitch),
+	}
+	if err := m.build(time.Now()); err != nil {
+		return nil, err
+	}
+	m.cursor = m.busiest()
+	return m, nil
+}
+
+type heatmapModel struct {
+	width  int
+	height int
+
+	jobs []congestion.Job
+	opts HeatmapOptions
+
+	heatmap *congestion.Heatmap
+	max     int
+
+	cursor    int
+	rowOffset int
+	jobOffset int
+
+	// loading is the project whose jobs are loaded after a switch, the
+	// heatmap of the current project is shown until they are
+	loading  string
+	err      error
+	switcher switcherOverlay
+}
+
+// Ensure that heatmapModel fulfils the tea.Model interface.
+var _ tea.Model = (*heatmapModel)(nil)
+
+// build expands the schedules over the period containing now
+func (m *heatmapModel) build(now time.Time) error {
+	slot, err := congestion.SlotDuration(m.opts.Slot)
+	if err != nil {
+		return err
+	}
+	start := congestion.PeriodStart(now.In(m.opts.Timezone), m.opts.Period)
+	h, err := congestion.New(m.jobs, start, m.opts.Period, slot)
+	if err != nil {
+		return err
+	}
+	m.heatmap = h
+	m.max = h.Max()
+	m.rowOffset = 0
+	m.jobOffset = 0
+	return nil
+}
+
+// rebuild switches the period or the slots, keeping the cursor on the time
+// it points at when the new heatmap covers it
+func (m *heatmapModel) rebuild() {
+	at := m.heatmap.Slots[m.cursor].Start
+	if err := m.build(at); err != nil {
+		return
+	}
+	m.cursor = 0
+	if i := m.heatmap.Index(at); i >= 0 && i < len(m.heatmap.Slots) {
+		m.cursor = i
+	}
+}
+
+func (m *heatmapModel) busiest() int {
+	if slots := m.heatmap.Busiest(1); len(slots) > 0 {
+		return m.heatmap.Index(slots[0].Start)
+	}
+	return 0
+}
+
+func (m *heatmapModel) Init() tea.Cmd {
+	return nil
+}
+
+// fetchJobs loads the jobs of every namespace of another project
+func (m *heatmapModel) fetchJobs(project string) tea.Cmd {
+	client := m.opts.Client
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		jobs, err := congestion.Load(ctx, client, project)
+		return heatmapLoadedMsg{project: project, jobs: jobs, err: err}
+	}
+}
+
+func (m *heatmapModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if handled, picked, cmd := m.switcher.update(msg, m.opts.Project, m.opts.Namespace); handled {
+		if picked != nil {
+			m.opts.Namespace = picked.namespace
+			m.loading = picked.project
+			m.err = nil
+			return m, m.fetchJobs(picked.project)
+		}
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case heatmapLoadedMsg:
+		if msg.project != m.loading {
+			// loaded before switching to yet another project
+			return m, nil
+		}
+		m.loading = ""
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.opts.Project = msg.project
+		m.jobs = msg.jobs
+		if err := m.build(time.Now()); err != nil {
+			m.err = err
+			return m, nil
+		}
+		m.cursor = m.busiest()
+		m.scroll()
+		return m, nil
+	case tea.KeyMsg:
+		perRow := heatmapRowLength(m.heatmap)
+		last := len(m.heatmap.Slots) - 1
+		previous := m.cursor
+
+		switch msg.String() {
+		case "ctrl+c", "q", "esc":
+			return m, tea.Quit
+		case "left", "h":
+			m.cursor = ints.Max(m.cursor-1, 0)
+		case "right", "l":
+			m.cursor = ints.Min(m.cursor+1, last)
+		case "up", "k":
+			if m.cursor-perRow >= 0 {
+				m.cursor -= perRow
+			}
+		case "down", "j":
+			if m.cursor+perRow <= last {
+				m.cursor += perRow
+			}
+		case "b":
+			m.cursor = m.busiest()
+		case "p":
+			if m.opts.Period == congestion.PeriodDay {
+				m.opts.Period = congestion.PeriodWeek
+			} else {
+				m.opts.Period = congestion.PeriodDay
+			}
+			m.rebuild()
+		case "s":
+			if m.opts.Slot == congestion.SlotMinute {
+				m.opts.Slot = congestion.SlotHour
+			} else {
+				m.opts.Slot = congestion.SlotMinute
+			}
+			m.rebuild()
+		case "J", "pgdown":
+			if m.jobOffset+heatmapDetailHeight-2 < len(m.heatmap.Slots[m.cursor].Jobs) {
+				m.jobOffset++
+			}
+		case "K", "pgup":
+			m.jobOffset = ints.Max(m.jobOffset-1, 0)
+		}
+		if m.cursor != previous {
+			m.jobOffset = 0
+		}
+		m.scroll()
+	}
+	return m, nil
+}
+
+// scroll keeps the row of the cursor within the visible rows
+func (m *heatmapModel) scroll() {
+	row := m.cursor / heatmapRowLength(m.heatmap)
+	visible := m.gridHeight()
+	if row < m.rowOffset {
+		m.rowOffset = row
+	}
+	if row >= m.rowOffset+visible {
+		m.rowOffset = row - visible + 1
+	}
+}
+
+func (m *heatmapModel) gridHeight() int {
+	// the rows left below the grid, the legend and the status bar
+	return ints.Max(m.height-heatmapHeaderHeight-heatmapDetailHeight-4, 3)
+}
+
+func (m *heatmapModel) View() string {
+	if m.switcher.open() {
+		return m.switcher.view(m.width, m.height)
+	}
+
+	h := m.heatmap
+	b := &strings.Builder{}
+
+	b.WriteString(BoldStyle.Render("Schedule congestion of "+m.opts.Project) + "  " + FeintStyle.Render(fmt.Sprintf("%s, runs per %s, %s",
+		plural(h.Jobs, "job"), m.opts.Slot, h.Start.Location())) + "\n")
+	switch {
+	case m.loading != "":
+		b.WriteString(FeintStyle.Render("Fetching the jobs of " + m.loading + "…"))
+	case m.err != nil:
+		b.WriteString(RenderError(m.err.Error()))
+	case len(h.Invalid) > 0:
+		b.WriteString(RenderWarning(fmt.Sprintf("%s not counted, their interval is not a valid cron expression", plural(len(h.Invalid), "job"))))
+	default:
+		b.WriteString(m.switcher.renderErr(m.width))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(renderHeatmapGrid(h, m.max, m.cursor, m.rowOffset, m.gridHeight()))
+	b.WriteString(renderHeatmapLegend(m.max) + "\n\n")
+	b.WriteString(m.renderDetail())
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar("Period", m.opts.Period, "Slot", m.opts.Slot, "Busiest", fmt.Sprintf("%d", m.max)),
+		FeintStyle.Render(m.help()),
+	))
+	return b.String()
+}
+
+func (m *heatmapModel) help() string {
+	help := "←↑↓→: move  b: busiest  p: day/week  s: minute/hour  J/K: scroll jobs  ctrl+p: switch  q: quit"
+	if !m.switcher.enabled() {
+		help = strings.Replace(help, "  ctrl+p: switch", "", 1)
+	}
+	return help
+}
+
+// renderDetail lists the jobs starting runs in the slot under the cursor
+func (m *heatmapModel) renderDetail() string {
+	slot := m.heatmap.Slots[m.cursor]
+	end := slot.Start.Add(m.heatmap.Slot)
+
+	lines := []string{BoldStyle.Render(slot.Start.Format("Mon 2006-01-02 15:04")+"–"+end.Format("15:04 MST")) + "  " +
+		FeintStyle.Render(fmt.Sprintf("%s of %s", plural(slot.Runs, "run"), plural(len(slot.Jobs), "job")))}
+	if len(slot.Jobs) == 0 {
+		lines = append(lines, FeintStyle.Render("  no job starts in this slot"))
+	}
+	visible := heatmapDetailHeight - 2
+	jobs := slot.Jobs[ints.Min(m.jobOffset, len(slot.Jobs)):]
+	for i, name := range jobs {
+		if i == visible-1 && len(jobs) > visible {
+			lines = append(lines, FeintStyle.Render(fmt.Sprintf("  … and %d more", len(jobs)-i)))
+			break
+		}
+		lines = append(lines, "  "+name)
+	}
+	for len(lines) < heatmapDetailHeight {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// heatmapRowLength returns the slots in a row, an hour of minutes or a day of hours
+func heatmapRowLength(h *congestion.Heatmap) int {
+	if h.Slot < time.Hour {
+		return int(time.Hour / h.Slot)
+	}
+	return int(24 * time.Hour / h.Slot)
+}
+
+// heatmapCell renders the runs of a slot relative to the busiest slot
+func heatmapCell(runs, top, width int) string {
+	if runs == 0 {
+		return FeintStyle.Render(strings.Repeat("·", width))
+	}
+	level := (runs*len(heatmapShades) - 1) / top
+	shade := heatmapShades[ints.Min(level, len(heatmapShades)-1)]
+	return lipgloss.NewStyle().Foreground(shade.color).Render(strings.Repeat(shade.char, width))
+}
+
+// renderHeatmapGrid renders rows of slots with a time label each, the slot
+// at cursor is highlighted unless cursor is negative
+func renderHeatmapGrid(h *congestion.Heatmap, top, cursor, offset, rows int) string {
+	perRow := heatmapRowLength(h)
+	width, gap := 1, ""
+	if h.Slot >= time.Hour {
+		width, gap = 2, " "
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(strings.Repeat(" ", heatmapLabelWidth))
+	for i := 0; i < perRow; i++ {
+		switch {
+		case h.Slot >= time.Hour && i%3 == 0:
+			b.WriteString(FeintStyle.Render(fmt.Sprintf("%-*s", 3*(width+len(gap)), fmt.Sprintf("%02d", i))))
+		case h.Slot < time.Hour && i%10 == 0:
+			b.WriteString(FeintStyle.Render(fmt.Sprintf("%-10s", fmt.Sprintf(":%02d", i))))
+		}
+	}
+	b.WriteString("\n")
+
+	total := (len(h.Slots) + perRow - 1) / perRow
+	for row := offset; row < total && row < offset+rows; row++ {
+		first := h.Slots[row*perRow].Start
+		label := first.Format("15:04")
+		switch {
+		case h.Slot >= time.Hour:
+			label = first.Format("Mon Jan 02")
+		case h.Period == congestion.PeriodWeek:
+			label = first.Format("Mon 15:04")
+		}
+		b.WriteString(FeintStyle.Render(column(label, heatmapLabelWidth)))
+
+		for i := row * perRow; i < (row+1)*perRow && i < len(h.Slots); i++ {
+			cell := heatmapCell(h.Slots[i].Runs, top, width)
+			if i == cursor {
+				cell = lipgloss.NewStyle().Reverse(true).Render(cell)
+			}
+			b.WriteString(cell + gap)
+		}
+		b.WriteString("\n")
+	}
+	if total > offset+rows {
+		b.WriteString(FeintStyle.Render(fmt.Sprintf("%*s%d more rows", heatmapLabelWidth, "", total-offset-rows)) + "\n")
+	}
+	return b.String()
+}
+
+// renderHeatmapLegend renders the number of runs each shade stands for
+func renderHeatmapLegend(top int) string {
+	parts := []string{FeintStyle.Render("· 0")}
+	for i, shade := range heatmapShades {
+		low := i*top/len(heatmapShades) + 1
+		high := (i + 1) * top / len(heatmapShades)
+		if high < low {
+			continue
+		}
+		label := fmt.Sprintf("%d", low)
+		if high > low {
+			label = fmt.Sprintf("%d–%d", low, high)
+		}
+		parts = append(parts, lipgloss.NewStyle().Foreground(shade.color).Render(shade.char)+" "+FeintStyle.Render(label))
+	}
+	return strings.Repeat(" ", heatmapLabelWidth) + strings.Join(parts, "  ") + FeintStyle.Render("  runs starting in a slot")
+}
+
+// RenderHeatmap renders the whole heatmap followed by its busiest slots, used
+// when printing without a terminal UI
+func RenderHeatmap(h *congestion.Heatmap, busiest int) string {
+	top := h.Max()
+	perRow := heatmapRowLength(h)
+
+	b := &strings.Builder{}
+	b.WriteString(renderHeatmapGrid(h, top, -1, 0, (len(h.Slots)+perRow-1)/perRow))
+	b.WriteString(renderHeatmapLegend(top) + "\n")
+
+	if slots := h.Busiest(busiest); len(slots) > 0 {
+		b.WriteString("\n" + BoldStyle.Render("Busiest slots") + "\n")
+		for _, s := range slots {
+			jobs := s.Jobs
+			if len(jobs) > 5 {
+				jobs = append(jobs[:5:5], fmt.Sprintf("… and %d more", len(s.Jobs)-5))
+			}
+			b.WriteString("  " + column(s.Start.Format("Mon 2006-01-02 15:04 MST"), 26) +
+				column(plural(s.Runs, "run"), 10) + FeintStyle.Render(strings.Join(jobs, ", ")) + "\n")
+		}
+	}
+	return b.String()
+}
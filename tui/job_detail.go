@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	humancron "github.com/lnquy/cron"
+	"github.com/robfig/cron/v3"
+
+	"github.com/sbchaos/mirage/job"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const detailLabelWidth = 14
+
+// describeCron returns the human readable form of a cron expression
+func describeCron(expr string) string {
+	return DescribeCron(expr, humancron.Locale_en)
+}
+
+// renderJobDetail renders the spec of a job along with the window of its next run
+func renderJobDetail(spec optimus.JobSpec, now time.Time) string {
+	b := &strings.Builder{}
+	write := func(label, value string) {
+		b.WriteString(FeintStyle.Render(column(label, detailLabelWidth)))
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+
+	b.WriteString(BoldStyle.Render(spec.Name) + "\n")
+	if spec.Description != "" {
+		b.WriteString(FeintStyle.Render(spec.Description) + "\n")
+	}
+	b.WriteString("\n")
+
+	write("Owner", spec.Owner)
+	write("Task", spec.TaskName)
+	write("Start date", spec.StartDate)
+	if spec.EndDate != "" {
+		write("End date", spec.EndDate)
+	}
+
+	schedule := BoldStyle.Render(spec.Interval)
+	if human := describeCron(spec.Interval); human != "" {
+		schedule += FeintStyle.Render(" (" + human + ")")
+	}
+	write("Schedule", schedule)
+
+	var nextRun time.Time
+	if sched, err := cron.ParseStandard(spec.Interval); err == nil {
+		nextRun = sched.Next(now)
+		write("Next run", nextRun.Format(time.RFC3339)+FeintStyle.Render(" (in "+humanizeDuration(nextRun.Sub(now))+")"))
+	} else if spec.Interval != "" {
+		write("Next run", RenderWarning("Invalid schedule: "+err.Error()))
+	}
+
+	write("Window", fmt.Sprintf("size %s, offset %s, truncate to %s",
+		orDash(spec.WindowSize), orDash(spec.WindowOffset), orDash(spec.WindowTruncateTo)))
+
+	window, err := job.NewDataWindow(spec.WindowSize, spec.WindowOffset, spec.WindowTruncateTo)
+	if err != nil {
+		write("", RenderWarning(err.Error()))
+	} else if !nextRun.IsZero() {
+		start, end := window.GetNextInterval(nextRun)
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().PaddingLeft(detailLabelWidth).Render(renderWithStartEnd(start, end)))
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().PaddingLeft(detailLabelWidth).Render(
+			FeintStyle.Render("Next run processes " + humanizeDuration(end.Sub(start)) + " of data")))
+		b.WriteString("\n\n")
+	}
+
+	if len(spec.Dependencies) > 0 {
+		var deps []string
+		for _, d := range spec.Dependencies {
+			deps = append(deps, d.Name)
+		}
+		write("Depends on", strings.Join(deps, ", "))
+	}
+	if len(spec.Hooks) > 0 {
+		var hooks []string
+		for _, h := range spec.Hooks {
+			hooks = append(hooks, h.Name)
+		}
+		write("Hooks", strings.Join(hooks, ", "))
+	}
+	if len(spec.Labels) > 0 {
+		write("Labels", joinMap(spec.Labels))
+	}
+	if len(spec.Config) > 0 {
+		write("Config", "")
+		for _, c := range spec.Config {
+			write("", c.Name+"="+c.Value)
+		}
+	}
+
+	return b.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func joinMap(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,606 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/notify"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/snapshot"
+)
+
+type jobsView int
+
+const (
+	jobsViewLoading jobsView = iota
+	jobsViewList
+	jobsViewDetail
+	jobsViewRuns
+)
+
+const (
+	nameColumnWidth     = 40
+	ownerColumnWidth    = 30
+	scheduleColumnWidth = 16
+	taskColumnWidth     = 14
+	statusColumnWidth   = 10
+
+	jobsHeaderHeight = 4
+	jobsStatusHeight = 2
+)
+
+type jobsLoadedMsg struct {
+	namespace string
+	jobs      []optimus.JobState
+	err       error
+
+	// cached is set for jobs read from a snapshot fetched at fetchedAt
+	cached    bool
+	fetchedAt time.Time
+}
+
+// JobsOptions configures the jobs view
+type JobsOptions struct {
+	Project   string
+	Namespace string
+
+	// Refresh is the polling interval, polling is disabled when zero
+	Refresh time.Duration
+
+	// OnSwitch is called when another namespace is picked in the switcher
+	OnSwitch SwitchFunc
+
+	// Snapshots keeps the fetched jobs, they are shown until the server answers
+	Snapshots *snapshot.Store
+
+	// Offline only reads the snapshots and never queries the server
+	Offline bool
+
+	// Notifier is told about every refresh to notify about watched jobs
+	Notifier *notify.Notifier
+}
+
+// NewJobsModel renders the jobs of a namespace as a filterable list
+func NewJobsModel(client *optimus.Client, opts JobsOptions) (*jobsModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	l := list.New(nil, jobDelegate{}, width, height-jobsHeaderHeight-jobsStatusHeight)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	l.SetStatusBarItemName("job", "jobs")
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	// the switcher needs the server, offline there is nothing to switch to
+	switchClient := client
+	if opts.Offline {
+		switchClient = nil
+	}
+
+	return &jobsModel{
+		width:     width,
+		height:    height,
+		view:      jobsViewLoading,
+		client:    client,
+		project:   opts.Project,
+		namespace: opts.Namespace,
+		watch:     newWatcher(opts.Refresh),
+		snapshots: opts.Snapshots,
+		offline:   opts.Offline,
+		notifier:  opts.Notifier,
+		jobList:   l,
+		spinner:   s,
+		detail:    viewport.New(width, height-jobsStatusHeight),
+		switcher:  newSwitcherOverlay(switchClient, opts.OnSwitch),
+	}, nil
+}
+
+type jobsModel struct {
+	width  int
+	height int
+
+	view jobsView
+
+	client    *optimus.Client
+	project   string
+	namespace string
+
+	jobs []optimus.JobState
+	err  error
+
+	// changed holds the previous run state of jobs changed in the last refresh
+	changed map[string]string
+	watch   *watcher
+
+	snapshots *snapshot.Store
+	offline   bool
+	// staleSince is the fetch time of the snapshot shown, zero once the server answered
+	staleSince time.Time
+
+	notifier *notify.Notifier
+	toasts   toasts
+
+	jobList list.Model
+	spinner spinner.Model
+	detail  viewport.Model
+	runs    *runsModel
+
+	switcher switcherOverlay
+}
+
+// Ensure that jobsModel fulfils the tea.Model interface.
+var _ tea.Model = (*jobsModel)(nil)
+
+func (m *jobsModel) Init() tea.Cmd {
+	if m.snapshots != nil && !m.offline {
+		return tea.Batch(m.spinner.Tick, m.loadSnapshot, m.fetchJobs)
+	}
+	return tea.Batch(m.spinner.Tick, m.fetchJobs)
+}
+
+func (m *jobsModel) fetchJobs() tea.Msg {
+	if m.offline {
+		return m.loadSnapshot()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	fetchedAt := time.Now()
+	jobs, err := m.client.ListJobStates(ctx, m.project, m.namespace)
+	if err == nil && m.snapshots != nil {
+		// the snapshot only speeds up the next start, failing to save it is not an error
+		_ = m.snapshots.SaveJobs(m.project, m.namespace, jobs, fetchedAt)
+	}
+	return jobsLoadedMsg{namespace: m.project + "/" + m.namespace, jobs: jobs, err: err}
+}
+
+// loadSnapshot reads the jobs saved by the last fetch, a missing snapshot is
+// only an error when offline
+func (m *jobsModel) loadSnapshot() tea.Msg {
+	key := m.project + "/" + m.namespace
+	snap, err := m.snapshots.Jobs(m.project, m.namespace)
+	if err == nil && snap == nil {
+		if !m.offline {
+			return nil
+		}
+		err = fmt.Errorf("no snapshot of %s, run mirage jobs without --offline first", key)
+	}
+	if err != nil {
+		return jobsLoadedMsg{namespace: key, err: err, cached: true}
+	}
+	return jobsLoadedMsg{namespace: key, jobs: snap.Jobs, cached: true, fetchedAt: snap.FetchedAt}
+}
+
+func (m *jobsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.toasts.update(msg) {
+		return m, nil
+	}
+	if handled, picked, cmd := m.switcher.update(msg, m.project, m.namespace); handled {
+		if picked != nil {
+			return m, m.switchNamespace(picked.project, picked.namespace)
+		}
+		return m, cmd
+	}
+
+	if m.view == jobsViewRuns {
+		if handled, cmd := m.updateRuns(msg); handled {
+			return m, cmd
+		}
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.jobList.SetSize(msg.Width, msg.Height-jobsHeaderHeight-jobsStatusHeight)
+		m.detail.Width = msg.Width
+		m.detail.Height = msg.Height - jobsStatusHeight
+		return m, nil
+	case jobsLoadedMsg:
+		if msg.namespace != m.project+"/"+m.namespace {
+			// loaded before switching to another namespace
+			return m, nil
+		}
+		if msg.cached && !m.offline && (msg.err != nil || (m.jobs != nil && m.staleSince.IsZero())) {
+			// the server answered first or the snapshot is unusable
+			return m, nil
+		}
+		if !msg.cached || m.offline {
+			m.err = msg.err
+		}
+		if msg.cached {
+			m.staleSince = msg.fetchedAt
+		} else {
+			m.watch.done(msg.err)
+			if msg.err == nil {
+				m.staleSince = time.Time{}
+			}
+		}
+		if msg.err == nil {
+			if m.jobs != nil {
+				m.changed = diffJobStates(m.jobs, msg.jobs)
+			}
+			m.jobs = msg.jobs
+			cmd = m.jobList.SetItems(jobItems(msg.jobs, m.changed))
+		}
+		if m.view == jobsViewLoading {
+			m.view = jobsViewList
+		}
+		if msg.cached {
+			return m, cmd
+		}
+		if msg.err == nil {
+			cmd = tea.Batch(cmd, m.notify(msg.jobs))
+		}
+		return m, tea.Batch(cmd, m.watch.schedule())
+	case notifyFailedMsg:
+		return m, m.toasts.push(toastWarning, "Notification failed", msg.err.Error())
+	case watchTickMsg:
+		if !m.watch.tick(msg) {
+			return m, nil
+		}
+		return m, m.fetchJobs
+	case spinner.TickMsg:
+		if m.view != jobsViewLoading {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		}
+		if m.jobList.SettingFilter() {
+			break
+		}
+
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "r":
+			return m, m.fetchJobs
+		}
+	}
+
+	switch m.view {
+	case jobsViewList:
+		return m.updateList(msg)
+	case jobsViewDetail:
+		return m.updateDetail(msg)
+	}
+	return m, nil
+}
+
+func (m *jobsModel) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if key, ok := msg.(tea.KeyMsg); ok && !m.jobList.SettingFilter() {
+		item, selected := m.jobList.SelectedItem().(jobItem)
+		switch {
+		case key.Type == tea.KeyEnter && selected:
+			m.detail.SetContent(renderJobDetail(item.state.Spec, time.Now()))
+			m.detail.GotoTop()
+			m.view = jobsViewDetail
+			return m, nil
+		case key.String() == "h" && selected:
+			return m, m.openRuns(item.state.Spec.Name)
+		}
+	}
+
+	m.jobList, cmd = m.jobList.Update(msg)
+	return m, cmd
+}
+
+func (m *jobsModel) updateDetail(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Type == tea.KeyEsc || key.Type == tea.KeyBackspace:
+			m.view = jobsViewList
+			return m, nil
+		case key.String() == "h":
+			if item, ok := m.jobList.SelectedItem().(jobItem); ok {
+				return m, m.openRuns(item.state.Spec.Name)
+			}
+		}
+	}
+
+	m.detail, cmd = m.detail.Update(msg)
+	return m, cmd
+}
+
+type notifyFailedMsg struct {
+	err error
+}
+
+// notify shows the failures and missed SLAs of watched jobs found in a refresh,
+// rings the bell and runs the configured command for them
+func (m *jobsModel) notify(jobs []optimus.JobState) tea.Cmd {
+	if m.notifier == nil || !m.notifier.Enabled() {
+		return nil
+	}
+	events := m.notifier.Check(m.project, m.namespace, jobs, time.Now())
+	if len(events) == 0 {
+		return nil
+	}
+
+	var cmds []tea.Cmd
+	for _, e := range events {
+		level, title := toastError, "Job failed"
+		if e.Kind == notify.EventSLAMiss {
+			level, title = toastWarning, "SLA missed"
+		}
+		cmds = append(cmds, m.toasts.push(level, title, e.Message()), m.execNotify(e))
+	}
+	if m.notifier.Bell() {
+		cmds = append(cmds, ringBell)
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *jobsModel) execNotify(e notify.Event) tea.Cmd {
+	notifier := m.notifier
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := notifier.Exec(ctx, e); err != nil {
+			return notifyFailedMsg{err: err}
+		}
+		return nil
+	}
+}
+
+// ringBell writes the bell to stderr, stdout is owned by the renderer
+func ringBell() tea.Msg {
+	fmt.Fprint(os.Stderr, "\a")
+	return nil
+}
+
+// switchNamespace shows the jobs of another namespace and persists the choice
+func (m *jobsModel) switchNamespace(project, namespace string) tea.Cmd {
+	m.project = project
+	m.namespace = namespace
+	m.jobs = nil
+	m.changed = nil
+	m.staleSince = time.Time{}
+	m.runs = nil
+	m.view = jobsViewLoading
+	m.jobList.ResetFilter()
+
+	m.err = nil
+	if m.snapshots != nil {
+		return tea.Batch(m.spinner.Tick, m.jobList.SetItems(nil), m.loadSnapshot, m.fetchJobs)
+	}
+	return tea.Batch(m.spinner.Tick, m.jobList.SetItems(nil), m.fetchJobs)
+}
+
+// openRuns switches to the run history of the job, the view stays on the
+// jobs with the error when it cannot be opened
+func (m *jobsModel) openRuns(name string) tea.Cmd {
+	runs, err := NewRunsModel(m.client, RunsOptions{
+		Project:   m.project,
+		Namespace: m.namespace,
+		Job:       name,
+		Snapshots: m.snapshots,
+		Offline:   m.offline,
+		Embedded:  true,
+	})
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.runs = runs
+	m.runs.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+	m.view = jobsViewRuns
+	return m.runs.Init()
+}
+
+// updateRuns forwards messages to the run history, it reports false for
+// messages which still need to be handled by the jobs view
+func (m *jobsModel) updateRuns(msg tea.Msg) (bool, tea.Cmd) {
+	switch msg := msg.(type) {
+	case jobsLoadedMsg:
+		return false, nil
+	case watchTickMsg:
+		if m.watch.owns(msg) {
+			return false, nil
+		}
+	case tea.WindowSizeMsg:
+		m.runs.Update(msg)
+		return false, nil
+	case tea.KeyMsg:
+		if (msg.Type == tea.KeyEsc || msg.Type == tea.KeyBackspace) && !m.runs.handlesBack() {
+			m.view = jobsViewList
+			m.runs = nil
+			return true, nil
+		}
+	}
+
+	_, cmd := m.runs.Update(msg)
+	return true, cmd
+}
+
+func (m *jobsModel) View() string {
+	return m.toasts.overlay(m.render(), m.width)
+}
+
+func (m *jobsModel) render() string {
+	b := &strings.Builder{}
+	if m.switcher.open() {
+		return m.switcher.view(m.width, m.height)
+	}
+
+	switch m.view {
+	case jobsViewLoading:
+		b.WriteString("\n " + m.spinner.View() + " Fetching jobs of " + BoldStyle.Render(m.project+"/"+m.namespace) + "\n")
+		return b.String()
+	case jobsViewList:
+		b.WriteString(m.renderHeader())
+		b.WriteString(m.jobList.View())
+	case jobsViewDetail:
+		b.WriteString(m.detail.View())
+	case jobsViewRuns:
+		return m.runs.View()
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.renderStatus())
+	return b.String()
+}
+
+func (m *jobsModel) renderHeader() string {
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render("Jobs in " + m.project + "/" + m.namespace))
+	if !m.staleSince.IsZero() {
+		b.WriteString(renderStale(m.staleSince, m.offline))
+	}
+	if len(m.changed) > 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(Orange).Render(fmt.Sprintf("  %d changed since last refresh", len(m.changed))))
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(RenderError(truncate.StringWithTail(m.err.Error(), uint(m.width-10), "…")))
+	} else {
+		b.WriteString(m.switcher.renderErr(m.width))
+	}
+	b.WriteString("\n")
+
+	header := "  " + column("NAME", nameColumnWidth) + column("OWNER", ownerColumnWidth) +
+		column("SCHEDULE", scheduleColumnWidth) + column("TASK", taskColumnWidth) + column("LAST RUN", statusColumnWidth)
+	b.WriteString(FeintStyle.Render(header) + "\n")
+	return b.String()
+}
+
+func (m *jobsModel) renderStatus() string {
+	help := "enter: details  h: runs  /: filter  r: refresh  ctrl+p: switch  q: quit"
+	if m.view == jobsViewDetail {
+		help = "↑/↓: scroll  h: runs  esc: back  ctrl+p: switch  q: quit"
+	}
+	if !m.switcher.enabled() {
+		help = strings.Replace(help, "  ctrl+p: switch", "", 1)
+	}
+	connection := m.watch.renderStatus()
+	if m.offline {
+		connection = renderOfflineStatus(m.staleSince)
+	}
+
+	pairs := []string{
+		"Project", m.project,
+		"Namespace", m.namespace,
+		"Jobs", strconv.Itoa(len(m.jobs)),
+	}
+	if m.notifier != nil && m.notifier.Enabled() {
+		watched := 0
+		for _, j := range m.jobs {
+			if m.notifier.Watches(j.Spec) {
+				watched++
+			}
+		}
+		pairs = append(pairs, "Notify", strconv.Itoa(watched))
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar(pairs...),
+		connection,
+		FeintStyle.Render(help),
+	)
+}
+
+// diffJobStates returns the jobs which are new or whose last run state changed,
+// mapped to their previous state
+func diffJobStates(prev, next []optimus.JobState) map[string]string {
+	previous := map[string]string{}
+	for _, j := range prev {
+		previous[j.Spec.Name] = j.LastRunState()
+	}
+
+	changed := map[string]string{}
+	for _, j := range next {
+		state, ok := previous[j.Spec.Name]
+		if !ok {
+			changed[j.Spec.Name] = "new"
+			continue
+		}
+		if state != j.LastRunState() {
+			changed[j.Spec.Name] = state
+		}
+	}
+	return changed
+}
+
+func jobItems(jobs []optimus.JobState, changed map[string]string) []list.Item {
+	items := make([]list.Item, len(jobs))
+	for i, j := range jobs {
+		prev, ok := changed[j.Spec.Name]
+		items[i] = jobItem{state: j, changed: ok, previous: prev}
+	}
+	return items
+}
+
+type jobItem struct {
+	state optimus.JobState
+
+	changed  bool
+	previous string
+}
+
+func (i jobItem) FilterValue() string {
+	spec := i.state.Spec
+	return strings.Join([]string{spec.Name, spec.Owner, spec.TaskName, i.state.LastRunState()}, " ")
+}
+
+// jobDelegate renders a job as a single row of columns
+type jobDelegate struct{}
+
+func (d jobDelegate) Height() int                               { return 1 }
+func (d jobDelegate) Spacing() int                              { return 0 }
+func (d jobDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d jobDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	j, ok := item.(jobItem)
+	if !ok {
+		return
+	}
+	spec := j.state.Spec
+
+	cursor := "  "
+	nameStyle := TextStyle
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+		nameStyle = BoldStyle.Copy().Foreground(Teal)
+	}
+
+	if j.changed {
+		nameStyle = nameStyle.Copy().Foreground(Orange)
+	}
+
+	row := cursor +
+		nameStyle.Render(column(spec.Name, nameColumnWidth)) +
+		column(spec.Owner, ownerColumnWidth) +
+		column(spec.Interval, scheduleColumnWidth) +
+		column(spec.TaskName, taskColumnWidth) +
+		RenderState(j.state.LastRunState())
+	if j.changed && j.previous != "" {
+		row += FeintStyle.Render("  was " + j.previous)
+	}
+	fmt.Fprint(w, row)
+}
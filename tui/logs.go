@@ -0,0 +1,439 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const (
+	// logsFollowInterval is the delay between fetches of new lines of a run in progress
+	logsFollowInterval = 2 * time.Second
+
+	logsHeaderHeight = 3
+	logsStatusHeight = 2
+
+	logTimeFormat     = "15:04:05"
+	logFileTimeFormat = "20060102T150405"
+)
+
+// logSourceFilters are cycled through with tab, an empty filter shows all lines
+var logSourceFilters = []string{"", optimus.LogSourceTask, optimus.LogSourceHook}
+
+var logMatchStyle = lipgloss.NewStyle().Reverse(true)
+
+type logsLoadedMsg struct {
+	run  *optimus.JobRun
+	logs *optimus.RunLogs
+	err  error
+}
+
+// LogsOptions selects the run whose logs are shown, the latest run of the
+// job is used when Run is zero
+type LogsOptions struct {
+	Project string
+	Job     string
+	Run     time.Time
+}
+
+// NewLogsModel shows the task and hook logs of a run and follows runs in progress
+func NewLogsModel(client *optimus.Client, opts LogsOptions) (*logsModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	input := textinput.New()
+	input.Prompt = "/"
+	input.Placeholder = "search"
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	return &logsModel{
+		width:    width,
+		height:   height,
+		client:   client,
+		project:  opts.Project,
+		job:      opts.Job,
+		runAt:    opts.Run,
+		loading:  true,
+		follow:   true,
+		watch:    newWatcher(logsFollowInterval),
+		viewport: viewport.New(width, ints.Max(height-logsHeaderHeight-logsStatusHeight, 1)),
+		search:   input,
+		spinner:  s,
+	}, nil
+}
+
+type logsModel struct {
+	width  int
+	height int
+
+	client  *optimus.Client
+	project string
+	job     string
+	runAt   time.Time
+
+	run      *optimus.JobRun
+	lines    []optimus.LogLine
+	offset   int
+	complete bool
+	err      error
+	loading  bool
+
+	follow bool
+	watch  *watcher
+	filter int
+
+	searching bool
+	query     string
+	pattern   *regexp.Regexp
+	match     int
+
+	// matches are the offsets in the viewport of the lines matching pattern,
+	// a log line takes several lines of the viewport when its message does
+	matches []int
+
+	// message is feedback for the last action, like saving to a file
+	message string
+
+	viewport viewport.Model
+	search   textinput.Model
+	spinner  spinner.Model
+}
+
+// Ensure that logsModel fulfils the tea.Model interface.
+var _ tea.Model = (*logsModel)(nil)
+
+func (m *logsModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.fetchLogs)
+}
+
+// fetchLogs loads the lines written since the last fetch, resolving the run first
+func (m *logsModel) fetchLogs() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// the run is looked up on every fetch to pick up its state while following
+	run, err := m.findRun(ctx)
+	if err != nil {
+		return logsLoadedMsg{err: err}
+	}
+
+	logs, err := m.client.GetJobRunLogs(ctx, m.project, m.job, run.ScheduledAt, m.offset)
+	return logsLoadedMsg{run: run, logs: logs, err: err}
+}
+
+// findRun looks up the run at runAt, or the latest run of the job
+func (m *logsModel) findRun(ctx context.Context) (*optimus.JobRun, error) {
+	runAt := m.runAt
+	if m.run != nil {
+		runAt = m.run.ScheduledAt
+	}
+	filter := optimus.JobRunFilter{StartDate: time.Now().AddDate(0, 0, -runsPageDays), EndDate: time.Now()}
+	if !runAt.IsZero() {
+		filter = optimus.JobRunFilter{StartDate: runAt, EndDate: runAt}
+	}
+	runs, err := m.client.ListJobRuns(ctx, m.project, m.job, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	run := optimus.LatestRun(runs)
+	if run == nil {
+		if m.runAt.IsZero() {
+			return nil, fmt.Errorf("%s has no runs in the last %d days", m.job, runsPageDays)
+		}
+		return nil, fmt.Errorf("%s has no run scheduled at %s", m.job, runAt.Format(time.RFC3339))
+	}
+	return run, nil
+}
+
+// capturingInput reports if keys are typed into the search, views embedding
+// the logs should not act on them
+func (m *logsModel) capturingInput() bool {
+	return m.searching
+}
+
+func (m *logsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.viewport.Width = msg.Width
+		m.viewport.Height = ints.Max(msg.Height-logsHeaderHeight-logsStatusHeight, 1)
+		m.refreshContent()
+		return m, nil
+	case logsLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		m.watch.done(msg.err)
+		if msg.err == nil {
+			m.run = msg.run
+			m.lines = append(m.lines, msg.logs.Lines...)
+			m.offset = msg.logs.Next
+			m.complete = msg.logs.Complete
+			m.refreshContent()
+		}
+		if m.complete {
+			return m, nil
+		}
+		return m, m.watch.schedule()
+	case watchTickMsg:
+		if !m.watch.tick(msg) {
+			return m, nil
+		}
+		return m, m.fetchLogs
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC || msg.Type == tea.KeyCtrlBackslash {
+			return m, tea.Quit
+		}
+		if m.searching {
+			return m, m.updateSearch(msg)
+		}
+
+		m.message = ""
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "/":
+			m.searching = true
+			m.search.SetValue(m.query)
+			m.search.CursorEnd()
+			return m, m.search.Focus()
+		case "n":
+			m.jumpToMatch(m.match + 1)
+			return m, nil
+		case "N":
+			m.jumpToMatch(m.match - 1)
+			return m, nil
+		case "f":
+			m.follow = !m.follow
+			if m.follow {
+				m.viewport.GotoBottom()
+			}
+			return m, nil
+		case "tab":
+			m.filter = (m.filter + 1) % len(logSourceFilters)
+			m.refreshContent()
+			return m, nil
+		case "s":
+			m.save()
+			return m, nil
+		case "up", "k", "pgup":
+			// scrolling back stops following new lines
+			m.follow = false
+		}
+	}
+
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *logsModel) updateSearch(key tea.KeyMsg) tea.Cmd {
+	switch key.Type {
+	case tea.KeyEsc:
+		m.searching = false
+		m.search.Blur()
+		return nil
+	case tea.KeyEnter:
+		m.searching = false
+		m.search.Blur()
+		m.query = m.search.Value()
+		m.pattern = searchPattern(m.query)
+		m.follow = false
+		m.refreshContent()
+		m.jumpToMatch(0)
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(key)
+	return cmd
+}
+
+// visibleLines returns the lines passing the source filter
+func (m *logsModel) visibleLines() []optimus.LogLine {
+	source := logSourceFilters[m.filter]
+	if source == "" {
+		return m.lines
+	}
+	var lines []optimus.LogLine
+	for _, l := range m.lines {
+		if l.SourceType == source {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// refreshContent renders the lines into the viewport and finds search matches
+func (m *logsModel) refreshContent() {
+	lines := m.visibleLines()
+	m.matches = nil
+
+	rendered := make([]string, len(lines))
+	offset := 0
+	for i, l := range lines {
+		if m.pattern != nil {
+			if loc := m.pattern.FindStringIndex(l.Message); loc != nil {
+				m.matches = append(m.matches, offset+strings.Count(l.Message[:loc[0]], "\n"))
+			}
+		}
+		rendered[i] = m.renderLine(l)
+		offset += strings.Count(rendered[i], "\n") + 1
+	}
+	if len(rendered) == 0 {
+		rendered = []string{FeintStyle.Render("No logs yet")}
+	}
+
+	m.viewport.SetContent(strings.Join(rendered, "\n"))
+	if m.follow {
+		m.viewport.GotoBottom()
+	}
+}
+
+func (m *logsModel) renderLine(l optimus.LogLine) string {
+	sourceStyle := lipgloss.NewStyle().Foreground(Teal)
+	if l.SourceType == optimus.LogSourceHook {
+		sourceStyle = lipgloss.NewStyle().Foreground(Orange)
+	}
+
+	message := l.Message
+	if m.pattern != nil {
+		message = highlight(message, m.pattern)
+	}
+	return FeintStyle.Render(l.Timestamp.Local().Format(logTimeFormat)) + " " +
+		sourceStyle.Render("["+l.Source+"]") + " " + message
+}
+
+// searchPattern matches query case insensitively, it is nil for an empty query
+func searchPattern(query string) *regexp.Regexp {
+	if query == "" {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+}
+
+// highlight marks every match of pattern in s
+func highlight(s string, pattern *regexp.Regexp) string {
+	b := &strings.Builder{}
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(s, -1) {
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(logMatchStyle.Render(s[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// jumpToMatch scrolls to the match with index i, wrapping around
+func (m *logsModel) jumpToMatch(i int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.match = (i + len(m.matches)) % len(m.matches)
+	m.follow = false
+	m.viewport.SetYOffset(ints.Max(m.matches[m.match]-m.viewport.Height/2, 0))
+}
+
+// save writes the lines of the run to a file in the working directory
+func (m *logsModel) save() {
+	if m.run == nil {
+		return
+	}
+	name := fmt.Sprintf("%s-%s.log", m.job, m.run.ScheduledAt.UTC().Format(logFileTimeFormat))
+
+	b := &strings.Builder{}
+	for _, l := range m.lines {
+		fmt.Fprintf(b, "%s [%s %s] %s\n", l.Timestamp.UTC().Format(time.RFC3339), l.SourceType, l.Source, l.Message)
+	}
+	if err := os.WriteFile(name, []byte(b.String()), 0o644); err != nil {
+		m.message = RenderError(err.Error())
+		return
+	}
+	m.message = lipgloss.NewStyle().Foreground(Green).Render(fmt.Sprintf("Saved %d lines to %s", len(m.lines), name))
+}
+
+func (m *logsModel) View() string {
+	b := &strings.Builder{}
+	b.WriteString(m.renderHeader())
+	b.WriteString(m.viewport.View())
+	b.WriteString("\n")
+	if m.searching {
+		b.WriteString(m.search.View())
+	} else {
+		b.WriteString(m.renderStatus())
+	}
+	return b.String()
+}
+
+func (m *logsModel) renderHeader() string {
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render("Logs of " + m.job))
+	if m.run != nil {
+		b.WriteString(FeintStyle.Render("  run " + m.run.ScheduledAt.Local().Format(runTimeFormat) + "  "))
+		b.WriteString(RenderState(m.run.State))
+		if !m.complete {
+			b.WriteString(FeintStyle.Render("  following"))
+		}
+	}
+	if m.loading {
+		b.WriteString("  " + m.spinner.View() + FeintStyle.Render(" fetching"))
+	}
+	b.WriteString("\n")
+
+	switch {
+	case m.err != nil:
+		b.WriteString(RenderError(truncate.StringWithTail(m.err.Error(), uint(m.width-10), "…")))
+	case m.message != "":
+		b.WriteString(m.message)
+	}
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+func (m *logsModel) renderStatus() string {
+	source := "all"
+	if s := logSourceFilters[m.filter]; s != "" {
+		source = s
+	}
+	follow := "off"
+	if m.follow {
+		follow = "on"
+	}
+
+	pairs := []string{"Source", source, "Lines", fmt.Sprint(len(m.lines)), "Follow", follow}
+	if m.query != "" {
+		found := "none"
+		if len(m.matches) > 0 {
+			found = fmt.Sprintf("%d/%d", m.match+1, len(m.matches))
+		}
+		pairs = append(pairs, "Match", found)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar(pairs...),
+		FeintStyle.Render("/: search  n/N: next/prev  tab: source  f: follow  s: save  q: quit"),
+	)
+}
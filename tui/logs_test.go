@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+
+	"github.com/sbchaos/mirage/optimus"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		query   string
+		matches []string
+	}{
+		{name: "case insensitive", line: "Task FAILED, task retried", query: "task", matches: []string{"Task", "task"}},
+		{name: "rune changing length when lowercased", line: "İİİ export failed", query: "failed", matches: []string{"failed"}},
+		{name: "query with regexp characters", line: "rows (total) 10", query: "(total)", matches: []string{"(total)"}},
+		{name: "no match", line: "done", query: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := searchPattern(tt.query)
+			if got := pattern.FindAllString(tt.line, -1); !reflect.DeepEqual(got, tt.matches) {
+				t.Errorf("matches of %q in %q = %q, want %q", tt.query, tt.line, got, tt.matches)
+			}
+			got := highlight(tt.line, pattern)
+			for _, m := range tt.matches {
+				if !strings.Contains(got, logMatchStyle.Render(m)) {
+					t.Errorf("highlight(%q, %q) = %q, %q is not highlighted", tt.line, tt.query, got, m)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchMatchesMultilineMessages(t *testing.T) {
+	line := func(message string) optimus.LogLine {
+		return optimus.LogLine{Source: "bq2bq", SourceType: optimus.LogSourceTask, Message: message}
+	}
+	m := &logsModel{
+		lines: []optimus.LogLine{
+			line("starting"),
+			line("Traceback:\n  File main.py\n  ValueError: bad rows"),
+			line("retrying"),
+			line("query:\nselect 1\nfrom rows"),
+			line("failed on rows"),
+		},
+		pattern:  searchPattern("rows"),
+		viewport: viewport.New(80, 2),
+	}
+	m.refreshContent()
+
+	// the matches of the second and fourth line are on their last line
+	if want := []int{3, 7, 8}; !reflect.DeepEqual(m.matches, want) {
+		t.Fatalf("matches = %v, want %v", m.matches, want)
+	}
+	m.jumpToMatch(2)
+	if m.viewport.YOffset != 7 {
+		t.Errorf("offset after jumping to the last match = %d, want 7", m.viewport.YOffset)
+	}
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbchaos/mirage/replay"
+)
+
+// RenderReplayPlan renders the runs a replay would execute
+func RenderReplayPlan(plan *replay.Plan) string {
+	b := &strings.Builder{}
+
+	b.WriteString(BoldStyle.Render(fmt.Sprintf("Replay of %s from %s to %s",
+		plan.Job.Name, plan.Start.Format(dateFormat), plan.End.Format(dateFormat))))
+	b.WriteString("\n")
+	if human := describeCron(plan.Job.Interval); human != "" {
+		b.WriteString(FeintStyle.Render(plan.Job.Interval+" ("+human+")") + "\n")
+	}
+	b.WriteString("\n")
+
+	if len(plan.Job.Runs) == 0 {
+		b.WriteString(RenderWarning("The job is not scheduled to run in this range") + "\n")
+	} else {
+		b.WriteString(FeintStyle.Render("  "+column("SCHEDULED AT", timeColumnWidth)+"WINDOW") + "\n")
+		for _, r := range plan.Job.Runs {
+			b.WriteString("  " + column(r.ScheduledAt.Format(runTimeFormat), timeColumnWidth))
+			b.WriteString(r.WindowStart.Format(runTimeFormat) + " → " + r.WindowEnd.Format(runTimeFormat) + "\n")
+		}
+	}
+	b.WriteString("\n")
+
+	if len(plan.Downstream) == 0 {
+		b.WriteString(FeintStyle.Render("No downstream jobs would be replayed") + "\n")
+		return b.String()
+	}
+
+	b.WriteString(BoldStyle.Render(fmt.Sprintf("Downstream jobs (%d)", len(plan.Downstream))) + "\n")
+	for _, d := range plan.Downstream {
+		runs := column(plural(len(d.Runs), "run"), 10)
+		if len(d.Runs) > 0 {
+			first, last := d.Runs[0], d.Runs[len(d.Runs)-1]
+			runs += FeintStyle.Render(fmt.Sprintf("  windows %s → %s",
+				first.WindowStart.Format(runTimeFormat), last.WindowEnd.Format(runTimeFormat)))
+		}
+		b.WriteString("  " + column(d.Project+"/"+d.Namespace+"/"+d.Name, nameColumnWidth+10) + runs + "\n")
+	}
+	return b.String()
+}
+
+// plural formats a count with the singular or plural form of word
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
@@ -0,0 +1,362 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/reflow/wordwrap"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const (
+	replayJobColumnWidth  = 40
+	replayCellColumnWidth = 6
+	replayDayFormat       = "01-02"
+)
+
+// statePriority decides the state shown for a day with several runs, the
+// most severe state wins
+var statePriority = map[string]int{
+	optimus.RunStateSuccess: 1,
+	optimus.RunStatePending: 2,
+	optimus.RunStateRunning: 3,
+	optimus.RunStateFailed:  4,
+}
+
+type replayStatusMsg struct {
+	status *optimus.ReplayStatus
+	err    error
+}
+
+type replayRow struct {
+	prefix string
+	job    string
+	days   map[string]string
+}
+
+type failedCell struct {
+	job string
+	run optimus.ReplayRun
+}
+
+// NewReplayStatusModel tracks the progress of a submitted replay
+func NewReplayStatusModel(client *optimus.Client, project, id string, refresh time.Duration) (*replayStatusModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	return &replayStatusModel{
+		width:    width,
+		height:   height,
+		client:   client,
+		project:  project,
+		id:       id,
+		watch:    newWatcher(refresh),
+		progress: progress.New(progress.WithSolidFill(string(Green)), progress.WithWidth(40)),
+	}, nil
+}
+
+type replayStatusModel struct {
+	width  int
+	height int
+
+	client  *optimus.Client
+	project string
+	id      string
+
+	status *optimus.ReplayStatus
+	err    error
+	watch  *watcher
+
+	rows   []replayRow
+	days   []string
+	counts map[string]int
+	failed []failedCell
+
+	// cursor selects a failed cell, dayOffset scrolls the columns
+	cursor      int
+	dayOffset   int
+	showMessage bool
+
+	progress progress.Model
+}
+
+// Ensure that replayStatusModel fulfils the tea.Model interface.
+var _ tea.Model = (*replayStatusModel)(nil)
+
+func (m *replayStatusModel) Init() tea.Cmd {
+	return m.fetchStatus
+}
+
+func (m *replayStatusModel) fetchStatus() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	status, err := m.client.GetReplayStatus(ctx, m.project, m.id)
+	return replayStatusMsg{status: status, err: err}
+}
+
+// finished reports if the replay reached a terminal state
+func (m *replayStatusModel) finished() bool {
+	return m.status != nil &&
+		(m.status.State == optimus.ReplayStateSuccess || m.status.State == optimus.ReplayStateFailed)
+}
+
+func (m *replayStatusModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case replayStatusMsg:
+		m.err = msg.err
+		m.watch.done(msg.err)
+		if msg.err == nil {
+			m.setStatus(msg.status)
+		}
+		if m.finished() {
+			return m, nil
+		}
+		return m, m.watch.schedule()
+	case watchTickMsg:
+		if !m.watch.tick(msg) {
+			return m, nil
+		}
+		return m, m.fetchStatus
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		case tea.KeyUp:
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case tea.KeyDown:
+			if m.cursor < len(m.failed)-1 {
+				m.cursor++
+			}
+		case tea.KeyLeft:
+			if m.dayOffset > 0 {
+				m.dayOffset--
+			}
+		case tea.KeyRight:
+			if m.dayOffset < len(m.days)-1 {
+				m.dayOffset++
+			}
+		case tea.KeyEnter:
+			m.showMessage = !m.showMessage && len(m.failed) > 0
+		case tea.KeyEsc:
+			m.showMessage = false
+		}
+
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "r":
+			return m, m.fetchStatus
+		}
+	}
+	return m, nil
+}
+
+// setStatus flattens the replay tree into rows of days and collects failures
+func (m *replayStatusModel) setStatus(status *optimus.ReplayStatus) {
+	m.status = status
+	m.rows = nil
+	m.failed = nil
+	m.counts = map[string]int{}
+
+	days := map[string]bool{}
+	var walk func(node *optimus.ReplayNode, prefix, childPrefix string)
+	walk = func(node *optimus.ReplayNode, prefix, childPrefix string) {
+		row := replayRow{prefix: prefix, job: node.JobName, days: map[string]string{}}
+		for _, run := range node.Runs {
+			day := run.Run.Format(dateFormat)
+			days[day] = true
+			if statePriority[run.State] > statePriority[row.days[day]] {
+				row.days[day] = run.State
+			}
+			m.counts[run.State]++
+			if run.State == optimus.RunStateFailed {
+				m.failed = append(m.failed, failedCell{job: node.JobName, run: run})
+			}
+		}
+		m.rows = append(m.rows, row)
+
+		for i, dependent := range node.Dependents {
+			if i == len(node.Dependents)-1 {
+				walk(dependent, childPrefix+"└─ ", childPrefix+"   ")
+			} else {
+				walk(dependent, childPrefix+"├─ ", childPrefix+"│  ")
+			}
+		}
+	}
+	if status.Tree != nil {
+		walk(status.Tree, "", "")
+	}
+
+	m.days = make([]string, 0, len(days))
+	for day := range days {
+		m.days = append(m.days, day)
+	}
+	sort.Strings(m.days)
+
+	if m.cursor >= len(m.failed) {
+		m.cursor = 0
+	}
+}
+
+func (m *replayStatusModel) View() string {
+	b := &strings.Builder{}
+	b.WriteString(m.renderHeader())
+
+	if m.status != nil {
+		b.WriteString(m.renderTree())
+		b.WriteString("\n")
+		b.WriteString(m.renderProgress())
+		b.WriteString("\n")
+		b.WriteString(m.renderFailed())
+	}
+
+	b.WriteString("\n")
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top,
+		m.watch.renderStatus(),
+		FeintStyle.Render("↑/↓: failed runs  enter: error  ←/→: scroll dates  r: refresh  q: quit"),
+	))
+	return b.String()
+}
+
+func (m *replayStatusModel) renderHeader() string {
+	b := &strings.Builder{}
+	b.WriteString("\n")
+	if m.status == nil {
+		b.WriteString(BoldStyle.Render("Replay "+m.id) + "\n")
+	} else {
+		b.WriteString(BoldStyle.Render("Replay of "+m.status.JobName) + "  " + renderReplayState(m.status.State) + "\n")
+		b.WriteString(FeintStyle.Render(fmt.Sprintf("%s → %s, submitted %s, id %s",
+			m.status.StartDate, m.status.EndDate, m.status.CreatedAt.Local().Format(runTimeFormat), m.id)) + "\n")
+	}
+	if m.err != nil {
+		b.WriteString(RenderError(truncate.StringWithTail(m.err.Error(), uint(m.width-10), "…")) + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (m *replayStatusModel) renderTree() string {
+	b := &strings.Builder{}
+
+	visible := (m.width - replayJobColumnWidth) / replayCellColumnWidth
+	if visible < 1 {
+		visible = 1
+	}
+	days := m.days[ints.Min(m.dayOffset, len(m.days)):]
+	if len(days) > visible {
+		days = days[:visible]
+	}
+
+	header := column("", replayJobColumnWidth)
+	for _, day := range days {
+		t, _ := time.Parse(dateFormat, day)
+		header += column(t.Format(replayDayFormat), replayCellColumnWidth)
+	}
+	b.WriteString(FeintStyle.Render(header) + "\n")
+
+	for _, row := range m.rows {
+		b.WriteString(FeintStyle.Render(row.prefix))
+		b.WriteString(column(row.job, replayJobColumnWidth-lipgloss.Width(row.prefix)))
+		for _, day := range days {
+			b.WriteString(lipgloss.NewStyle().Width(replayCellColumnWidth).Render(renderCell(row.days[day])))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderCell(state string) string {
+	switch state {
+	case optimus.RunStateSuccess:
+		return lipgloss.NewStyle().Foreground(Green).Render("■")
+	case optimus.RunStateFailed:
+		return lipgloss.NewStyle().Foreground(Red).Render("■")
+	case optimus.RunStateRunning:
+		return lipgloss.NewStyle().Foreground(Orange).Render("■")
+	case optimus.RunStatePending:
+		return FeintStyle.Render("□")
+	}
+	return " "
+}
+
+func renderReplayState(state string) string {
+	switch state {
+	case optimus.ReplayStateSuccess:
+		return RenderState(optimus.RunStateSuccess)
+	case optimus.ReplayStateFailed:
+		return RenderState(optimus.RunStateFailed)
+	case optimus.ReplayStateInProgress:
+		return RenderState(optimus.RunStateRunning)
+	}
+	return RenderState(state)
+}
+
+func (m *replayStatusModel) renderProgress() string {
+	total := 0
+	for _, count := range m.counts {
+		total += count
+	}
+	done := m.counts[optimus.RunStateSuccess] + m.counts[optimus.RunStateFailed]
+
+	percent := 0.0
+	if total > 0 {
+		percent = float64(done) / float64(total)
+	}
+
+	counters := fmt.Sprintf("  %d/%d  ", done, total) +
+		lipgloss.NewStyle().Foreground(Green).Render(fmt.Sprintf("success %d", m.counts[optimus.RunStateSuccess])) + "  " +
+		lipgloss.NewStyle().Foreground(Orange).Render(fmt.Sprintf("running %d", m.counts[optimus.RunStateRunning])) + "  " +
+		FeintStyle.Render(fmt.Sprintf("pending %d", m.counts[optimus.RunStatePending])) + "  " +
+		lipgloss.NewStyle().Foreground(Red).Render(fmt.Sprintf("failed %d", m.counts[optimus.RunStateFailed]))
+	return m.progress.ViewAs(percent) + counters + "\n"
+}
+
+func (m *replayStatusModel) renderFailed() string {
+	if len(m.failed) == 0 {
+		return ""
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Copy().Foreground(Red).Render(fmt.Sprintf("Failed runs (%d)", len(m.failed))) + "\n")
+	for i, cell := range m.failed {
+		cursor := "  "
+		style := TextStyle
+		if i == m.cursor {
+			cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+			style = BoldStyle.Copy().Foreground(Teal)
+		}
+		b.WriteString(cursor + style.Render(column(cell.job, replayJobColumnWidth)) + cell.run.Run.Format(runTimeFormat) + "\n")
+
+		if i == m.cursor && m.showMessage {
+			message := cell.run.Message
+			if message == "" {
+				message = "optimus did not report an error message"
+			}
+			box := lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(Red).
+				Padding(0, 1).
+				MarginLeft(2).
+				Render(wordwrap.String(message, ints.Max(m.width-10, 20)))
+			b.WriteString(box + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,512 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/job"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/snapshot"
+)
+
+const (
+	// runsPageDays is the range of scheduled dates fetched for every page
+	runsPageDays = 30
+
+	timeColumnWidth     = 22
+	typeColumnWidth     = 11
+	runStateColumnWidth = 10
+	durationColumnWidth = 12
+	attemptColumnWidth  = 9
+
+	runsHeaderHeight = 5
+	runsStatusHeight = 2
+
+	runTimeFormat = "2006-01-02 15:04:05"
+)
+
+// runStateFilters are cycled through with tab, an empty filter shows all runs
+var runStateFilters = []string{"", optimus.RunStateSuccess, optimus.RunStateFailed, optimus.RunStateRunning, optimus.RunStatePending}
+
+type runsLoadedMsg struct {
+	spec  *optimus.JobSpec
+	runs  []optimus.JobRun
+	until time.Time
+	err   error
+
+	// cached is set for runs read from a snapshot fetched at fetchedAt
+	cached    bool
+	fetchedAt time.Time
+}
+
+// RunsOptions selects the job whose runs are shown
+type RunsOptions struct {
+	Project   string
+	Namespace string
+	Job       string
+
+	// Snapshots keeps the latest page of runs, it is shown until the server answers
+	Snapshots *snapshot.Store
+
+	// Offline only reads the snapshots and never queries the server
+	Offline bool
+
+	// Embedded is set when the runs are opened from another view, which esc goes back to
+	Embedded bool
+}
+
+// NewRunsModel renders the run history of a job
+func NewRunsModel(client *optimus.Client, opts RunsOptions) (*runsModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	l := list.New(nil, runDelegate{}, width, height-runsHeaderHeight-runsStatusHeight)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	l.SetShowFilter(false)
+	l.SetFilteringEnabled(false)
+	l.SetStatusBarItemName("run", "runs")
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+
+	return &runsModel{
+		width:     width,
+		height:    height,
+		client:    client,
+		project:   opts.Project,
+		namespace: opts.Namespace,
+		job:       opts.Job,
+		snapshots: opts.Snapshots,
+		offline:   opts.Offline,
+		embedded:  opts.Embedded,
+		loading:   true,
+		runList:   l,
+		spinner:   s,
+	}, nil
+}
+
+type runsModel struct {
+	width  int
+	height int
+
+	client    *optimus.Client
+	project   string
+	namespace string
+	job       string
+
+	spec   *optimus.JobSpec
+	window *job.DataWindow
+	runs   []optimus.JobRun
+	err    error
+
+	// loadedFrom is the oldest scheduled time fetched so far
+	loadedFrom time.Time
+	loading    bool
+	filter     int
+
+	snapshots *snapshot.Store
+	offline   bool
+	embedded  bool
+	// staleSince is the fetch time of the snapshot shown, zero once the server answered
+	staleSince time.Time
+
+	runList list.Model
+	spinner spinner.Model
+	logs    *logsModel
+}
+
+// Ensure that runsModel fulfils the tea.Model interface.
+var _ tea.Model = (*runsModel)(nil)
+
+func (m *runsModel) Init() tea.Cmd {
+	switch {
+	case m.offline:
+		return m.loadSnapshot
+	case m.snapshots != nil:
+		return tea.Batch(m.spinner.Tick, m.loadSnapshot, m.fetchPage(time.Now()))
+	}
+	return tea.Batch(m.spinner.Tick, m.fetchPage(time.Now()))
+}
+
+// loadSnapshot reads the runs saved by the last fetch, a missing snapshot is
+// only an error when offline
+func (m *runsModel) loadSnapshot() tea.Msg {
+	snap, err := m.snapshots.Runs(m.project, m.namespace, m.job)
+	if err == nil && snap == nil {
+		if !m.offline {
+			return nil
+		}
+		err = fmt.Errorf("no snapshot of the runs of %s, open them without --offline first", m.job)
+	}
+	if err != nil {
+		return runsLoadedMsg{err: err, cached: true}
+	}
+	return runsLoadedMsg{spec: snap.Spec, runs: snap.Runs, until: snap.Until, cached: true, fetchedAt: snap.FetchedAt}
+}
+
+// fetchPage loads the runs scheduled in the page of days ending at until
+func (m *runsModel) fetchPage(until time.Time) tea.Cmd {
+	spec := m.spec
+	first := m.loadedFrom.IsZero()
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		if spec == nil {
+			var err error
+			spec, err = m.client.GetJobSpec(ctx, m.project, m.namespace, m.job)
+			if err != nil {
+				return runsLoadedMsg{err: err}
+			}
+		}
+
+		runs, err := m.client.ListJobRuns(ctx, m.project, m.job, optimus.JobRunFilter{
+			StartDate: until.AddDate(0, 0, -runsPageDays),
+			EndDate:   until,
+		})
+		if err == nil && first && m.snapshots != nil {
+			// the snapshot only speeds up the next start, failing to save it is not an error
+			_ = m.snapshots.SaveRuns(m.project, m.namespace, snapshot.Runs{
+				FetchedAt: time.Now(),
+				Spec:      spec,
+				Runs:      runs,
+				Until:     until,
+			})
+		}
+		return runsLoadedMsg{spec: spec, runs: runs, until: until, err: err}
+	}
+}
+
+func (m *runsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.logs != nil {
+		if handled, cmd := m.updateLogs(msg); handled {
+			return m, cmd
+		}
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.runList.SetSize(msg.Width, msg.Height-runsHeaderHeight-runsStatusHeight)
+		return m, nil
+	case runsLoadedMsg:
+		if msg.cached {
+			return m, m.showSnapshot(msg)
+		}
+		m.loading = false
+		m.err = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+		if !m.staleSince.IsZero() {
+			// replace the runs of the snapshot with the ones of the server
+			m.spec = nil
+			m.runs = nil
+			m.staleSince = time.Time{}
+		}
+		if m.spec == nil {
+			m.spec = msg.spec
+			m.window, _ = job.NewDataWindow(msg.spec.WindowSize, msg.spec.WindowOffset, msg.spec.WindowTruncateTo)
+		}
+		m.runs = append(m.runs, olderRuns(msg.runs, m.loadedFrom)...)
+		sort.Slice(m.runs, func(i, j int) bool { return m.runs[i].ScheduledAt.After(m.runs[j].ScheduledAt) })
+		m.loadedFrom = msg.until.AddDate(0, 0, -runsPageDays)
+		return m, m.refreshItems()
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		case tea.KeyTab:
+			m.filter = (m.filter + 1) % len(runStateFilters)
+			return m, m.refreshItems()
+		case tea.KeyShiftTab:
+			m.filter = (m.filter + len(runStateFilters) - 1) % len(runStateFilters)
+			return m, m.refreshItems()
+		}
+
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "m":
+			if m.loading || m.loadedFrom.IsZero() {
+				return m, nil
+			}
+			m.loading = true
+			return m, tea.Batch(m.spinner.Tick, m.fetchPage(m.loadedFrom))
+		case "l":
+			if m.offline {
+				m.err = fmt.Errorf("logs are not available offline")
+				return m, nil
+			}
+			if item, ok := m.runList.SelectedItem().(runItem); ok {
+				return m, m.openLogs(item.run.ScheduledAt)
+			}
+		}
+	}
+
+	m.runList, cmd = m.runList.Update(msg)
+	return m, cmd
+}
+
+// showSnapshot renders the runs of a snapshot, unless the server answered first
+func (m *runsModel) showSnapshot(msg runsLoadedMsg) tea.Cmd {
+	if !m.offline && (msg.err != nil || !m.loadedFrom.IsZero()) {
+		return nil
+	}
+	if m.offline {
+		m.loading = false
+		m.err = msg.err
+		if msg.err != nil {
+			return nil
+		}
+	}
+
+	m.spec = msg.spec
+	m.window, _ = job.NewDataWindow(msg.spec.WindowSize, msg.spec.WindowOffset, msg.spec.WindowTruncateTo)
+	m.runs = msg.runs
+	sort.Slice(m.runs, func(i, j int) bool { return m.runs[i].ScheduledAt.After(m.runs[j].ScheduledAt) })
+	m.staleSince = msg.fetchedAt
+	return m.refreshItems()
+}
+
+// openLogs switches to the logs of the run scheduled at scheduledAt, the view
+// stays on the runs with the error when they cannot be opened
+func (m *runsModel) openLogs(scheduledAt time.Time) tea.Cmd {
+	logs, err := NewLogsModel(m.client, LogsOptions{
+		Project: m.project,
+		Job:     m.job,
+		Run:     scheduledAt,
+	})
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.logs = logs
+	m.logs.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+	return m.logs.Init()
+}
+
+// updateLogs forwards messages to the logs, it reports false for messages
+// which still need to be handled by the runs view
+func (m *runsModel) updateLogs(msg tea.Msg) (bool, tea.Cmd) {
+	switch msg := msg.(type) {
+	case runsLoadedMsg:
+		return false, nil
+	case tea.WindowSizeMsg:
+		m.logs.Update(msg)
+		return false, nil
+	case tea.KeyMsg:
+		if (msg.Type == tea.KeyEsc || msg.Type == tea.KeyBackspace) && !m.logs.capturingInput() {
+			m.logs = nil
+			return true, nil
+		}
+	}
+
+	_, cmd := m.logs.Update(msg)
+	return true, cmd
+}
+
+// handlesBack reports if esc is used within the view rather than to leave it
+func (m *runsModel) handlesBack() bool {
+	return m.logs != nil
+}
+
+// olderRuns drops runs already fetched by a previous page, pages share their boundary
+func olderRuns(runs []optimus.JobRun, loadedFrom time.Time) []optimus.JobRun {
+	if loadedFrom.IsZero() {
+		return runs
+	}
+	var older []optimus.JobRun
+	for _, r := range runs {
+		if r.ScheduledAt.Before(loadedFrom) {
+			older = append(older, r)
+		}
+	}
+	return older
+}
+
+func (m *runsModel) refreshItems() tea.Cmd {
+	state := runStateFilters[m.filter]
+
+	var items []list.Item
+	for _, r := range m.runs {
+		if state != "" && r.State != state {
+			continue
+		}
+		item := runItem{run: r}
+		if m.window != nil {
+			item.windowStart, item.windowEnd = m.window.GetNextInterval(r.ScheduledAt)
+		}
+		items = append(items, item)
+	}
+	return m.runList.SetItems(items)
+}
+
+func (m *runsModel) View() string {
+	if m.logs != nil {
+		return m.logs.View()
+	}
+	b := &strings.Builder{}
+	b.WriteString(m.renderHeader())
+	b.WriteString(m.runList.View())
+	b.WriteString("\n")
+	b.WriteString(m.renderStatus())
+	return b.String()
+}
+
+func (m *runsModel) renderHeader() string {
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render("Runs of " + m.job))
+	if !m.staleSince.IsZero() {
+		b.WriteString(renderStale(m.staleSince, m.offline))
+	}
+	if m.loading {
+		b.WriteString("  " + m.spinner.View() + FeintStyle.Render(" fetching"))
+	}
+	b.WriteString("\n")
+
+	if m.err != nil {
+		b.WriteString(RenderError(truncate.StringWithTail(m.err.Error(), uint(m.width-10), "…")))
+	} else if m.spec != nil {
+		schedule := m.spec.Interval
+		if human := describeCron(schedule); human != "" {
+			schedule += " (" + human + ")"
+		}
+		b.WriteString(FeintStyle.Render(schedule + ", window " + orDash(m.spec.WindowSize) + " truncated to " + orDash(m.spec.WindowTruncateTo)))
+	}
+	b.WriteString("\n\n")
+
+	header := column("SCHEDULED AT", timeColumnWidth) + column("TYPE", typeColumnWidth) +
+		column("STATE", runStateColumnWidth) + column("STARTED", timeColumnWidth) +
+		column("ENDED", timeColumnWidth) + column("DURATION", durationColumnWidth) +
+		column("ATTEMPT", attemptColumnWidth) + "WINDOW"
+	b.WriteString(FeintStyle.Render("  "+header) + "\n")
+	return b.String()
+}
+
+func (m *runsModel) renderStatus() string {
+	filter := "all"
+	if state := runStateFilters[m.filter]; state != "" {
+		filter = state
+	}
+
+	loaded := "-"
+	if !m.loadedFrom.IsZero() {
+		loaded = "since " + m.loadedFrom.Format(dateFormat)
+	}
+
+	help := "tab: filter state  l: logs  m: load older  ←/→: page  "
+	if m.offline {
+		help = "tab: filter state  ←/→: page  "
+	}
+	if m.embedded {
+		help += "esc: back  "
+	}
+	help += "q: quit"
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar(
+			"Job", m.job,
+			"State", filter,
+			"Runs", strconv.Itoa(len(m.runs)),
+			"Loaded", loaded,
+		),
+		FeintStyle.Render(help),
+	)
+}
+
+type runItem struct {
+	run optimus.JobRun
+
+	windowStart time.Time
+	windowEnd   time.Time
+}
+
+func (i runItem) FilterValue() string { return i.run.State }
+
+// runDelegate renders a run as a single row of columns
+type runDelegate struct{}
+
+func (d runDelegate) Height() int                               { return 1 }
+func (d runDelegate) Spacing() int                              { return 0 }
+func (d runDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d runDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	r, ok := item.(runItem)
+	if !ok {
+		return
+	}
+	run := r.run
+
+	cursor := "  "
+	timeStyle := TextStyle
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+		timeStyle = BoldStyle.Copy().Foreground(Teal)
+	}
+
+	runType := run.Type
+	if runType == "" {
+		runType = optimus.RunTypeScheduled
+	}
+
+	window := "-"
+	if !r.windowEnd.IsZero() {
+		window = r.windowStart.Format(runTimeFormat) + " → " + r.windowEnd.Format(runTimeFormat)
+	}
+
+	row := cursor +
+		timeStyle.Render(column(run.ScheduledAt.Format(runTimeFormat), timeColumnWidth)) +
+		column(runType, typeColumnWidth) +
+		lipgloss.NewStyle().Width(runStateColumnWidth).Render(RenderState(run.State)) +
+		column(formatRunTime(run.StartTime), timeColumnWidth) +
+		column(formatRunTime(run.EndTime), timeColumnWidth) +
+		column(formatRunDuration(run), durationColumnWidth) +
+		column(formatAttempt(run.Attempt), attemptColumnWidth) +
+		FeintStyle.Render(window)
+	fmt.Fprint(w, row)
+}
+
+func formatRunTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(runTimeFormat)
+}
+
+func formatRunDuration(run optimus.JobRun) string {
+	d := run.Duration()
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+func formatAttempt(attempt int) string {
+	if attempt == 0 {
+		return "-"
+	}
+	return strconv.Itoa(attempt)
+}
@@ -0,0 +1,538 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/optimus"
+	"github.com/sbchaos/mirage/sla"
+)
+
+const (
+	slaJobColumnWidth       = 36
+	slaColumnWidth          = 8
+	slaScheduledColumnWidth = 13
+	slaStatusColumnWidth    = 10
+	slaCountdownColumnWidth = 18
+	slaBreachColumnWidth    = 10
+
+	slaHeaderHeight = 4
+	slaStatusHeight = 2
+
+	slaTimeFormat = "01-02 15:04"
+)
+
+// slaFilters are cycled through with tab, an empty filter shows all jobs
+var slaFilters = []string{"", sla.StatusBreached, sla.StatusAtRisk}
+
+// sparkBlocks are the levels of a sparkline, the highest is 150% of the SLA
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+type slaLoadedMsg struct {
+	// namespace is project/namespace the reports were evaluated for
+	namespace string
+	reports   []*sla.Report
+	err       error
+}
+
+type slaClockMsg struct{}
+
+// SLAOptions selects the namespace and range of the SLA dashboard
+type SLAOptions struct {
+	Project   string
+	Namespace string
+
+	// Days is the number of days of runs evaluated and shown in the trend
+	Days int
+
+	// DefaultSLA applies to jobs without an SLA in their spec, they are
+	// skipped when zero
+	DefaultSLA time.Duration
+
+	// Refresh is the polling interval, polling is disabled when zero
+	Refresh time.Duration
+
+	// OnSwitch is called when another namespace is picked in the switcher
+	OnSwitch SwitchFunc
+}
+
+// NewSLAModel renders the SLA of the jobs of a namespace with their recent breaches
+func NewSLAModel(client *optimus.Client, opts SLAOptions) (*slaModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	m := &slaModel{
+		width:      width,
+		height:     height,
+		client:     client,
+		project:    opts.Project,
+		namespace:  opts.Namespace,
+		days:       opts.Days,
+		defaultSLA: opts.DefaultSLA,
+		watch:      newWatcher(opts.Refresh),
+		loading:    true,
+		now:        time.Now(),
+		detail:     viewport.New(width, ints.Max(height-slaStatusHeight, 1)),
+		switcher:   newSwitcherOverlay(client, opts.OnSwitch),
+	}
+
+	m.jobList = list.New(nil, slaDelegate{now: &m.now, days: opts.Days}, width, ints.Max(height-slaHeaderHeight-slaStatusHeight, 1))
+	m.jobList.SetShowTitle(false)
+	m.jobList.SetShowHelp(false)
+	m.jobList.SetShowFilter(false)
+	m.jobList.SetFilteringEnabled(false)
+	m.jobList.SetStatusBarItemName("job", "jobs")
+
+	m.spinner = spinner.New()
+	m.spinner.Spinner = spinner.Dot
+	return m, nil
+}
+
+type slaModel struct {
+	width  int
+	height int
+
+	client     *optimus.Client
+	project    string
+	namespace  string
+	days       int
+	defaultSLA time.Duration
+
+	reports []*sla.Report
+	err     error
+	watch   *watcher
+	loading bool
+	filter  int
+
+	// now is the time countdowns are rendered at, it advances every second
+	now time.Time
+
+	showDetail bool
+	jobList    list.Model
+	spinner    spinner.Model
+	detail     viewport.Model
+	switcher   switcherOverlay
+}
+
+// Ensure that slaModel fulfils the tea.Model interface.
+var _ tea.Model = (*slaModel)(nil)
+
+func (m *slaModel) Init() tea.Cmd {
+	return tea.Batch(m.spinner.Tick, m.fetchReports, tickClock())
+}
+
+func (m *slaModel) fetchReports() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	reports, err := sla.Load(ctx, m.client, m.project, m.namespace, m.days, m.defaultSLA, time.Now())
+	return slaLoadedMsg{namespace: m.project + "/" + m.namespace, reports: reports, err: err}
+}
+
+// switchNamespace evaluates the jobs of another namespace
+func (m *slaModel) switchNamespace(project, namespace string) tea.Cmd {
+	m.project = project
+	m.namespace = namespace
+	m.reports = nil
+	m.err = nil
+	m.showDetail = false
+	m.loading = true
+	return tea.Batch(m.spinner.Tick, m.jobList.SetItems(nil), m.fetchReports)
+}
+
+func tickClock() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return slaClockMsg{}
+	})
+}
+
+func (m *slaModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if handled, picked, cmd := m.switcher.update(msg, m.project, m.namespace); handled {
+		if picked != nil {
+			return m, m.switchNamespace(picked.project, picked.namespace)
+		}
+		return m, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.jobList.SetSize(msg.Width, ints.Max(msg.Height-slaHeaderHeight-slaStatusHeight, 1))
+		m.detail.Width = msg.Width
+		m.detail.Height = ints.Max(msg.Height-slaStatusHeight, 1)
+		return m, nil
+	case slaLoadedMsg:
+		if msg.namespace != m.project+"/"+m.namespace {
+			// evaluated before switching to another namespace
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		m.watch.done(msg.err)
+		if msg.err == nil {
+			m.reports = msg.reports
+			cmd = m.refreshItems()
+			if m.showDetail {
+				m.setDetail()
+			}
+		}
+		return m, tea.Batch(cmd, m.watch.schedule())
+	case slaClockMsg:
+		m.now = time.Now()
+		return m, tickClock()
+	case watchTickMsg:
+		if !m.watch.tick(msg) {
+			return m, nil
+		}
+		m.loading = true
+		return m, tea.Batch(m.spinner.Tick, m.fetchReports)
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlBackslash:
+			return m, tea.Quit
+		}
+
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "r":
+			if m.loading {
+				return m, nil
+			}
+			m.loading = true
+			return m, tea.Batch(m.spinner.Tick, m.fetchReports)
+		}
+
+		if m.showDetail {
+			if msg.Type == tea.KeyEsc || msg.Type == tea.KeyBackspace {
+				m.showDetail = false
+				return m, nil
+			}
+			m.detail, cmd = m.detail.Update(msg)
+			return m, cmd
+		}
+
+		switch msg.Type {
+		case tea.KeyTab:
+			m.filter = (m.filter + 1) % len(slaFilters)
+			return m, m.refreshItems()
+		case tea.KeyShiftTab:
+			m.filter = (m.filter + len(slaFilters) - 1) % len(slaFilters)
+			return m, m.refreshItems()
+		case tea.KeyEnter:
+			if _, ok := m.jobList.SelectedItem().(slaItem); ok {
+				m.showDetail = true
+				m.setDetail()
+				m.detail.GotoTop()
+			}
+			return m, nil
+		}
+	}
+
+	if m.showDetail {
+		return m, nil
+	}
+	m.jobList, cmd = m.jobList.Update(msg)
+	return m, cmd
+}
+
+func (m *slaModel) refreshItems() tea.Cmd {
+	status := slaFilters[m.filter]
+
+	var items []list.Item
+	for _, r := range m.reports {
+		if status != "" && (r.Current() == nil || r.Current().Status != status) {
+			continue
+		}
+		items = append(items, slaItem{report: r})
+	}
+	return m.jobList.SetItems(items)
+}
+
+// setDetail renders the results of the selected job
+func (m *slaModel) setDetail() {
+	item, ok := m.jobList.SelectedItem().(slaItem)
+	if !ok {
+		m.showDetail = false
+		return
+	}
+	r := item.report
+
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render(r.Spec.Name) + "\n")
+	typical := "-"
+	if r.Typical > 0 {
+		typical = formatSLADuration(r.Typical) + " after the scheduled time"
+	}
+	b.WriteString(FeintStyle.Render(fmt.Sprintf("SLA %s, typically done %s, %d of %d runs breached in the last %d days",
+		formatSLADuration(r.SLA), typical, r.Breaches(), len(r.Results), m.days)) + "\n\n")
+
+	header := column("SCHEDULED AT", timeColumnWidth) + column("STATE", runStateColumnWidth) +
+		column("ENDED", timeColumnWidth) + column("DEADLINE", timeColumnWidth) + "RESULT"
+	b.WriteString(FeintStyle.Render(header) + "\n")
+	for _, res := range r.Results {
+		state, ended := "not created", "-"
+		if res.Run != nil {
+			state = res.Run.State
+			if !res.Run.EndTime.IsZero() {
+				ended = formatRunTime(res.Run.EndTime)
+			}
+		}
+		b.WriteString(column(formatRunTime(res.ScheduledAt), timeColumnWidth) +
+			RenderState(column(state, runStateColumnWidth)) +
+			column(ended, timeColumnWidth) +
+			column(formatRunTime(res.Deadline), timeColumnWidth) +
+			renderSLAResult(res, m.now) + "\n")
+	}
+	m.detail.SetContent(b.String())
+}
+
+func (m *slaModel) View() string {
+	if m.switcher.open() {
+		return m.switcher.view(m.width, m.height)
+	}
+	if m.showDetail {
+		return m.detail.View() + "\n" + m.renderStatus()
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(m.renderHeader())
+	if m.reports == nil && m.loading {
+		b.WriteString("\n " + m.spinner.View() + " Evaluating the SLA of jobs in " + BoldStyle.Render(m.project+"/"+m.namespace) + "\n")
+		return b.String()
+	}
+	if m.reports != nil && len(m.reports) == 0 {
+		b.WriteString(FeintStyle.Render("  No job declares an SLA, add an sla_miss notifier with a duration to their spec or use --sla") + "\n")
+	}
+	b.WriteString(m.jobList.View())
+	b.WriteString("\n")
+	b.WriteString(m.renderStatus())
+	return b.String()
+}
+
+func (m *slaModel) renderHeader() string {
+	b := &strings.Builder{}
+	b.WriteString(BoldStyle.Render(fmt.Sprintf("SLA of %s/%s, last %d days", m.project, m.namespace, m.days)))
+
+	counts := map[string]int{}
+	for _, r := range m.reports {
+		if c := r.Current(); c != nil {
+			counts[c.Status]++
+		}
+	}
+	if n := counts[sla.StatusBreached]; n > 0 {
+		b.WriteString("  " + lipgloss.NewStyle().Foreground(Red).Bold(true).Render(fmt.Sprintf("%d breached", n)))
+	}
+	if n := counts[sla.StatusAtRisk]; n > 0 {
+		b.WriteString("  " + lipgloss.NewStyle().Foreground(Orange).Bold(true).Render(fmt.Sprintf("%d at risk", n)))
+	}
+	if m.loading && m.reports != nil {
+		b.WriteString("  " + m.spinner.View() + FeintStyle.Render(" refreshing"))
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(RenderError(truncate.StringWithTail(m.err.Error(), uint(m.width-10), "…")))
+	} else {
+		b.WriteString(m.switcher.renderErr(m.width))
+	}
+	b.WriteString("\n")
+
+	header := "  " + column("JOB", slaJobColumnWidth) + column("SLA", slaColumnWidth) +
+		column("SCHEDULED", slaScheduledColumnWidth) + column("STATUS", slaStatusColumnWidth) +
+		column("DEADLINE", slaCountdownColumnWidth) + column("BREACHES", slaBreachColumnWidth) + "TREND"
+	b.WriteString(FeintStyle.Render(header) + "\n")
+	return b.String()
+}
+
+func (m *slaModel) renderStatus() string {
+	filter := "all"
+	if status := slaFilters[m.filter]; status != "" {
+		filter = status
+	}
+	help := "enter: runs  tab: filter  r: refresh  ctrl+p: switch  q: quit"
+	if m.showDetail {
+		help = "↑/↓: scroll  esc: back  ctrl+p: switch  q: quit"
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		renderStatusBar(
+			"Project", m.project,
+			"Namespace", m.namespace,
+			"Jobs", strconv.Itoa(len(m.reports)),
+			"Show", filter,
+		),
+		m.watch.renderStatus(),
+		FeintStyle.Render(help),
+	)
+}
+
+type slaItem struct {
+	report *sla.Report
+}
+
+func (i slaItem) FilterValue() string { return i.report.Spec.Name }
+
+// slaDelegate renders a job as a single row, countdowns are rendered at now
+type slaDelegate struct {
+	now  *time.Time
+	days int
+}
+
+func (d slaDelegate) Height() int                               { return 1 }
+func (d slaDelegate) Spacing() int                              { return 0 }
+func (d slaDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d slaDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	i, ok := item.(slaItem)
+	if !ok {
+		return
+	}
+	r := i.report
+
+	cursor := "  "
+	nameStyle := TextStyle
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+		nameStyle = BoldStyle.Copy().Foreground(Teal)
+	}
+
+	scheduled, status, countdown := "-", FeintStyle.Render(column("-", slaStatusColumnWidth)), "-"
+	if c := r.Current(); c != nil {
+		scheduled = c.ScheduledAt.Local().Format(slaTimeFormat)
+		status = renderSLAStatus(c.Status, slaStatusColumnWidth)
+		countdown = renderCountdown(*c, *d.now)
+	}
+
+	fmt.Fprint(w, cursor+
+		nameStyle.Render(column(r.Spec.Name, slaJobColumnWidth))+
+		column(formatSLADuration(r.SLA), slaColumnWidth)+
+		column(scheduled, slaScheduledColumnWidth)+
+		status+
+		countdown+strings.Repeat(" ", ints.Max(slaCountdownColumnWidth-lipgloss.Width(countdown), 1))+
+		column(fmt.Sprintf("%d/%d", r.Breaches(), len(r.Results)), slaBreachColumnWidth)+
+		sparkline(r.Trend(d.days, *d.now)))
+}
+
+// renderSLAStatus colours an SLA status padded to width
+func renderSLAStatus(status string, width int) string {
+	style := TextStyle.Copy().Bold(true)
+	switch status {
+	case sla.StatusMet, sla.StatusOnTrack:
+		style = style.Foreground(Green)
+	case sla.StatusAtRisk:
+		style = style.Foreground(Orange)
+	case sla.StatusBreached:
+		style = style.Foreground(Red)
+	}
+	return style.Render(column(status, width))
+}
+
+// renderCountdown renders the time left to the deadline of unfinished runs
+// and how early or late finished runs were
+func renderCountdown(res sla.Result, now time.Time) string {
+	finished := res.Run != nil && res.Run.State == optimus.RunStateSuccess && !res.Run.EndTime.IsZero()
+	switch {
+	case finished:
+		return renderSLAResult(res, now)
+	case now.After(res.Deadline):
+		return lipgloss.NewStyle().Foreground(Red).Render(formatCountdown(now.Sub(res.Deadline)) + " over")
+	}
+	style := lipgloss.NewStyle().Foreground(Green)
+	if res.Status == sla.StatusAtRisk {
+		style = style.Foreground(Orange)
+	}
+	return style.Render("in " + formatCountdown(res.Deadline.Sub(now)))
+}
+
+// renderSLAResult renders the margin or lateness of a result
+func renderSLAResult(res sla.Result, now time.Time) string {
+	finished := res.Run != nil && res.Run.State == optimus.RunStateSuccess && !res.Run.EndTime.IsZero()
+	switch {
+	case finished && res.Lateness > 0:
+		return lipgloss.NewStyle().Foreground(Red).Render(formatSLADuration(res.Lateness) + " late")
+	case finished:
+		return FeintStyle.Render(formatSLADuration(-res.Lateness) + " early")
+	case now.After(res.Deadline):
+		return lipgloss.NewStyle().Foreground(Red).Render("not done, " + formatSLADuration(now.Sub(res.Deadline)) + " over")
+	}
+	return renderSLAStatus(res.Status, 0)
+}
+
+// formatCountdown renders a duration with seconds when less than an hour is left
+func formatCountdown(d time.Duration) string {
+	if d < time.Hour {
+		return d.Truncate(time.Second).String()
+	}
+	return formatSLADuration(d)
+}
+
+// formatSLADuration renders a duration to the minute, like 2h5m or 3d4h
+func formatSLADuration(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	days := int(d / (24 * time.Hour))
+	d -= time.Duration(days) * 24 * time.Hour
+	hours := int(d / time.Hour)
+	minutes := int((d - time.Duration(hours)*time.Hour) / time.Minute)
+
+	var b strings.Builder
+	if days > 0 {
+		fmt.Fprintf(&b, "%dd", days)
+	}
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dh", hours)
+	}
+	if minutes > 0 && days == 0 {
+		fmt.Fprintf(&b, "%dm", minutes)
+	}
+	return b.String()
+}
+
+// sparkline renders values relative to the SLA, a value of 1 is the deadline,
+// negative values are days without runs
+func sparkline(values []float64) string {
+	var b strings.Builder
+	for _, v := range values {
+		if v < 0 {
+			b.WriteString(FeintStyle.Render("·"))
+			continue
+		}
+		level := int(v / 1.5 * float64(len(sparkBlocks)-1))
+		if level >= len(sparkBlocks) {
+			level = len(sparkBlocks) - 1
+		}
+		color := Green
+		switch {
+		case v > 1:
+			color = Red
+		case v > 0.75:
+			color = Orange
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(color).Render(string(sparkBlocks[level])))
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// renderAge renders how long ago t was, to the minute
+func renderAge(t time.Time) string {
+	age := time.Since(t).Truncate(time.Minute)
+	if age < time.Minute {
+		return "just now"
+	}
+	return humanizeDuration(age) + " ago"
+}
+
+// renderStale marks data shown from a snapshot, while online it is replaced
+// once the server answers
+func renderStale(fetchedAt time.Time, offline bool) string {
+	style := lipgloss.NewStyle().Foreground(Orange)
+	if offline {
+		return style.Render("  offline, data from " + renderAge(fetchedAt))
+	}
+	return style.Render("  cached " + renderAge(fetchedAt) + ", refreshing…")
+}
+
+// renderOfflineStatus replaces the connection status of the watcher in offline mode
+func renderOfflineStatus(fetchedAt time.Time) string {
+	fetched := "never"
+	if !fetchedAt.IsZero() {
+		fetched = fetchedAt.Format("2006-01-02 15:04")
+	}
+	return renderStatusBar("Fetched", fetched, "Server", lipgloss.NewStyle().Foreground(Orange).Render("●")+" offline")
+}
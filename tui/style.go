@@ -1,6 +1,9 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+)
 
 var (
 	Color   = lipgloss.AdaptiveColor{Light: "#111222", Dark: "#FAFAFA"}
@@ -33,3 +36,51 @@ func RenderWarning(msg string) string {
 	content := lipgloss.NewStyle().Bold(true).Foreground(Orange).Padding(0, 1).Render(msg)
 	return err + content
 }
+
+var (
+	statusKeyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFDF5")).
+			Background(lipgloss.Color("#6124DF")).
+			Padding(0, 1)
+
+	statusValueStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFFDF5")).
+				Background(lipgloss.Color("#A550DF")).
+				Padding(0, 1).
+				MarginRight(1)
+)
+
+// renderStatusBar renders pairs of label and value in the style of a status bar
+func renderStatusBar(pairs ...string) string {
+	var cells []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		cells = append(cells, statusKeyStyle.Render(pairs[i]), statusValueStyle.Render(pairs[i+1]))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, cells...)
+}
+
+// RenderState returns the state of a run coloured by its outcome
+func RenderState(state string) string {
+	style := TextStyle.Copy().Bold(true)
+	switch state {
+	case "success":
+		style = style.Foreground(Green)
+	case "failed":
+		style = style.Foreground(Red)
+	case "running":
+		style = style.Foreground(Orange)
+	case "":
+		return FeintStyle.Render("-")
+	default:
+		style = style.Foreground(Feint)
+	}
+	return style.Render(state)
+}
+
+// column truncates or pads s to exactly width cells, keeping a space after the text
+func column(s string, width int) string {
+	if lipgloss.Width(s) >= width {
+		s = truncate.StringWithTail(s, uint(width-1), "…")
+	}
+	return lipgloss.NewStyle().Width(width).MaxWidth(width).Render(s)
+}
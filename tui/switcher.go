@@ -0,0 +1,467 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"golang.org/x/term"
+
+	"github.com/sbchaos/mirage/internal/ints"
+	"github.com/sbchaos/mirage/optimus"
+)
+
+const (
+	switcherWidth  = 60
+	switcherHeight = 20
+
+	// switcherKey opens the switcher from the views supporting it
+	switcherKey = "ctrl+p"
+)
+
+// switcherOverlay opens the switcher over a view with switcherKey and takes
+// the messages of the switcher while it is open. Views working on the context
+// embed it and reload their content for the project and namespace picked.
+type switcherOverlay struct {
+	// client is nil for views which cannot switch, like offline ones
+	client   *optimus.Client
+	onSwitch SwitchFunc
+	switcher *switcher
+
+	// err is the error of persisting the last choice as the default
+	err error
+}
+
+func newSwitcherOverlay(client *optimus.Client, onSwitch SwitchFunc) switcherOverlay {
+	return switcherOverlay{client: client, onSwitch: onSwitch}
+}
+
+// update opens the switcher on switcherKey and forwards messages to it while
+// it is open. It reports whether msg was handled, along with the project and
+// namespace picked once the switcher closes with a choice, which is then
+// persisted with onSwitch.
+func (o *switcherOverlay) update(msg tea.Msg, project, namespace string) (bool, *contextSwitchedMsg, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if o.switcher == nil && o.client != nil && msg.String() == switcherKey {
+			o.switcher = newSwitcher(o.client, project, namespace)
+			return true, nil, o.switcher.init()
+		}
+	case contextSwitchedMsg:
+		o.switcher = nil
+		o.err = nil
+		if o.onSwitch != nil {
+			o.err = o.onSwitch(msg.project, msg.namespace)
+		}
+		return true, &msg, nil
+	case switcherClosedMsg:
+		o.switcher = nil
+		return true, nil, nil
+	}
+	if o.switcher == nil {
+		return false, nil, nil
+	}
+
+	switch msg.(type) {
+	case tea.KeyMsg, switcherLoadedMsg, list.FilterMatchesMsg:
+		return true, nil, o.switcher.update(msg)
+	}
+	return false, nil, nil
+}
+
+// enabled reports whether the switcher can be opened, views leave its key
+// out of their help otherwise
+func (o *switcherOverlay) enabled() bool {
+	return o.client != nil
+}
+
+// open reports whether the switcher is shown instead of the view
+func (o *switcherOverlay) open() bool {
+	return o.switcher != nil
+}
+
+func (o *switcherOverlay) view(width, height int) string {
+	return o.switcher.view(width, height)
+}
+
+// renderErr warns that the last choice was not saved, it is empty otherwise
+func (o *switcherOverlay) renderErr(width int) string {
+	if o.err == nil {
+		return ""
+	}
+	return RenderWarning(truncate.StringWithTail("namespace not saved as default: "+o.err.Error(), uint(ints.Max(width-20, 10)), "…"))
+}
+
+// SwitchFunc is called with the project and namespace picked in the switcher,
+// usually to persist them as the default of the profile
+type SwitchFunc func(project, namespace string) error
+
+type switcherLoadedMsg struct {
+	items []list.Item
+	err   error
+}
+
+// contextSwitchedMsg is sent once a project and namespace are picked
+type contextSwitchedMsg struct {
+	project   string
+	namespace string
+}
+
+// switcherClosedMsg is sent when the switcher is closed without a choice
+type switcherClosedMsg struct{}
+
+// switcher lists the namespaces of every project on the server with fuzzy filtering
+type switcher struct {
+	client  *optimus.Client
+	current string
+
+	loading bool
+	err     error
+	list    list.Model
+}
+
+func newSwitcher(client *optimus.Client, project, namespace string) *switcher {
+	l := list.New(nil, switcherDelegate{}, switcherWidth-4, switcherHeight-4)
+	l.SetShowTitle(false)
+	l.SetShowHelp(false)
+	l.SetStatusBarItemName("namespace", "namespaces")
+
+	return &switcher{
+		client:  client,
+		current: project + "/" + namespace,
+		loading: true,
+		list:    l,
+	}
+}
+
+func (s *switcher) init() tea.Cmd {
+	return s.fetch
+}
+
+func (s *switcher) fetch() tea.Msg {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	projects, err := s.client.ListProjects(ctx)
+	if err != nil {
+		return switcherLoadedMsg{err: err}
+	}
+	var items []list.Item
+	for _, p := range projects {
+		namespaces, err := s.client.ListNamespaces(ctx, p.Name)
+		if err != nil {
+			return switcherLoadedMsg{err: err}
+		}
+		for _, ns := range namespaces {
+			items = append(items, switcherItem{project: p.Name, namespace: ns.Name})
+		}
+	}
+	return switcherLoadedMsg{items: items}
+}
+
+func (s *switcher) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case switcherLoadedMsg:
+		s.loading = false
+		s.err = msg.err
+		if msg.err != nil {
+			return nil
+		}
+		cmd = s.list.SetItems(msg.items)
+
+		// start with the filter focused, typing narrows down the list right away
+		var filterCmd tea.Cmd
+		s.list, filterCmd = s.list.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+		for i, item := range msg.items {
+			if item.(switcherItem).id() == s.current {
+				s.list.Select(i)
+			}
+		}
+		return tea.Batch(cmd, filterCmd)
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEsc:
+			if s.list.FilterValue() == "" || !s.list.SettingFilter() {
+				return func() tea.Msg { return switcherClosedMsg{} }
+			}
+		case tea.KeyEnter:
+			item, ok := s.list.SelectedItem().(switcherItem)
+			if !ok {
+				return nil
+			}
+			return func() tea.Msg {
+				return contextSwitchedMsg{project: item.project, namespace: item.namespace}
+			}
+		}
+	}
+
+	s.list, cmd = s.list.Update(msg)
+	if _, ok := msg.(list.FilterMatchesMsg); ok {
+		// highlight the best match of the new filter
+		s.list.Select(0)
+	}
+	return cmd
+}
+
+// view renders the switcher as a box in the middle of the screen
+func (s *switcher) view(width, height int) string {
+	content := BoldStyle.Render("Switch project and namespace") + "\n" +
+		FeintStyle.Render("current "+s.current) + "\n\n"
+	switch {
+	case s.loading:
+		content += FeintStyle.Render("Fetching namespaces…")
+	case s.err != nil:
+		content += RenderError(s.err.Error())
+	default:
+		content += s.list.View()
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(Teal).
+		Padding(0, 1).
+		Width(switcherWidth).
+		Height(switcherHeight).
+		Render(content)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+type switcherItem struct {
+	project   string
+	namespace string
+}
+
+func (i switcherItem) id() string { return i.project + "/" + i.namespace }
+
+func (i switcherItem) FilterValue() string { return i.id() }
+
+// switcherDelegate renders a namespace along with its project
+type switcherDelegate struct{}
+
+func (d switcherDelegate) Height() int                               { return 1 }
+func (d switcherDelegate) Spacing() int                              { return 0 }
+func (d switcherDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+
+func (d switcherDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	i, ok := item.(switcherItem)
+	if !ok {
+		return
+	}
+
+	cursor := "  "
+	style := TextStyle
+	if index == m.Index() {
+		cursor = lipgloss.NewStyle().Foreground(Teal).Render("│ ")
+		style = BoldStyle.Copy().Foreground(Teal)
+	}
+	fmt.Fprint(w, cursor+FeintStyle.Render(i.project+" / ")+style.Render(i.namespace))
+}
+
+// NewSwitchModel runs the switcher on its own and exits once a namespace is picked
+func NewSwitchModel(client *optimus.Client, project, namespace string, onSwitch SwitchFunc) (*switchModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	return &switchModel{
+		width:    width,
+		height:   height,
+		switcher: newSwitcher(client, project, namespace),
+		onSwitch: onSwitch,
+	}, nil
+}
+
+type switchModel struct {
+	width  int
+	height int
+
+	switcher *switcher
+	onSwitch SwitchFunc
+
+	project   string
+	namespace string
+	err       error
+}
+
+// Selected returns the project and namespace picked, empty when the switcher
+// was closed, along with the error of persisting the choice
+func (m *switchModel) Selected() (string, string, error) {
+	return m.project, m.namespace, m.err
+}
+
+// Ensure that switchModel fulfils the tea.Model interface.
+var _ tea.Model = (*switchModel)(nil)
+
+func (m *switchModel) Init() tea.Cmd {
+	return m.switcher.init()
+}
+
+func (m *switchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		return m, nil
+	case contextSwitchedMsg:
+		m.project = msg.project
+		m.namespace = msg.namespace
+		if m.onSwitch != nil {
+			m.err = m.onSwitch(msg.project, msg.namespace)
+		}
+		return m, tea.Quit
+	case switcherClosedMsg:
+		return m, tea.Quit
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+	}
+	return m, m.switcher.update(msg)
+}
+
+func (m *switchModel) View() string {
+	return m.switcher.view(m.width, m.height)
+}
+
+// ReloadFunc builds the view shown once another project and namespace are
+// picked in the switcher of a view which cannot reload itself
+type ReloadFunc func(project, namespace string) (tea.Model, error)
+
+type switchableReloadedMsg struct {
+	view tea.Model
+	err  error
+}
+
+// NewSwitchableModel adds the switcher to a view started for a single job, run
+// or replay, which has no content to reload for another namespace. Once a
+// namespace is picked, the view is replaced by the one built by reload. Without
+// reload, like for a deployment in progress, the view is kept and the choice is
+// only persisted with onSwitch.
+func NewSwitchableModel(view tea.Model, client *optimus.Client, project, namespace string, onSwitch SwitchFunc, reload ReloadFunc) (*switchableModel, error) {
+	width, height, _ := term.GetSize(int(os.Stdout.Fd()))
+
+	return &switchableModel{
+		width:     width,
+		height:    height,
+		view:      view,
+		project:   project,
+		namespace: namespace,
+		switcher:  newSwitcherOverlay(client, onSwitch),
+		reload:    reload,
+	}, nil
+}
+
+type switchableModel struct {
+	width  int
+	height int
+
+	view      tea.Model
+	project   string
+	namespace string
+	switcher  switcherOverlay
+	reload    ReloadFunc
+
+	// picked is set once a namespace is picked, replaced once the view of
+	// reload is shown, which brings its own switcher along
+	picked    bool
+	reloading bool
+	replaced  bool
+	err       error
+}
+
+// Ensure that switchableModel fulfils the tea.Model interface.
+var _ tea.Model = (*switchableModel)(nil)
+
+// Switched reports whether another namespace was picked, the view started
+// with is then replaced or left with a choice made elsewhere
+func (m *switchableModel) Switched() bool {
+	return m.picked
+}
+
+func (m *switchableModel) Init() tea.Cmd {
+	return m.view.Init()
+}
+
+func (m *switchableModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+	case switchableReloadedMsg:
+		m.reloading = false
+		m.err = msg.err
+		if msg.err != nil {
+			return m, nil
+		}
+		m.view = msg.view
+		m.replaced = true
+		m.view.Update(tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		return m, m.view.Init()
+	}
+
+	if !m.replaced && !m.reloading {
+		handled, picked, switchCmd := m.switcher.update(msg, m.project, m.namespace)
+		if picked != nil {
+			m.picked = true
+			m.project = picked.project
+			m.namespace = picked.namespace
+			return m, m.reloadView()
+		}
+		if handled {
+			return m, switchCmd
+		}
+	}
+
+	m.view, cmd = m.view.Update(msg)
+	return m, cmd
+}
+
+// reloadView builds the view of the namespace picked
+func (m *switchableModel) reloadView() tea.Cmd {
+	if m.reload == nil {
+		return nil
+	}
+	m.reloading = true
+	reload, project, namespace := m.reload, m.project, m.namespace
+	return func() tea.Msg {
+		view, err := reload(project, namespace)
+		return switchableReloadedMsg{view: view, err: err}
+	}
+}
+
+func (m *switchableModel) View() string {
+	if m.switcher.open() {
+		return m.switcher.view(m.width, m.height)
+	}
+
+	var status string
+	switch {
+	case m.reloading:
+		status = FeintStyle.Render(fmt.Sprintf("Opening %s/%s…", m.project, m.namespace))
+	case m.err != nil:
+		status = RenderWarning(truncate.StringWithTail(fmt.Sprintf("%s/%s not opened: %s", m.project, m.namespace, m.err), uint(ints.Max(m.width-20, 10)), "…"))
+	case m.switcher.err != nil:
+		status = m.switcher.renderErr(m.width)
+	case m.picked && m.reload == nil:
+		status = FeintStyle.Render(fmt.Sprintf("%s/%s is the default namespace now", m.project, m.namespace))
+	}
+	if status == "" {
+		return m.view.View()
+	}
+
+	// the status takes the last line of views filling the screen
+	lines := strings.Split(m.view.View(), "\n")
+	if len(lines) > m.height-1 && m.height > 1 {
+		lines = lines[:m.height-1]
+	}
+	return strings.Join(append(lines, status), "\n")
+}
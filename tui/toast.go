@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+	"github.com/muesli/reflow/wordwrap"
+)
+
+const (
+	toastDuration = 10 * time.Second
+	toastWidth    = 48
+	maxToasts     = 4
+)
+
+type toastLevel int
+
+const (
+	toastInfo toastLevel = iota
+	toastWarning
+	toastError
+)
+
+type toastExpiredMsg struct {
+	id int
+}
+
+type toast struct {
+	id      int
+	level   toastLevel
+	title   string
+	message string
+}
+
+// toasts are short lived messages drawn over the top right corner of a view
+type toasts struct {
+	seq   int
+	items []toast
+}
+
+// push shows a toast and returns the command removing it after toastDuration
+func (t *toasts) push(level toastLevel, title, message string) tea.Cmd {
+	t.seq++
+	t.items = append(t.items, toast{id: t.seq, level: level, title: title, message: message})
+	if len(t.items) > maxToasts {
+		t.items = t.items[len(t.items)-maxToasts:]
+	}
+
+	id := t.seq
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	})
+}
+
+// update removes expired toasts and reports if the message was handled
+func (t *toasts) update(msg tea.Msg) bool {
+	expired, ok := msg.(toastExpiredMsg)
+	if !ok {
+		return false
+	}
+	for i, item := range t.items {
+		if item.id == expired.id {
+			t.items = append(t.items[:i], t.items[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (t *toasts) render() string {
+	var boxes []string
+	for i := len(t.items) - 1; i >= 0; i-- {
+		item := t.items[i]
+		color := lipgloss.TerminalColor(Teal)
+		switch item.level {
+		case toastWarning:
+			color = Orange
+		case toastError:
+			color = Red
+		}
+		style := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(color).
+			Padding(0, 1).
+			Width(toastWidth)
+		content := lipgloss.NewStyle().Bold(true).Foreground(color).Render(item.title) + "\n" +
+			wordwrap.String(item.message, toastWidth-4)
+		boxes = append(boxes, style.Render(content))
+	}
+	return lipgloss.JoinVertical(lipgloss.Right, boxes...)
+}
+
+// overlay draws the toasts over the top right corner of view, one line below the top
+func (t *toasts) overlay(view string, width int) string {
+	if len(t.items) == 0 {
+		return view
+	}
+	box := t.render()
+	left := width - lipgloss.Width(box) - 1
+	if left < 0 {
+		return view
+	}
+
+	lines := strings.Split(view, "\n")
+	for i, boxLine := range strings.Split(box, "\n") {
+		row := i + 1
+		for len(lines) <= row {
+			lines = append(lines, "")
+		}
+		line := truncate.String(lines[row], uint(left))
+		if pad := left - lipgloss.Width(line); pad > 0 {
+			line += strings.Repeat(" ", pad)
+		}
+		// reset styles left open by the truncated line before the box starts
+		lines[row] = line + "\x1b[0m" + boxLine
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxWatchBackoff = 5 * time.Minute
+
+type watchTickMsg struct {
+	watcher *watcher
+	seq     int
+}
+
+// watcher schedules periodic refreshes and backs off while the server errors
+type watcher struct {
+	interval time.Duration
+	seq      int
+
+	failures    int
+	lastErr     error
+	lastRefresh time.Time
+}
+
+func newWatcher(interval time.Duration) *watcher {
+	return &watcher{interval: interval}
+}
+
+// enabled reports if polling is turned on
+func (w *watcher) enabled() bool {
+	return w.interval > 0
+}
+
+// next returns the delay before the next refresh, doubling it for every
+// consecutive failure
+func (w *watcher) next() time.Duration {
+	delay := w.interval
+	for i := 0; i < w.failures && delay < maxWatchBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxWatchBackoff {
+		delay = maxWatchBackoff
+	}
+	return delay
+}
+
+// schedule starts a new tick, ticks from earlier schedules are ignored by tick
+func (w *watcher) schedule() tea.Cmd {
+	if !w.enabled() {
+		return nil
+	}
+	w.seq++
+	seq := w.seq
+	return tea.Tick(w.next(), func(time.Time) tea.Msg {
+		return watchTickMsg{watcher: w, seq: seq}
+	})
+}
+
+// tick reports if the message belongs to the latest schedule
+func (w *watcher) tick(msg watchTickMsg) bool {
+	return w.owns(msg) && msg.seq == w.seq
+}
+
+// owns reports if the tick was scheduled by this watcher, views nesting
+// another watching view forward the ticks they do not own
+func (w *watcher) owns(msg watchTickMsg) bool {
+	return msg.watcher == w
+}
+
+// done records the outcome of a refresh
+func (w *watcher) done(err error) {
+	w.lastErr = err
+	if err != nil {
+		w.failures++
+		return
+	}
+	w.failures = 0
+	w.lastRefresh = time.Now()
+}
+
+// renderStatus renders the refresh time and health of the connection
+func (w *watcher) renderStatus() string {
+	refreshed := "never"
+	if !w.lastRefresh.IsZero() {
+		refreshed = w.lastRefresh.Format("15:04:05")
+	}
+
+	health := lipgloss.NewStyle().Foreground(Green).Render("●") + " connected"
+	if w.lastErr != nil {
+		health = lipgloss.NewStyle().Foreground(Red).Render("●") + " error"
+		if w.enabled() {
+			health += ", retry in " + w.next().String()
+		}
+	}
+
+	pairs := []string{"Refreshed", refreshed, "Server", health}
+	if !w.enabled() {
+		pairs = append(pairs, "Watch", "off")
+	}
+	return renderStatusBar(pairs...)
+}